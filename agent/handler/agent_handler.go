@@ -1,12 +1,15 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"loan-module/agent/models"
 	"loan-module/agent/service"
+	"loan-module/apperror"
+	"loan-module/constants"
 )
 
 type AgentHandler struct {
@@ -20,45 +23,224 @@ func NewAgentHandler(agentService *service.AgentService) *AgentHandler {
 func (h *AgentHandler) CreateAgent(c *gin.Context) {
 	var req models.CreateAgentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.InvalidBody(err))
 		return
 	}
 
 	// Validate required fields
 	if req.Name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Name is required"})
+		c.Error(fmt.Errorf("%w: name is required", apperror.ErrInvalidBody))
 		return
 	}
 
 	agent, err := h.agentService.CreateAgent(&req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Agent created successfully", "agent": agent})
 }
 
+func (h *AgentHandler) ListAgents(c *gin.Context) {
+	var filter models.AgentFilter
+	if managerID := c.Query("manager_id"); managerID != "" {
+		id, err := strconv.Atoi(managerID)
+		if err != nil {
+			c.Error(apperror.InvalidParameter("manager ID"))
+			return
+		}
+		filter.ManagerID = &id
+	}
+	if active := c.Query("active"); active != "" {
+		value, err := strconv.ParseBool(active)
+		if err != nil {
+			c.Error(apperror.InvalidParameter("active must be true or false"))
+			return
+		}
+		filter.Active = &value
+	}
+	filter.NamePrefix = c.Query("name")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
+	if page < constants.DefaultMinPage {
+		page = constants.DefaultPage
+	}
+	if size < constants.DefaultMinPageSize || size > constants.DefaultMaxPageSize {
+		size = constants.DefaultPageSize
+	}
+	agents, total, err := h.agentService.ListAgents(filter, page, size)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"agents": agents, "page": page, "size": size, "total": total})
+}
+
+func (h *AgentHandler) GetAgent(c *gin.Context) {
+	id, ok := agentID(c)
+	if !ok {
+		return
+	}
+	agent, err := h.agentService.GetAgent(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, agent)
+}
+
+func (h *AgentHandler) UpdateAgent(c *gin.Context) {
+	id, ok := agentID(c)
+	if !ok {
+		return
+	}
+	var req models.UpdateAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	agent, err := h.agentService.UpdateAgent(id, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Agent updated successfully", "agent": agent})
+}
+
+func (h *AgentHandler) DeactivateAgent(c *gin.Context) {
+	id, ok := agentID(c)
+	if !ok {
+		return
+	}
+	response, err := h.agentService.DeactivateAgent(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *AgentHandler) GetTree(c *gin.Context) {
+	id, ok := agentID(c)
+	if !ok {
+		return
+	}
+	tree, err := h.agentService.GetTree(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, tree)
+}
+
+func (h *AgentHandler) GetLoanReview(c *gin.Context) {
+	agentID, err := strconv.Atoi(c.Param("agent_id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("agent ID"))
+		return
+	}
+	loanID, err := strconv.Atoi(c.Param("loan_id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return
+	}
+	review, err := h.agentService.GetLoanReview(agentID, loanID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
 func (h *AgentHandler) MakeDecision(c *gin.Context) {
 	agentID, err := strconv.Atoi(c.Param("agent_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid agent ID"})
+		c.Error(apperror.InvalidParameter("agent ID"))
 		return
 	}
 	loanID, err := strconv.Atoi(c.Param("loan_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loan ID"})
+		c.Error(apperror.InvalidParameter("loan ID"))
 		return
 	}
 	var req models.AgentDecisionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.InvalidBody(err))
 		return
 	}
 	loan, err := h.agentService.MakeDecision(agentID, loanID, req.Decision)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Decision recorded successfully", "loan": loan})
 }
+
+func (h *AgentHandler) GetWorkQueue(c *gin.Context) {
+	agentID, err := strconv.Atoi(c.Param("agent_id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("agent ID"))
+		return
+	}
+	queue := models.Queue(c.Query("queue"))
+	items, err := h.agentService.GetWorkQueue(agentID, queue)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if queue == "" {
+		queue = models.PersonalQueue
+	}
+	c.JSON(http.StatusOK, gin.H{"queue": queue, "loans": items, "total": len(items)})
+}
+
+func (h *AgentHandler) ClaimLoan(c *gin.Context) {
+	agentID, loanID, ok := agentLoanIDs(c)
+	if !ok {
+		return
+	}
+	loan, err := h.agentService.ClaimLoan(agentID, loanID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Loan claimed successfully", "loan": loan})
+}
+
+func (h *AgentHandler) UnclaimLoan(c *gin.Context) {
+	agentID, loanID, ok := agentLoanIDs(c)
+	if !ok {
+		return
+	}
+	loan, err := h.agentService.UnclaimLoan(agentID, loanID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Loan returned to the team queue", "loan": loan})
+}
+
+func agentID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("agent_id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("agent ID"))
+		return 0, false
+	}
+	return id, true
+}
+
+func agentLoanIDs(c *gin.Context) (int, int, bool) {
+	agentID, err := strconv.Atoi(c.Param("agent_id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("agent ID"))
+		return 0, 0, false
+	}
+	loanID, err := strconv.Atoi(c.Param("loan_id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return 0, 0, false
+	}
+	return agentID, loanID, true
+}
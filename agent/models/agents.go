@@ -1,12 +1,26 @@
 package models
 
-import "time"
+import (
+	"time"
 
+	collateralModels "loan-module/collateral/models"
+	creditModels "loan-module/credit/models"
+	customerModels "loan-module/customer/models"
+	documentModels "loan-module/document/models"
+	loanModels "loan-module/loan/models"
+	"loan-module/money"
+)
+
+// Agent is a loan reviewer. Deactivated agents are kept so that their
+// assignments and decisions stay attributed, but they take no new work.
 type Agent struct {
-	ID        int       `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name      string    `gorm:"not null" json:"name"`
-	ManagerID *int      `gorm:"index;constraint:OnDelete:SET NULL" json:"manager_id,omitempty"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ID            int        `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name          string     `gorm:"not null" json:"name"`
+	ManagerID     *int       `gorm:"index;constraint:OnDelete:SET NULL" json:"manager_id,omitempty"`
+	Active        bool       `gorm:"not null;default:true" json:"active"`
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 type AgentDecisionRequest struct {
@@ -17,3 +31,119 @@ type CreateAgentRequest struct {
 	Name      string `json:"name" binding:"required"`
 	ManagerID *int   `json:"manager_id"`
 }
+
+// UpdateAgentRequest replaces an agent's name and manager. Leaving out
+// manager_id makes the agent top-level.
+type UpdateAgentRequest struct {
+	Name      string `json:"name" binding:"required"`
+	ManagerID *int   `json:"manager_id"`
+}
+
+// AgentFilter narrows the agent list. Empty fields match every agent.
+type AgentFilter struct {
+	ManagerID  *int
+	Active     *bool
+	NamePrefix string
+}
+
+// AgentNode is an agent with everyone reporting directly to them.
+type AgentNode struct {
+	*Agent
+	Reports []*AgentNode `json:"reports"`
+}
+
+// Reassignment records where a deactivated agent's open loan went. AgentID
+// is nil when the loan went back to its team's queue.
+type Reassignment struct {
+	LoanID  int  `json:"loan_id"`
+	AgentID *int `json:"agent_id"`
+}
+
+type DeactivationResponse struct {
+	Agent      *Agent          `json:"agent"`
+	Reassigned []*Reassignment `json:"reassigned"`
+}
+
+// LoanReview is everything an agent sees when reviewing an assigned loan.
+type LoanReview struct {
+	Loan         *loanModels.Loan                  `json:"loan"`
+	Customer     *customerModels.Customer          `json:"customer"`
+	CreditReport *creditModels.CreditReport        `json:"credit_report,omitempty"`
+	Documents    *documentModels.ChecklistResponse `json:"documents"`
+	Collateral   *collateralModels.Summary         `json:"collateral"`
+}
+
+type Queue string
+
+// An agent's personal queue holds the loans assigned to them; their team
+// queue holds the unclaimed loans shared with the team they belong to or
+// manage.
+const (
+	PersonalQueue Queue = "personal"
+	TeamQueue     Queue = "team"
+)
+
+// Risk flags shown on work queue items.
+const (
+	FlagFraudCleared        = "FRAUD_CLEARED"
+	FlagNoCreditHistory     = "NO_CREDIT_HISTORY"
+	FlagLowCreditScore      = "LOW_CREDIT_SCORE"
+	FlagPastDefaults        = "PAST_DEFAULTS"
+	FlagAffordabilityRefer  = "AFFORDABILITY_REFER"
+	FlagAffordabilityReject = "AFFORDABILITY_REJECT"
+	FlagKYCNotVerified      = "KYC_NOT_VERIFIED"
+)
+
+// QueueRow is a loan under review with the customer, fraud, credit and
+// affordability details its work queue item is built from.
+type QueueRow struct {
+	LoanID               int
+	LoanType             loanModels.LoanType
+	LoanAmount           money.Money
+	Currency             money.Currency
+	AssignedAgentID      *int
+	ReviewTeamID         *int
+	CreatedAt            time.Time
+	FirstAssignedAt      time.Time
+	CustomerID           int
+	CustomerName         string
+	CustomerPhone        string
+	KYCStatus            customerModels.KYCStatus `gorm:"column:kyc_status"`
+	EmploymentType       customerModels.EmploymentType
+	MonthlyIncome        money.Money
+	IncomeCurrency       money.Currency
+	FraudStatus          *string
+	HasHistory           *bool
+	Score                *int
+	Defaults             *int
+	AffordabilityOutcome *loanModels.AffordabilityOutcome
+}
+
+type CustomerSummary struct {
+	ID             int                           `json:"id"`
+	Name           string                        `json:"name"`
+	Phone          string                        `json:"phone"`
+	KYCStatus      customerModels.KYCStatus      `json:"kyc_status"`
+	EmploymentType customerModels.EmploymentType `json:"employment_type,omitempty"`
+	MonthlyIncome  money.Money                   `json:"monthly_income"`
+	IncomeCurrency money.Currency                `json:"income_currency,omitempty"`
+}
+
+// QueueItem is a loan in an agent's work queue. Its SLA deadline runs from
+// the loan's first assignment, so claiming a loan does not restart the
+// clock. Priority weighs the SLA, the application's age and the amount.
+type QueueItem struct {
+	LoanID          int                 `json:"loan_id"`
+	LoanType        loanModels.LoanType `json:"loan_type"`
+	LoanAmount      money.Money         `json:"loan_amount"`
+	Currency        money.Currency      `json:"currency"`
+	AssignedAgentID *int                `json:"assigned_agent_id,omitempty"`
+	ReviewTeamID    *int                `json:"review_team_id,omitempty"`
+	Customer        CustomerSummary     `json:"customer"`
+	RiskFlags       []string            `json:"risk_flags"`
+	AppliedAt       time.Time           `json:"applied_at"`
+	AgeHours        float64             `json:"age_hours"`
+	SLADeadline     time.Time           `json:"sla_deadline"`
+	Overdue         bool                `json:"overdue"`
+	Priority        float64             `json:"priority"`
+}
@@ -1,10 +1,31 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"loan-module/agent/models"
+	loanModels "loan-module/loan/models"
 	"loan-module/repository"
 )
 
+var (
+	// ErrAgentInactive is returned when updating or deactivating an agent
+	// who is already inactive.
+	ErrAgentInactive = errors.New("agent is inactive")
+	// ErrManagerNotFound, ErrManagerInactive and ErrManagerCycle are returned
+	// by UpdateAgent for a manager the agent cannot be put under.
+	ErrManagerNotFound = errors.New("manager not found")
+	ErrManagerInactive = errors.New("manager is inactive")
+	ErrManagerCycle    = errors.New("manager reports to the agent")
+	// ErrNoReviewer is returned when deactivating an agent would leave a loan
+	// under review with neither an agent nor a team queue.
+	ErrNoReviewer = errors.New("loan would be left without a reviewer")
+)
+
 type AgentRepository struct {
 	DB *database.Database
 }
@@ -27,29 +48,251 @@ func (r *AgentRepository) GetAgentByID(id int) (*models.Agent, bool) {
 	return &agent, result.Error == nil
 }
 
-func (r *AgentRepository) GetAvailableAgent() *models.Agent {
+// ListAgents returns a page of the agents matching the filter, ordered by
+// ID, and how many match in total.
+func (r *AgentRepository) ListAgents(filter models.AgentFilter, page, size int) ([]*models.Agent, int64, error) {
+	query := r.DB.DB.Model(&models.Agent{})
+	if filter.ManagerID != nil {
+		query = query.Where("manager_id = ?", *filter.ManagerID)
+	}
+	if filter.Active != nil {
+		query = query.Where("active = ?", *filter.Active)
+	}
+	if filter.NamePrefix != "" {
+		query = query.Where("name ILIKE ?", database.PrefixPattern(filter.NamePrefix))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	agents := []*models.Agent{}
+	err := query.Order("id").Offset((page - 1) * size).Limit(size).Find(&agents).Error
+	return agents, total, err
+}
+
+// UpdateAgent saves the agent's name and manager. Every agent row is locked
+// in ID order first, so changes to the hierarchy are made one at a time and
+// the checks see the hierarchy they change: the agent must be active
+// (ErrAgentInactive), and the manager must exist (ErrManagerNotFound), be
+// active (ErrManagerInactive) and not report to the agent, directly or
+// indirectly (ErrManagerCycle).
+func (r *AgentRepository) UpdateAgent(agent *models.Agent) error {
+	return r.DB.DB.Transaction(func(tx *gorm.DB) error {
+		var agents []*models.Agent
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("id").Find(&agents).Error; err != nil {
+			return err
+		}
+		byID := make(map[int]*models.Agent, len(agents))
+		for _, a := range agents {
+			byID[a.ID] = a
+		}
+		stored, exists := byID[agent.ID]
+		if !exists {
+			return gorm.ErrRecordNotFound
+		}
+		if !stored.Active {
+			return ErrAgentInactive
+		}
+		if agent.ManagerID != nil {
+			manager, exists := byID[*agent.ManagerID]
+			if !exists {
+				return ErrManagerNotFound
+			}
+			if !manager.Active {
+				return ErrManagerInactive
+			}
+			// Walk up from the manager; meeting the agent would close a cycle
+			seen := map[int]bool{}
+			for m := manager; m != nil && !seen[m.ID]; {
+				if m.ID == agent.ID {
+					return ErrManagerCycle
+				}
+				seen[m.ID] = true
+				if m.ManagerID == nil {
+					break
+				}
+				m = byID[*m.ManagerID]
+			}
+		}
+		return tx.Model(agent).Select("name", "manager_id", "updated_at").Updates(agent).Error
+	})
+}
+
+// DeactivateAgent marks the agent inactive and hands their place in the
+// hierarchy to their manager: direct reports move up to the manager, and
+// loans waiting in the agent's team queue move to the manager's queue. The
+// loans the agent was reviewing go to the least busy active agent, or back to
+// their team's queue when nobody else is available; a top-level agent's queue
+// has no team to move to, so its loans need an agent too. Everything happens
+// in one transaction, which is rolled back with ErrNoReviewer when a loan
+// would be left without an agent or a team.
+func (r *AgentRepository) DeactivateAgent(agent *models.Agent) ([]*models.Reassignment, error) {
+	now := time.Now()
+	reassigned := []*models.Reassignment{}
+	err := r.DB.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Agent{}).Where("id = ? AND active", agent.ID).
+			Updates(map[string]interface{}{"active": false, "deactivated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAgentInactive
+		}
+		if err := tx.Model(&models.Agent{}).Where("manager_id = ?", agent.ID).
+			Update("manager_id", agent.ManagerID).Error; err != nil {
+			return err
+		}
+
+		var loans []*loanModels.Loan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("application_status = ? AND (assigned_agent_id = ? OR (assigned_agent_id IS NULL AND review_team_id = ?))",
+				loanModels.UnderReview, agent.ID, agent.ID).
+			Order("id").Find(&loans).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&loanModels.Loan{}).
+			Where("review_team_id = ? AND application_status = ?", agent.ID, loanModels.UnderReview).
+			Updates(map[string]interface{}{"review_team_id": agent.ManagerID, "updated_at": now}).Error; err != nil {
+			return err
+		}
+
+		for _, loan := range loans {
+			if loan.AssignedAgentID == nil && agent.ManagerID != nil {
+				continue // waiting in the team queue, which moved to the manager
+			}
+			if target := availableAgent(tx); target != nil {
+				if err := tx.Model(&loanModels.Loan{}).Where("id = ?", loan.ID).Updates(map[string]interface{}{
+					"assigned_agent_id": target.ID,
+					"review_team_id":    target.ManagerID,
+					"updated_at":        now,
+				}).Error; err != nil {
+					return err
+				}
+				if err := tx.Create(&loanModels.LoanAssignment{LoanID: loan.ID, AgentID: target.ID, AssignedAt: now}).Error; err != nil {
+					return err
+				}
+				reassigned = append(reassigned, &models.Reassignment{LoanID: loan.ID, AgentID: &target.ID})
+				continue
+			}
+			// The team queue can take the loan back if it still has a team
+			// once the agent's team has moved to their manager
+			if loan.AssignedAgentID == nil || loan.ReviewTeamID == nil ||
+				(*loan.ReviewTeamID == agent.ID && agent.ManagerID == nil) {
+				return fmt.Errorf("%w: loan %d", ErrNoReviewer, loan.ID)
+			}
+			if err := tx.Model(&loanModels.Loan{}).Where("id = ?", loan.ID).
+				Updates(map[string]interface{}{"assigned_agent_id": nil, "updated_at": now}).Error; err != nil {
+				return err
+			}
+			reassigned = append(reassigned, &models.Reassignment{LoanID: loan.ID})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	agent.Active = false
+	agent.DeactivatedAt = &now
+	return reassigned, nil
+}
+
+// GetAvailableAgent returns the active agent with a manager who has the
+// fewest open loans, skipping the excluded agents.
+func (r *AgentRepository) GetAvailableAgent(exclude ...int) *models.Agent {
+	return availableAgent(r.DB.DB, exclude...)
+}
+
+func availableAgent(db *gorm.DB, exclude ...int) *models.Agent {
 	type AgentLoad struct {
 		ID    int
 		Count int
 	}
 
+	condition := "a.manager_id IS NOT NULL AND a.active"
+	if len(exclude) > 0 {
+		condition += " AND a.id NOT IN @exclude"
+	}
+
 	var loads []AgentLoad
-	r.DB.DB.Raw(`
+	db.Raw(`
         SELECT a.id, COUNT(l.id) as count
         FROM agents a
         LEFT JOIN loans l ON l.assigned_agent_id = a.id 
                            AND l.application_status IN ('PROCESSING', 'UNDER_REVIEW')
-        WHERE a.manager_id IS NOT NULL
+        WHERE `+condition+`
         GROUP BY a.id
         ORDER BY count ASC, a.id ASC
         LIMIT 1
-    `).Scan(&loads)
+    `, map[string]interface{}{"exclude": exclude}).Scan(&loads)
 
 	if len(loads) == 0 {
 		return nil
 	}
 
 	var agent models.Agent
-	r.DB.DB.First(&agent, loads[0].ID)
+	db.First(&agent, loads[0].ID)
 	return &agent
 }
+
+// GetTeam returns every agent reporting to the manager, directly or through
+// other managers, ordered by depth below the manager and then ID. Agents
+// already on the path are skipped, so a cycle in ManagerID cannot loop.
+func (r *AgentRepository) GetTeam(managerID int) []*models.Agent {
+	var agents []*models.Agent
+	r.DB.DB.Raw(`
+		WITH RECURSIVE team AS (
+			SELECT id, ARRAY[manager_id, id] AS path FROM agents WHERE manager_id = ?
+			UNION ALL
+			SELECT a.id, t.path || a.id FROM agents a JOIN team t ON a.manager_id = t.id
+			WHERE a.id <> ALL(t.path)
+		)
+		SELECT a.* FROM agents a
+		JOIN team t ON t.id = a.id
+		ORDER BY cardinality(t.path), a.id
+	`, managerID).Scan(&agents)
+	return agents
+}
+
+// GetQueue returns the loans under review in one of an agent's queues: those
+// assigned to the agent, or the unclaimed loans shared with the given teams.
+func (r *AgentRepository) GetQueue(agentID int, queue models.Queue, teamIDs []int) []*models.QueueRow {
+	rows := []*models.QueueRow{}
+	condition := "l.assigned_agent_id = @agent"
+	if queue == models.TeamQueue {
+		if len(teamIDs) == 0 {
+			return rows
+		}
+		condition = "l.assigned_agent_id IS NULL AND l.review_team_id IN @teams"
+	}
+	r.DB.DB.Raw(`
+		SELECT l.id AS loan_id, l.loan_type, l.loan_amount, l.currency, l.assigned_agent_id, l.review_team_id, l.created_at,
+			COALESCE(a.first_assigned_at, l.created_at) AS first_assigned_at,
+			c.id AS customer_id, c.name AS customer_name, c.phone AS customer_phone,
+			c.kyc_status, c.employment_type, c.monthly_income, c.income_currency,
+			f.status AS fraud_status, cr.has_history, cr.score, cr.defaults, af.outcome AS affordability_outcome
+		FROM loans l
+		JOIN customers c ON c.id = l.customer_id
+		JOIN LATERAL (
+			SELECT MIN(assigned_at) AS first_assigned_at FROM loan_assignments WHERE loan_id = l.id
+		) a ON true
+		LEFT JOIN LATERAL (
+			SELECT status FROM fraud_checks WHERE loan_id = l.id ORDER BY created_at DESC, id DESC LIMIT 1
+		) f ON true
+		LEFT JOIN LATERAL (
+			SELECT has_history, score, defaults FROM credit_reports WHERE loan_id = l.id ORDER BY pulled_at DESC, id DESC LIMIT 1
+		) cr ON true
+		LEFT JOIN LATERAL (
+			SELECT outcome FROM affordability_assessments WHERE loan_id = l.id ORDER BY created_at DESC, id DESC LIMIT 1
+		) af ON true
+		WHERE l.application_status = @under_review AND `+condition, map[string]interface{}{
+		"agent":        agentID,
+		"teams":        teamIDs,
+		"under_review": loanModels.UnderReview,
+	}).Scan(&rows)
+	for _, row := range rows {
+		row.LoanAmount = row.LoanAmount.In(row.Currency)
+		row.MonthlyIncome = row.MonthlyIncome.In(row.IncomeCurrency)
+	}
+	return rows
+}
@@ -2,13 +2,34 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"time"
 
 	"loan-module/agent/models"
 	"loan-module/agent/repository"
+	"loan-module/apperror"
+	collateralService "loan-module/collateral/service"
+	creditService "loan-module/credit/service"
 	customerRepo "loan-module/customer/repository"
+	documentService "loan-module/document/service"
+	"loan-module/exposure"
 	loanModels "loan-module/loan/models"
 	loanRepo "loan-module/loan/repository"
 	"loan-module/notification"
+	"loan-module/pricing"
+	repaymentService "loan-module/repayment/service"
+	"loan-module/underwriting"
+)
+
+var (
+	ErrAgentNotFound    = apperror.NotFound("AGENT_NOT_FOUND", "agent not found")
+	ErrLoanNotFound     = apperror.NotFound("LOAN_NOT_FOUND", "loan not found")
+	ErrCustomerNotFound = apperror.NotFound("CUSTOMER_NOT_FOUND", "customer not found")
+	ErrNotAssigned      = apperror.Forbidden("LOAN_NOT_ASSIGNED", "loan not assigned to this agent")
+	ErrNotUnderReview   = apperror.InvalidTransition("NOT_UNDER_REVIEW", "loan is not under review")
+	ErrApprovalBlocked  = apperror.Unprocessable("APPROVAL_BLOCKED", "cannot approve loan")
+	ErrInvalidDecision  = apperror.Validation("INVALID_DECISION", "invalid decision. Must be APPROVE or REJECT")
 )
 
 type AgentService struct {
@@ -16,6 +37,13 @@ type AgentService struct {
 	loanRepo            *loanRepo.LoanRepository
 	customerRepo        *customerRepo.CustomerRepository
 	notificationService *notification.NotificationService
+	documentService     *documentService.DocumentService
+	exposureChecker     *exposure.Checker
+	creditService       *creditService.CreditService
+	pricingEngine       *pricing.Engine
+	collateralService   *collateralService.CollateralService
+	repaymentService    *repaymentService.RepaymentService
+	thresholds          underwriting.ApprovalThresholds
 }
 
 func NewAgentService(
@@ -23,21 +51,34 @@ func NewAgentService(
 	loanRepo *loanRepo.LoanRepository,
 	customerRepo *customerRepo.CustomerRepository,
 	notificationService *notification.NotificationService,
+	documentService *documentService.DocumentService,
+	exposureChecker *exposure.Checker,
+	creditService *creditService.CreditService,
+	pricingEngine *pricing.Engine,
+	collateralService *collateralService.CollateralService,
+	repaymentService *repaymentService.RepaymentService,
+	thresholds underwriting.ApprovalThresholds,
 ) *AgentService {
 	return &AgentService{
 		repo:                repo,
 		loanRepo:            loanRepo,
 		customerRepo:        customerRepo,
 		notificationService: notificationService,
+		documentService:     documentService,
+		exposureChecker:     exposureChecker,
+		creditService:       creditService,
+		pricingEngine:       pricingEngine,
+		collateralService:   collateralService,
+		repaymentService:    repaymentService,
+		thresholds:          thresholds,
 	}
 }
 
 func (s *AgentService) CreateAgent(req *models.CreateAgentRequest) (*models.Agent, error) {
 	// Validate manager ID if provided
 	if req.ManagerID != nil {
-		_, exists := s.repo.GetAgentByID(*req.ManagerID)
-		if !exists {
-			return nil, errors.New("manager not found")
+		if err := s.checkManager(*req.ManagerID); err != nil {
+			return nil, err
 		}
 	}
 
@@ -45,43 +86,101 @@ func (s *AgentService) CreateAgent(req *models.CreateAgentRequest) (*models.Agen
 	agent := &models.Agent{
 		Name:      req.Name,
 		ManagerID: req.ManagerID,
+		Active:    true,
 	}
 
 	return s.repo.AddAgent(agent)
 }
 
+// GetLoanReview gathers what an agent needs to decide a loan assigned to them.
+func (s *AgentService) GetLoanReview(agentID, loanID int) (*models.LoanReview, error) {
+	loan, exists := s.loanRepo.GetLoanByID(loanID)
+	if !exists {
+		return nil, ErrLoanNotFound
+	}
+	if loan.AssignedAgentID == nil || *loan.AssignedAgentID != agentID {
+		return nil, ErrNotAssigned
+	}
+	customer, exists := s.customerRepo.GetCustomerByID(loan.CustomerID)
+	if !exists {
+		return nil, ErrCustomerNotFound
+	}
+
+	review := &models.LoanReview{
+		Loan:       loan,
+		Customer:   customer,
+		Documents:  s.documentService.BuildChecklist(loan),
+		Collateral: s.collateralService.Summarize(loan),
+	}
+	if report, err := s.creditService.GetReportByLoan(loan.ID); err == nil {
+		review.CreditReport = report
+	}
+	return review, nil
+}
+
 func (s *AgentService) MakeDecision(agentID, loanID int, decision string) (*loanModels.Loan, error) {
 	loan, exists := s.loanRepo.GetLoanByID(loanID)
 	if !exists {
-		return nil, errors.New("loan not found")
+		return nil, ErrLoanNotFound
 	}
 	if loan.AssignedAgentID == nil || *loan.AssignedAgentID != agentID {
-		return nil, errors.New("loan not assigned to this agent")
+		return nil, ErrNotAssigned
 	}
 	if loan.ApplicationStatus != loanModels.UnderReview {
-		return nil, errors.New("loan is not under review")
+		return nil, ErrNotUnderReview
 	}
 	_, exists = s.repo.GetAgentByID(agentID)
 	if !exists {
-		return nil, errors.New("agent not found")
+		return nil, ErrAgentNotFound
 	}
 
 	// Get customer phone for notification
 	customer, customerExists := s.customerRepo.GetCustomerByID(loan.CustomerID)
 	if !customerExists {
-		return nil, errors.New("customer not found")
+		return nil, ErrCustomerNotFound
 	}
 
 	switch decision {
 	case "APPROVE":
+		// Approval needs every required document on file
+		checklist := s.documentService.BuildChecklist(loan)
+		if !checklist.Complete {
+			s.notificationService.SendSMS(customer.Phone, documentService.MissingDocumentsMessage(loan.ID, checklist.Missing))
+			return nil, fmt.Errorf("%w: missing required documents %v", ErrApprovalBlocked, checklist.Missing)
+		}
+		// Secured loans need enough current collateral to stay within the LTV limit
+		if security := s.collateralService.Summarize(loan); !security.WithinLimit {
+			return nil, fmt.Errorf("%w: collateral does not cover the loan %v", ErrApprovalBlocked, security.Issues)
+		}
+		if err := s.exposureChecker.CheckDecision(loan); err != nil {
+			return nil, err
+		}
+		var score pricing.CreditScore
+		score.Score, score.Scored = s.creditService.LoanScore(loan.ID)
+		if _, err := s.pricingEngine.PriceApproval(loan, score); err != nil {
+			return nil, fmt.Errorf("%w: cannot price loan: %w", ErrApprovalBlocked, err)
+		}
 		loan.ApplicationStatus = loanModels.ApprovedByAgent
-		s.notificationService.SendSMS(customer.Phone, "Your loan has been approved by our agent.")
 	case "REJECT":
 		loan.ApplicationStatus = loanModels.RejectedByAgent
-		s.notificationService.SendSMS(customer.Phone, "loan has been rejected after review.")
 	default:
-		return nil, errors.New("invalid decision. Must be APPROVE or REJECT")
+		return nil, ErrInvalidDecision
+	}
+	// Only one decision is saved when two are made at once
+	err := s.loanRepo.UpdateLoan(loan, loanModels.UnderReview)
+	if errors.Is(err, loanRepo.ErrStatusChanged) {
+		return nil, ErrNotUnderReview
+	}
+	if err != nil {
+		return nil, err
+	}
+	if loan.ApplicationStatus == loanModels.ApprovedByAgent {
+		s.notificationService.SendSMSToAll(s.customerRepo.GetLoanPartyPhones(loan.ID), "Your loan has been approved by our agent.")
+		if _, err := s.repaymentService.CreateSchedule(loan, time.Now()); err != nil {
+			log.Printf("Error creating repayment schedule for loan %d: %v", loan.ID, err)
+		}
+	} else {
+		s.notificationService.SendSMSToAll(s.customerRepo.GetLoanPartyPhones(loan.ID), "loan has been rejected after review.")
 	}
-	s.loanRepo.UpdateLoan(loan)
 	return loan, nil
 }
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"loan-module/agent/models"
+	"loan-module/agent/repository"
+	"loan-module/apperror"
+)
+
+var (
+	ErrManagerNotFound  = apperror.Validation("MANAGER_NOT_FOUND", "manager not found")
+	ErrManagerInactive  = apperror.Validation("MANAGER_INACTIVE", "manager is deactivated")
+	ErrManagerCycle     = apperror.Unprocessable("MANAGER_CYCLE", "an agent cannot report to themselves or to anyone in their team")
+	ErrAgentInactive    = apperror.Conflict("AGENT_INACTIVE", "agent is deactivated")
+	ErrNoAgentAvailable = apperror.Conflict("NO_AGENT_AVAILABLE", "no other agent is available to take over the open loans")
+)
+
+func (s *AgentService) ListAgents(filter models.AgentFilter, page, size int) ([]*models.Agent, int64, error) {
+	return s.repo.ListAgents(filter, page, size)
+}
+
+func (s *AgentService) GetAgent(id int) (*models.Agent, error) {
+	agent, exists := s.repo.GetAgentByID(id)
+	if !exists {
+		return nil, ErrAgentNotFound
+	}
+	return agent, nil
+}
+
+// UpdateAgent renames an active agent and moves them under another manager,
+// or to the top of the hierarchy. The new manager must not report to the
+// agent, directly or indirectly.
+func (s *AgentService) UpdateAgent(id int, req *models.UpdateAgentRequest) (*models.Agent, error) {
+	agent, exists := s.repo.GetAgentByID(id)
+	if !exists {
+		return nil, ErrAgentNotFound
+	}
+	if !agent.Active {
+		return nil, ErrAgentInactive
+	}
+
+	agent.Name = req.Name
+	agent.ManagerID = req.ManagerID
+	// The repository checks the manager with the hierarchy locked
+	err := s.repo.UpdateAgent(agent)
+	switch {
+	case errors.Is(err, repository.ErrAgentInactive):
+		return nil, ErrAgentInactive
+	case errors.Is(err, repository.ErrManagerNotFound):
+		return nil, ErrManagerNotFound
+	case errors.Is(err, repository.ErrManagerInactive):
+		return nil, ErrManagerInactive
+	case errors.Is(err, repository.ErrManagerCycle):
+		return nil, ErrManagerCycle
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, ErrAgentNotFound
+	case err != nil:
+		return nil, err
+	}
+	return agent, nil
+}
+
+// DeactivateAgent stops an agent from taking work. Their reports and team
+// queue move to their manager, and the loans they were reviewing go to the
+// least busy active agent, or back to the team queue when nobody else is
+// available. The agent is only deactivated when every loan they hold or
+// queue for can be handed on.
+func (s *AgentService) DeactivateAgent(id int) (*models.DeactivationResponse, error) {
+	agent, exists := s.repo.GetAgentByID(id)
+	if !exists {
+		return nil, ErrAgentNotFound
+	}
+	if !agent.Active {
+		return nil, ErrAgentInactive
+	}
+
+	reassigned, err := s.repo.DeactivateAgent(agent)
+	switch {
+	case errors.Is(err, repository.ErrAgentInactive):
+		return nil, ErrAgentInactive
+	case errors.Is(err, repository.ErrNoReviewer):
+		return nil, fmt.Errorf("%w: %v", ErrNoAgentAvailable, err)
+	case err != nil:
+		return nil, err
+	}
+	for _, reassignment := range reassigned {
+		if reassignment.AgentID != nil {
+			s.notificationService.SendPushNotification(*reassignment.AgentID,
+				fmt.Sprintf("Loan #%d has been reassigned to you for review", reassignment.LoanID))
+		}
+	}
+	return &models.DeactivationResponse{Agent: agent, Reassigned: reassigned}, nil
+}
+
+// GetTree returns the agent with everyone reporting to them, directly or
+// through other managers.
+func (s *AgentService) GetTree(id int) (*models.AgentNode, error) {
+	agent, exists := s.repo.GetAgentByID(id)
+	if !exists {
+		return nil, ErrAgentNotFound
+	}
+	root := &models.AgentNode{Agent: agent, Reports: []*models.AgentNode{}}
+	nodes := map[int]*models.AgentNode{agent.ID: root}
+	// GetTeam orders by depth, so every manager is placed before their reports
+	for _, member := range s.repo.GetTeam(agent.ID) {
+		node := &models.AgentNode{Agent: member, Reports: []*models.AgentNode{}}
+		nodes[member.ID] = node
+		if manager, ok := nodes[*member.ManagerID]; ok {
+			manager.Reports = append(manager.Reports, node)
+		}
+	}
+	return root, nil
+}
+
+// checkManager makes sure an agent can be put under the manager.
+func (s *AgentService) checkManager(managerID int) error {
+	manager, exists := s.repo.GetAgentByID(managerID)
+	if !exists {
+		return ErrManagerNotFound
+	}
+	if !manager.Active {
+		return ErrManagerInactive
+	}
+	return nil
+}
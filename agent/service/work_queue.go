@@ -0,0 +1,194 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"loan-module/agent/models"
+	"loan-module/apperror"
+	"loan-module/constants"
+	customerModels "loan-module/customer/models"
+	fraudModels "loan-module/fraud/models"
+	loanModels "loan-module/loan/models"
+)
+
+var (
+	ErrInvalidQueue     = apperror.Validation("INVALID_QUEUE", "invalid queue. Must be personal or team")
+	ErrLoanNotClaimable = apperror.Conflict("LOAN_NOT_CLAIMABLE", "loan is not waiting in a team queue")
+	ErrNotInTeam        = apperror.Forbidden("NOT_IN_TEAM", "loan is shared with another team")
+)
+
+// Priority weights. A loan at or past its SLA deadline, applied for at least
+// priorityMaxAge ago and at the system approval limit scores 100.
+const (
+	prioritySLAWeight    = 50
+	priorityAgeWeight    = 20
+	priorityAmountWeight = 30
+	priorityMaxAge       = 7 * 24 * time.Hour
+)
+
+// GetWorkQueue lists the loans in one of an agent's queues, most urgent
+// first.
+func (s *AgentService) GetWorkQueue(agentID int, queue models.Queue) ([]*models.QueueItem, error) {
+	if queue == "" {
+		queue = models.PersonalQueue
+	}
+	if queue != models.PersonalQueue && queue != models.TeamQueue {
+		return nil, ErrInvalidQueue
+	}
+	agent, exists := s.repo.GetAgentByID(agentID)
+	if !exists {
+		return nil, ErrAgentNotFound
+	}
+
+	now := time.Now()
+	items := make([]*models.QueueItem, 0)
+	for _, row := range s.repo.GetQueue(agent.ID, queue, teamsOf(agent)) {
+		items = append(items, s.queueItem(row, now))
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Priority != items[j].Priority {
+			return items[i].Priority > items[j].Priority
+		}
+		if !items[i].SLADeadline.Equal(items[j].SLADeadline) {
+			return items[i].SLADeadline.Before(items[j].SLADeadline)
+		}
+		return items[i].LoanID < items[j].LoanID
+	})
+	return items, nil
+}
+
+// teamsOf returns the teams whose queues the agent works: the team they
+// manage and the team they belong to.
+func teamsOf(agent *models.Agent) []int {
+	teams := []int{agent.ID}
+	if agent.ManagerID != nil {
+		teams = append(teams, *agent.ManagerID)
+	}
+	return teams
+}
+
+// ClaimLoan takes an unclaimed loan from the team queue of the agent's team,
+// or of the team they manage.
+func (s *AgentService) ClaimLoan(agentID, loanID int) (*loanModels.Loan, error) {
+	agent, exists := s.repo.GetAgentByID(agentID)
+	if !exists {
+		return nil, ErrAgentNotFound
+	}
+	if !agent.Active {
+		return nil, ErrAgentInactive
+	}
+	loan, exists := s.loanRepo.GetLoanByID(loanID)
+	if !exists {
+		return nil, ErrLoanNotFound
+	}
+	if loan.ApplicationStatus != loanModels.UnderReview || loan.AssignedAgentID != nil || loan.ReviewTeamID == nil {
+		return nil, ErrLoanNotClaimable
+	}
+	if *loan.ReviewTeamID != agent.ID && (agent.ManagerID == nil || *loan.ReviewTeamID != *agent.ManagerID) {
+		return nil, ErrNotInTeam
+	}
+	// The claim re-checks the team, which may have changed since the read
+	claimed, err := s.loanRepo.ClaimLoan(loan.ID, agent.ID, teamsOf(agent))
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrLoanNotClaimable
+	}
+	loan.AssignedAgentID = &agent.ID
+	return loan, nil
+}
+
+// UnclaimLoan returns a loan the agent holds to their team's queue and lets
+// the team's manager know.
+func (s *AgentService) UnclaimLoan(agentID, loanID int) (*loanModels.Loan, error) {
+	loan, exists := s.loanRepo.GetLoanByID(loanID)
+	if !exists {
+		return nil, ErrLoanNotFound
+	}
+	if loan.AssignedAgentID == nil || *loan.AssignedAgentID != agentID || loan.ApplicationStatus != loanModels.UnderReview {
+		return nil, ErrNotAssigned
+	}
+	if loan.ReviewTeamID == nil {
+		return nil, fmt.Errorf("%w: the assigned agent has no team", ErrLoanNotClaimable)
+	}
+	released, err := s.loanRepo.UnclaimLoan(loan.ID, agentID)
+	if err != nil {
+		return nil, err
+	}
+	if !released {
+		return nil, ErrNotAssigned
+	}
+	loan.AssignedAgentID = nil
+	s.notificationService.SendPushNotification(*loan.ReviewTeamID,
+		fmt.Sprintf("Loan #%d was returned to your team's queue by agent %d", loan.ID, agentID))
+	return loan, nil
+}
+
+func (s *AgentService) queueItem(row *models.QueueRow, now time.Time) *models.QueueItem {
+	deadline := row.FirstAssignedAt.Add(constants.ReviewSLA)
+	item := &models.QueueItem{
+		LoanID:          row.LoanID,
+		LoanType:        row.LoanType,
+		LoanAmount:      row.LoanAmount,
+		Currency:        row.Currency,
+		AssignedAgentID: row.AssignedAgentID,
+		ReviewTeamID:    row.ReviewTeamID,
+		Customer: models.CustomerSummary{
+			ID:             row.CustomerID,
+			Name:           row.CustomerName,
+			Phone:          row.CustomerPhone,
+			KYCStatus:      row.KYCStatus,
+			EmploymentType: row.EmploymentType,
+			MonthlyIncome:  row.MonthlyIncome,
+			IncomeCurrency: row.IncomeCurrency,
+		},
+		RiskFlags:   riskFlags(row),
+		AppliedAt:   row.CreatedAt,
+		AgeHours:    math.Round(now.Sub(row.CreatedAt).Hours()*10) / 10,
+		SLADeadline: deadline,
+		Overdue:     now.After(deadline),
+	}
+
+	sla := math.Min(now.Sub(row.FirstAssignedAt).Seconds()/constants.ReviewSLA.Seconds(), 1)
+	age := math.Min(now.Sub(row.CreatedAt).Seconds()/priorityMaxAge.Seconds(), 1)
+	// Loans in a currency without approval thresholds count as mid-sized
+	amount := 0.5
+	if threshold, ok := s.thresholds.For(row.Currency); ok && threshold.Max.IsPositive() {
+		amount = math.Min(row.LoanAmount.Ratio(threshold.Max), 1)
+	}
+	item.Priority = math.Round((prioritySLAWeight*math.Max(sla, 0)+priorityAgeWeight*math.Max(age, 0)+priorityAmountWeight*amount)*10) / 10
+	return item
+}
+
+func riskFlags(row *models.QueueRow) []string {
+	flags := []string{}
+	if row.FraudStatus != nil && *row.FraudStatus == string(fraudModels.Cleared) {
+		flags = append(flags, models.FlagFraudCleared)
+	}
+	switch {
+	case row.HasHistory == nil:
+	case !*row.HasHistory:
+		flags = append(flags, models.FlagNoCreditHistory)
+	case *row.Score < constants.AutoApproveMinCreditScore:
+		flags = append(flags, models.FlagLowCreditScore)
+	}
+	if row.Defaults != nil && *row.Defaults > 0 {
+		flags = append(flags, models.FlagPastDefaults)
+	}
+	if row.AffordabilityOutcome != nil {
+		switch *row.AffordabilityOutcome {
+		case loanModels.AffordabilityRefer:
+			flags = append(flags, models.FlagAffordabilityRefer)
+		case loanModels.AffordabilityReject:
+			flags = append(flags, models.FlagAffordabilityReject)
+		}
+	}
+	if row.KYCStatus != customerModels.KYCVerified {
+		flags = append(flags, models.FlagKYCNotVerified)
+	}
+	return flags
+}
@@ -0,0 +1,134 @@
+// Package apperror defines the errors the API reports to clients. Each error
+// has a kind, which decides the HTTP status, and a machine-readable code.
+// Services declare their sentinel errors with the constructors below and wrap
+// them with fmt.Errorf("%w: ...") to add detail; handlers pass every error to
+// c.Error and Middleware writes it as RFC 7807 problem details.
+package apperror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Kind classifies an error by what the client can do about it.
+type Kind string
+
+const (
+	KindValidation        Kind = "VALIDATION"
+	KindUnauthorized      Kind = "UNAUTHORIZED"
+	KindForbidden         Kind = "FORBIDDEN"
+	KindNotFound          Kind = "NOT_FOUND"
+	KindConflict          Kind = "CONFLICT"
+	KindInvalidTransition Kind = "INVALID_TRANSITION"
+	KindTooLarge          Kind = "TOO_LARGE"
+	KindUnprocessable     Kind = "UNPROCESSABLE"
+	KindRateLimited       Kind = "RATE_LIMITED"
+	KindInternal          Kind = "INTERNAL"
+)
+
+// Status returns the HTTP status errors of the kind are reported with.
+func (k Kind) Status() int {
+	switch k {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict, KindInvalidTransition:
+		return http.StatusConflict
+	case KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case KindUnprocessable:
+		return http.StatusUnprocessableEntity
+	case KindRateLimited:
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is a problem the client is told about. Errors are compared by
+// identity, so declare each one once as a sentinel.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// Validation is for requests that are malformed or break a field rule.
+func Validation(code, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+// Unauthorized is for requests that do not say who is making them.
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+// Forbidden is for callers who may not act on the resource.
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+// NotFound is for resources that do not exist.
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+// Conflict is for requests that clash with existing data, such as a
+// duplicate.
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+// InvalidTransition is for actions the resource's current state does not
+// allow, such as deciding a loan that is not under review.
+func InvalidTransition(code, message string) *Error {
+	return New(KindInvalidTransition, code, message)
+}
+
+// TooLarge is for request bodies over a size limit.
+func TooLarge(code, message string) *Error {
+	return New(KindTooLarge, code, message)
+}
+
+// Unprocessable is for well-formed requests that a business rule turns
+// down, such as an exposure limit.
+func Unprocessable(code, message string) *Error {
+	return New(KindUnprocessable, code, message)
+}
+
+// RateLimited is for requests made too often.
+func RateLimited(code, message string) *Error {
+	return New(KindRateLimited, code, message)
+}
+
+var (
+	ErrInvalidBody      = Validation("INVALID_BODY", "invalid request body")
+	ErrInvalidParameter = Validation("INVALID_PARAMETER", "invalid parameter")
+	ErrRouteNotFound    = NotFound("ROUTE_NOT_FOUND", "no such endpoint")
+	// ErrInternal stands in for errors that are not an *Error, whose text is
+	// never shown to clients.
+	ErrInternal = New(KindInternal, "INTERNAL_ERROR", "internal server error")
+)
+
+// InvalidBody reports a request body that could not be bound.
+func InvalidBody(err error) error {
+	return fmt.Errorf("%w: %v", ErrInvalidBody, err)
+}
+
+// InvalidParameter reports a path or query parameter that could not be
+// read, such as "loan ID".
+func InvalidParameter(detail string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidParameter, detail)
+}
@@ -0,0 +1,110 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errWidget = NotFound("WIDGET_NOT_FOUND", "widget not found")
+
+type ruleError struct{ rule string }
+
+func (e *ruleError) Error() string { return "rule " + e.rule + " breached" }
+func (e *ruleError) Unwrap() error { return Unprocessable("LIMIT", "limit exceeded") }
+func (e *ruleError) ProblemExtensions() map[string]interface{} {
+	return map[string]interface{}{"rule": e.rule, "status": 999}
+}
+
+func TestProblemFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		typ    string
+		title  string
+		detail string
+	}{
+		{"typed", errWidget, 404, "WIDGET_NOT_FOUND", "urn:loan-module:problem:widget-not-found", "widget not found", "widget not found"},
+		{"wrapped", fmt.Errorf("%w: id 7", errWidget), 404, "WIDGET_NOT_FOUND", "urn:loan-module:problem:widget-not-found", "widget not found", "widget not found: id 7"},
+		{"untyped", errors.New("connection refused"), 500, "INTERNAL_ERROR", "urn:loan-module:problem:internal-error", "internal server error", "internal server error"},
+		{"body", InvalidBody(errors.New("EOF")), 400, "INVALID_BODY", "urn:loan-module:problem:invalid-body", "invalid request body", "invalid request body: EOF"},
+		{"transition", InvalidTransition("LOAN_DECIDED", "loan already decided"), 409, "LOAN_DECIDED", "urn:loan-module:problem:loan-decided", "loan already decided", "loan already decided"},
+	}
+	for _, tt := range tests {
+		p := ProblemFor(tt.err)
+		if p.Status != tt.status || p.Code != tt.code || p.Type != tt.typ || p.Title != tt.title || p.Detail != tt.detail {
+			t.Errorf("%s: ProblemFor = %+v, want %d %s %s %q %q", tt.name, p, tt.status, tt.code, tt.typ, tt.title, tt.detail)
+		}
+	}
+}
+
+func TestProblemExtensions(t *testing.T) {
+	p := ProblemFor(fmt.Errorf("checking: %w", &ruleError{rule: "MAX_OPEN"}))
+	if p.Status != http.StatusUnprocessableEntity || p.Detail != "checking: rule MAX_OPEN breached" {
+		t.Fatalf("ProblemFor = %+v", p)
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"urn:loan-module:problem:limit","title":"limit exceeded","status":422,"detail":"checking: rule MAX_OPEN breached","code":"LIMIT","rule":"MAX_OPEN"}`
+	if string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Recovery(), Middleware())
+	router.NoRoute(NoRoute)
+	router.GET("/widgets/:id", func(c *gin.Context) {
+		c.Error(fmt.Errorf("%w: id %s", errWidget, c.Param("id")))
+	})
+	router.GET("/ok", func(c *gin.Context) {
+		c.Error(errors.New("ignored"))
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		code   string
+	}{
+		{"/widgets/7", 404, "WIDGET_NOT_FOUND"},
+		{"/nowhere", 404, "ROUTE_NOT_FOUND"},
+		{"/panic", 500, "INTERNAL_ERROR"},
+		{"/ok", 200, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.status {
+			t.Errorf("GET %s: status %d, want %d", tt.path, w.Code, tt.status)
+			continue
+		}
+		if tt.code == "" {
+			continue
+		}
+		if got := w.Header().Get("Content-Type"); got != ContentType {
+			t.Errorf("GET %s: Content-Type %q, want %q", tt.path, got, ContentType)
+		}
+		var p Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatalf("GET %s: %v", tt.path, err)
+		}
+		if p.Code != tt.code || p.Status != tt.status || p.Instance != tt.path {
+			t.Errorf("GET %s: problem %+v, want %s", tt.path, p, tt.code)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package apperror
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware reports the last error a handler added with c.Error as problem
+// details, unless the handler already wrote a response. Internal errors are
+// logged with their cause, which the client does not see.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		Respond(c, c.Errors.Last().Err)
+	}
+}
+
+// Recovery turns a panic in a handler into an internal error response.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		Respond(c, fmt.Errorf("panic: %v", recovered))
+	})
+}
+
+// NoRoute reports requests for unknown endpoints.
+func NoRoute(c *gin.Context) {
+	c.Error(ErrRouteNotFound)
+}
+
+// Respond writes err as problem details and stops the handler chain.
+func Respond(c *gin.Context, err error) {
+	problem := ProblemFor(err)
+	problem.Instance = c.Request.URL.Path
+	if problem.Code == ErrInternal.Code {
+		log.Printf("Error handling %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	}
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
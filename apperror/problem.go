@@ -0,0 +1,92 @@
+package apperror
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ContentType is the media type of problem details.
+const ContentType = "application/problem+json"
+
+// typePrefix starts the URI identifying each problem type; the code follows
+// in lower case.
+const typePrefix = "urn:loan-module:problem:"
+
+// Extender is implemented by errors that add members to their problem
+// details, such as the exposure rule a limit error breached.
+type Extender interface {
+	ProblemExtensions() map[string]interface{}
+}
+
+// Problem is an RFC 7807 problem details object. Code repeats the error
+// code for clients that do not parse Type; Extensions become additional
+// members.
+type Problem struct {
+	Type       string                 `json:"type"`
+	Title      string                 `json:"title"`
+	Status     int                    `json:"status"`
+	Detail     string                 `json:"detail,omitempty"`
+	Instance   string                 `json:"instance,omitempty"`
+	Code       string                 `json:"code"`
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// ProblemFor describes err to a client. The title comes from the *Error the
+// error wraps and the detail from the full error text. Errors that wrap no
+// *Error are internal and report nothing about their cause.
+func ProblemFor(err error) *Problem {
+	var appErr *Error
+	if !errors.As(err, &appErr) || appErr.Kind == KindInternal {
+		appErr, err = ErrInternal, ErrInternal
+	}
+	problem := &Problem{
+		Type:   typePrefix + strings.ToLower(strings.ReplaceAll(appErr.Code, "_", "-")),
+		Title:  appErr.Message,
+		Status: appErr.Kind.Status(),
+		Detail: err.Error(),
+		Code:   appErr.Code,
+	}
+	var extender Extender
+	if errors.As(err, &extender) {
+		problem.Extensions = extender.ProblemExtensions()
+	}
+	return problem
+}
+
+// MarshalJSON writes the standard members first and the extensions after
+// them, sorted by name. Extensions never replace a standard member.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type standard Problem
+	data, err := json.Marshal((*standard)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+	names := make([]string, 0, len(p.Extensions))
+	for name := range p.Extensions {
+		switch name {
+		case "type", "title", "status", "detail", "instance", "code":
+		default:
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for _, name := range names {
+		key, _ := json.Marshal(name)
+		value, err := json.Marshal(p.Extensions[name])
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteByte(',')
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
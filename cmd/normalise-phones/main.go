@@ -0,0 +1,46 @@
+// Command normalise-phones rewrites the phones stored before normalisation
+// was introduced in E.164, reading national numbers in the configured
+// phone.defaultRegion. Customers whose phones turn out to be the same number
+// are left unchanged and listed for merging; run the command again once they
+// have been merged. Run it from the directory holding
+// loan-module-configuration.yaml.
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	customerRepo "loan-module/customer/repository"
+	"loan-module/phone"
+	"loan-module/providers"
+	"loan-module/repository"
+)
+
+func main() {
+	config, err := providers.GetConfig("loan-module-configuration.yaml")
+	if err != nil {
+		log.Fatal("Failed to load configuration: ", err)
+	}
+	phones, err := phone.NewNormalizer(config.Phone.DefaultRegion)
+	if err != nil {
+		log.Fatal("Invalid phone configuration: ", err)
+	}
+
+	db := database.NewDatabaseWithConfig(config)
+	result, err := customerRepo.NewCustomerRepository(db).NormalisePhones(phones)
+	if err != nil {
+		log.Fatal("Failed to normalise phones: ", err)
+	}
+	log.Printf("Normalised %d customer and %d quote phones; %d collisions to merge, %d invalid phones",
+		result.CustomersUpdated, result.QuotesUpdated, len(result.Collisions), len(result.Invalid))
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		log.Fatal(err)
+	}
+	if len(result.Collisions) > 0 || len(result.Invalid) > 0 {
+		os.Exit(1)
+	}
+}
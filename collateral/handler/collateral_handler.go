@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"loan-module/apperror"
+	"loan-module/collateral/models"
+	"loan-module/collateral/service"
+)
+
+type CollateralHandler struct {
+	collateralService *service.CollateralService
+}
+
+func NewCollateralHandler(collateralService *service.CollateralService) *CollateralHandler {
+	return &CollateralHandler{collateralService: collateralService}
+}
+
+func (h *CollateralHandler) AddCollateral(c *gin.Context) {
+	loanID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return
+	}
+	var req models.AddCollateralRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	collateral, err := h.collateralService.AddCollateral(loanID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, collateral)
+}
+
+func (h *CollateralHandler) GetCollateral(c *gin.Context) {
+	loanID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return
+	}
+	summary, err := h.collateralService.GetSummary(loanID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+func (h *CollateralHandler) AddValuation(c *gin.Context) {
+	loanID, collateralID, ok := parseIDs(c)
+	if !ok {
+		return
+	}
+	var req models.AddValuationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	valuation, err := h.collateralService.AddValuation(loanID, collateralID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, valuation)
+}
+
+func (h *CollateralHandler) UpdateLien(c *gin.Context) {
+	loanID, collateralID, ok := parseIDs(c)
+	if !ok {
+		return
+	}
+	var req models.UpdateLienRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	collateral, err := h.collateralService.UpdateLien(loanID, collateralID, req.LienStatus)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, collateral)
+}
+
+func parseIDs(c *gin.Context) (int, int, bool) {
+	loanID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return 0, 0, false
+	}
+	collateralID, err := strconv.Atoi(c.Param("collateral_id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("collateral ID"))
+		return 0, 0, false
+	}
+	return loanID, collateralID, true
+}
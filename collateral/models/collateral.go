@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"loan-module/money"
+)
+
+type AssetType string
+
+const (
+	Property AssetType = "PROPERTY"
+	Vehicle  AssetType = "VEHICLE"
+	Deposit  AssetType = "DEPOSIT"
+)
+
+func (t AssetType) IsValid() bool {
+	switch t {
+	case Property, Vehicle, Deposit:
+		return true
+	}
+	return false
+}
+
+type LienStatus string
+
+const (
+	LienNone     LienStatus = "NONE"
+	LienPending  LienStatus = "PENDING"
+	LienMarked   LienStatus = "MARKED"
+	LienReleased LienStatus = "RELEASED"
+)
+
+func (s LienStatus) IsValid() bool {
+	switch s {
+	case LienNone, LienPending, LienMarked, LienReleased:
+		return true
+	}
+	return false
+}
+
+// Collateral is an asset pledged as security for a loan.
+type Collateral struct {
+	ID          int          `gorm:"primaryKey" json:"id"`
+	LoanID      int          `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"loan_id"`
+	AssetType   AssetType    `gorm:"type:varchar(20);not null" json:"asset_type"`
+	Description string       `json:"description,omitempty"`
+	Identifier  string       `gorm:"type:varchar(100);not null" json:"identifier"`
+	OwnerName   string       `gorm:"not null" json:"owner_name"`
+	LienStatus  LienStatus   `gorm:"type:varchar(20);not null" json:"lien_status"`
+	Valuations  []*Valuation `gorm:"foreignKey:CollateralID" json:"valuations"`
+	CreatedAt   time.Time    `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time    `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// LatestValuation returns the most recent valuation, or nil if the asset has
+// not been valued.
+func (c *Collateral) LatestValuation() *Valuation {
+	var latest *Valuation
+	for _, valuation := range c.Valuations {
+		if latest == nil || valuation.ValuedOn.After(latest.ValuedOn) {
+			latest = valuation
+		}
+	}
+	return latest
+}
+
+type Valuation struct {
+	ID           int         `gorm:"primaryKey" json:"id"`
+	CollateralID int         `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"collateral_id"`
+	Value        money.Money `gorm:"not null" json:"value"`
+	ValuedOn     time.Time   `gorm:"type:date;not null" json:"valued_on"`
+	Valuer       string      `gorm:"not null" json:"valuer"`
+	CreatedAt    time.Time   `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type AddCollateralRequest struct {
+	AssetType   AssetType `json:"asset_type" binding:"required"`
+	Description string    `json:"description"`
+	Identifier  string    `json:"identifier" binding:"required"`
+	OwnerName   string    `json:"owner_name" binding:"required"`
+}
+
+type AddValuationRequest struct {
+	Value    money.Money `json:"value"`
+	ValuedOn string      `json:"valued_on" binding:"required"`
+	Valuer   string      `json:"valuer" binding:"required"`
+}
+
+type UpdateLienRequest struct {
+	LienStatus LienStatus `json:"lien_status" binding:"required"`
+}
+
+// Summary is the LTV position of a loan's collateral. Only collateral with a
+// current valuation and an unreleased lien counts towards SecuredValue.
+type Summary struct {
+	LoanID       int           `json:"loan_id"`
+	LoanAmount   money.Money   `json:"loan_amount"`
+	Secured      bool          `json:"secured"`
+	SecuredValue money.Money   `json:"secured_value"`
+	LTV          *float64      `json:"ltv,omitempty"`
+	MaxLTV       float64       `json:"max_ltv,omitempty"`
+	WithinLimit  bool          `json:"within_limit"`
+	Issues       []string      `json:"issues"`
+	Collateral   []*Collateral `json:"collateral"`
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"loan-module/collateral/models"
+	"loan-module/repository"
+)
+
+type CollateralRepository struct {
+	db *database.Database
+}
+
+func NewCollateralRepository(db *database.Database) *CollateralRepository {
+	return &CollateralRepository{db: db}
+}
+
+func (r *CollateralRepository) AddCollateral(collateral *models.Collateral) error {
+	return r.db.DB.Create(collateral).Error
+}
+
+func (r *CollateralRepository) UpdateLienStatus(id int, status models.LienStatus) error {
+	return r.db.DB.Model(&models.Collateral{}).Where("id = ?", id).Update("lien_status", status).Error
+}
+
+func (r *CollateralRepository) AddValuation(valuation *models.Valuation) error {
+	return r.db.DB.Create(valuation).Error
+}
+
+func (r *CollateralRepository) GetCollateral(loanID, id int) (*models.Collateral, bool) {
+	var collateral models.Collateral
+	result := r.db.DB.Preload("Valuations").Where("loan_id = ?", loanID).First(&collateral, id)
+	return &collateral, result.Error == nil
+}
+
+func (r *CollateralRepository) GetCollateralByLoan(loanID int) []*models.Collateral {
+	var collateral []*models.Collateral
+	r.db.DB.Preload("Valuations").Where("loan_id = ?", loanID).Order("id ASC").Find(&collateral)
+	return collateral
+}
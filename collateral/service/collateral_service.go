@@ -0,0 +1,208 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"loan-module/apperror"
+	"loan-module/collateral/models"
+	"loan-module/collateral/repository"
+	"loan-module/constants"
+	loanModels "loan-module/loan/models"
+	loanRepo "loan-module/loan/repository"
+	"loan-module/money"
+	repaymentService "loan-module/repayment/service"
+	"loan-module/underwriting"
+)
+
+var (
+	ErrLoanNotFound       = apperror.NotFound("LOAN_NOT_FOUND", "loan not found")
+	ErrCollateralNotFound = apperror.NotFound("COLLATERAL_NOT_FOUND", "collateral not found")
+	ErrUnsecuredLoan      = apperror.Unprocessable("UNSECURED_LOAN", "loan type does not take collateral")
+	ErrLoanDecided        = apperror.InvalidTransition("LOAN_DECIDED", "loan has already been decided")
+	ErrInvalidCollateral  = apperror.Validation("INVALID_COLLATERAL", "invalid collateral")
+)
+
+type CollateralService struct {
+	repo             *repository.CollateralRepository
+	loanRepo         *loanRepo.LoanRepository
+	repaymentService *repaymentService.RepaymentService
+	ltvLimits        underwriting.LTVLimits
+}
+
+func NewCollateralService(repo *repository.CollateralRepository, loanRepo *loanRepo.LoanRepository, repaymentService *repaymentService.RepaymentService, ltvLimits underwriting.LTVLimits) *CollateralService {
+	return &CollateralService{repo: repo, loanRepo: loanRepo, repaymentService: repaymentService, ltvLimits: ltvLimits}
+}
+
+func (s *CollateralService) AddCollateral(loanID int, req *models.AddCollateralRequest) (*models.Collateral, error) {
+	loan, err := s.openSecuredLoan(loanID)
+	if err != nil {
+		return nil, err
+	}
+	if !req.AssetType.IsValid() {
+		return nil, fmt.Errorf("%w: unknown asset type %q", ErrInvalidCollateral, req.AssetType)
+	}
+
+	collateral := &models.Collateral{
+		LoanID:      loan.ID,
+		AssetType:   req.AssetType,
+		Description: req.Description,
+		Identifier:  req.Identifier,
+		OwnerName:   req.OwnerName,
+		LienStatus:  models.LienPending,
+	}
+	if err := s.repo.AddCollateral(collateral); err != nil {
+		return nil, err
+	}
+	collateral.Valuations = []*models.Valuation{}
+	return collateral, nil
+}
+
+func (s *CollateralService) AddValuation(loanID, collateralID int, req *models.AddValuationRequest) (*models.Valuation, error) {
+	if _, err := s.openSecuredLoan(loanID); err != nil {
+		return nil, err
+	}
+	collateral, exists := s.repo.GetCollateral(loanID, collateralID)
+	if !exists {
+		return nil, ErrCollateralNotFound
+	}
+	valuedOn, err := time.Parse("2006-01-02", req.ValuedOn)
+	if err != nil {
+		return nil, fmt.Errorf("%w: valued_on must be YYYY-MM-DD", ErrInvalidCollateral)
+	}
+	if valuedOn.After(time.Now()) {
+		return nil, fmt.Errorf("%w: valued_on cannot be in the future", ErrInvalidCollateral)
+	}
+
+	valuation := &models.Valuation{
+		CollateralID: collateral.ID,
+		Value:        req.Value,
+		ValuedOn:     valuedOn,
+		Valuer:       req.Valuer,
+	}
+	if err := s.repo.AddValuation(valuation); err != nil {
+		return nil, err
+	}
+	return valuation, nil
+}
+
+// UpdateLien records a change in the lien over an asset. A released lien no
+// longer secures the loan.
+func (s *CollateralService) UpdateLien(loanID, collateralID int, status models.LienStatus) (*models.Collateral, error) {
+	if !status.IsValid() {
+		return nil, fmt.Errorf("%w: unknown lien status %q", ErrInvalidCollateral, status)
+	}
+	if _, exists := s.loanRepo.GetLoanByID(loanID); !exists {
+		return nil, ErrLoanNotFound
+	}
+	collateral, exists := s.repo.GetCollateral(loanID, collateralID)
+	if !exists {
+		return nil, ErrCollateralNotFound
+	}
+	if err := s.repo.UpdateLienStatus(collateral.ID, status); err != nil {
+		return nil, err
+	}
+	collateral.LienStatus = status
+	return collateral, nil
+}
+
+func (s *CollateralService) GetSummary(loanID int) (*models.Summary, error) {
+	loan, exists := s.loanRepo.GetLoanByID(loanID)
+	if !exists {
+		return nil, ErrLoanNotFound
+	}
+	return s.Summarize(loan), nil
+}
+
+// Summarize works out the LTV of a loan from its collateral. Each asset counts
+// at its latest valuation, provided that valuation is recent enough and the
+// lien over the asset has not been released. A top-up is secured by its
+// parent loan's collateral, against what is owed on the parent and on every
+// active top-up of it.
+func (s *CollateralService) Summarize(loan *loanModels.Loan) *models.Summary {
+	securedLoanID, amount := loan.ID, loan.LoanAmount
+	if loan.ParentLoanID != nil {
+		if parent, exists := s.loanRepo.GetLoanByID(*loan.ParentLoanID); exists {
+			securedLoanID = parent.ID
+			amount = s.owed(parent)
+			counted := false
+			for _, topUp := range s.loanRepo.GetActiveTopUps(parent.ID) {
+				amount = amount.Add(s.owed(topUp))
+				counted = counted || topUp.ID == loan.ID
+			}
+			if !counted {
+				amount = amount.Add(loan.LoanAmount)
+			}
+		}
+	}
+	collateral := s.repo.GetCollateralByLoan(securedLoanID)
+	summary := &models.Summary{
+		LoanID:     loan.ID,
+		LoanAmount: amount,
+		Issues:     []string{},
+		Collateral: collateral,
+	}
+	maxLTV, secured := s.ltvLimits.For(loan.LoanType)
+	summary.Secured = secured
+	if !secured {
+		summary.WithinLimit = true
+		return summary
+	}
+	summary.MaxLTV = maxLTV
+
+	cutoff := time.Now().Add(-constants.CollateralValuationMaxAge)
+	for _, item := range collateral {
+		if item.LienStatus == models.LienReleased {
+			summary.Issues = append(summary.Issues, fmt.Sprintf("collateral %d: lien released", item.ID))
+			continue
+		}
+		valuation := item.LatestValuation()
+		if valuation == nil {
+			summary.Issues = append(summary.Issues, fmt.Sprintf("collateral %d: not valued", item.ID))
+			continue
+		}
+		if valuation.ValuedOn.Before(cutoff) {
+			summary.Issues = append(summary.Issues, fmt.Sprintf("collateral %d: valuation of %s is out of date",
+				item.ID, valuation.ValuedOn.Format("2006-01-02")))
+			continue
+		}
+		summary.SecuredValue = summary.SecuredValue.Add(valuation.Value)
+	}
+
+	if summary.SecuredValue.IsZero() {
+		summary.Issues = append(summary.Issues, "no valued collateral registered")
+		return summary
+	}
+	ltv := amount.Ratio(summary.SecuredValue)
+	summary.LTV = &ltv
+	summary.WithinLimit = ltv <= maxLTV
+	if !summary.WithinLimit {
+		summary.Issues = append(summary.Issues, fmt.Sprintf("LTV %.2f exceeds limit %.2f", ltv, maxLTV))
+	}
+	return summary
+}
+
+// owed is what a loan counts for against its collateral: the outstanding
+// principal once it is being repaid, and the full amount before that.
+func (s *CollateralService) owed(loan *loanModels.Loan) money.Money {
+	if standing, err := s.repaymentService.GetStanding(loan.ID); err == nil {
+		return standing.OutstandingPrincipal.In(loan.Currency)
+	}
+	return loan.LoanAmount
+}
+
+// openSecuredLoan returns the loan if it takes collateral and is still open
+// for changes.
+func (s *CollateralService) openSecuredLoan(loanID int) (*loanModels.Loan, error) {
+	loan, exists := s.loanRepo.GetLoanByID(loanID)
+	if !exists {
+		return nil, ErrLoanNotFound
+	}
+	if _, secured := s.ltvLimits.For(loan.LoanType); !secured {
+		return nil, ErrUnsecuredLoan
+	}
+	if !loan.ApplicationStatus.IsOpen() {
+		return nil, ErrLoanDecided
+	}
+	return loan, nil
+}
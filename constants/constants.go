@@ -1,16 +1,66 @@
 package constants
 
-import "time"
+import (
+	"time"
+
+	"loan-module/money"
+)
 
 const Workers = 5
 const ChannelBufferSize = 100
 
 const TimeIntervalToFeedJobs = 5 * time.Second
-const MinAmountApproveBySystem = 10000
-const MaxAmountApproveBySystem = 500000
+
+var MinAmountApproveBySystem = money.FromUnits(10000, money.DefaultCurrency)
+var MaxAmountApproveBySystem = money.FromUnits(500000, money.DefaultCurrency)
 
 const DefaultPage = 1
 const DefaultPageSize = 10
 const DefaultMinPage = 1
 const DefaultMaxPageSize = 10
 const DefaultMinPageSize = 1
+
+const KYCValidityPeriod = 365 * 24 * time.Hour
+
+const MaxDocumentSize = 10 << 20
+const DefaultDocumentStoragePath = "./data/documents"
+
+const CreditReportTTL = 30 * 24 * time.Hour
+const MinCreditScore = 550
+const AutoApproveMinCreditScore = 700
+
+const FraudVelocityWindow = 24 * time.Hour
+const FraudVelocityMaxApplications = 3
+const FraudThresholdMargin = 0.05
+const FraudNameMaxDistance = 3
+const FraudReviewScore = 30
+
+const QuoteValidity = 7 * 24 * time.Hour
+
+const CollateralValuationMaxAge = 180 * 24 * time.Hour
+
+// TopUpMinPaidInstalments is how many instalments a loan must have repaid
+// before its customer can take a top-up on it.
+const TopUpMinPaidInstalments = 6
+
+const DefaultTopCustomers = 3
+const MaxTopCustomers = 50
+
+// ReviewSLA is how long an agent has to decide a loan once it is assigned.
+const ReviewSLA = 48 * time.Hour
+
+// DefaultReportPeriod is the date range reports cover when none is given.
+const DefaultReportPeriod = 30 * 24 * time.Hour
+const MaxReportPeriod = 366 * 24 * time.Hour
+
+// OverviewNotifications is how many of the latest notifications a customer
+// overview lists.
+const OverviewNotifications = 50
+
+// OTPs sent to verify a customer's phone are OTPLength digits long, valid for
+// OTPValidity and allow OTPMaxAttempts wrong guesses. A new one can only be
+// requested OTPResendInterval after the last.
+const OTPLength = 6
+const OTPValidity = 10 * time.Minute
+const OTPMaxAttempts = 5
+const OTPResendInterval = time.Minute
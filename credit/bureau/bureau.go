@@ -0,0 +1,37 @@
+package bureau
+
+import (
+	"context"
+	"time"
+
+	"loan-module/money"
+)
+
+// Request identifies the customer whose credit file is requested.
+type Request struct {
+	CustomerID  int
+	FullName    string
+	Phone       string
+	NationalID  string
+	DateOfBirth *time.Time
+}
+
+// Report is the bureau's view of a customer's credit file. HasHistory is
+// false for customers the bureau has no record of. Amounts are in Currency,
+// which is empty when the bureau does not say.
+type Report struct {
+	Reference          string
+	HasHistory         bool
+	Score              int
+	Currency           money.Currency
+	MonthlyObligations money.Money
+	OutstandingBalance money.Money
+	ActiveAccounts     int
+	Defaults           int
+}
+
+// CreditBureau fetches credit reports from a credit bureau.
+type CreditBureau interface {
+	Name() string
+	FetchReport(ctx context.Context, req *Request) (*Report, error)
+}
@@ -0,0 +1,85 @@
+package bureau
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"loan-module/money"
+)
+
+type fileEntry struct {
+	Phone              string         `json:"phone"`
+	NationalID         string         `json:"national_id"`
+	Score              int            `json:"score"`
+	Currency           money.Currency `json:"currency"`
+	MonthlyObligations money.Money    `json:"monthly_obligations"`
+	OutstandingBalance money.Money    `json:"outstanding_balance"`
+	ActiveAccounts     int            `json:"active_accounts"`
+	Defaults           int            `json:"defaults"`
+}
+
+// FileBureau is a development CreditBureau backed by a JSON file of credit
+// files keyed by national ID or phone. Customers not in the file are reported
+// as having no credit history.
+type FileBureau struct {
+	byNationalID map[string]*fileEntry
+	byPhone      map[string]*fileEntry
+}
+
+func NewFileBureau(path string) (*FileBureau, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credit bureau file: %w", err)
+	}
+
+	var entries []*fileEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse credit bureau file: %w", err)
+	}
+
+	b := &FileBureau{
+		byNationalID: make(map[string]*fileEntry),
+		byPhone:      make(map[string]*fileEntry),
+	}
+	for _, entry := range entries {
+		if entry.NationalID != "" {
+			b.byNationalID[strings.ToUpper(entry.NationalID)] = entry
+		}
+		if entry.Phone != "" {
+			b.byPhone[entry.Phone] = entry
+		}
+	}
+	return b, nil
+}
+
+func (b *FileBureau) Name() string {
+	return "file-stub"
+}
+
+func (b *FileBureau) FetchReport(ctx context.Context, req *Request) (*Report, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	entry := b.byNationalID[strings.ToUpper(req.NationalID)]
+	if entry == nil {
+		entry = b.byPhone[req.Phone]
+	}
+	if entry == nil {
+		return &Report{Reference: fmt.Sprintf("FILE-NOHIT-%d", req.CustomerID)}, nil
+	}
+
+	return &Report{
+		Reference:          fmt.Sprintf("FILE-%d", req.CustomerID),
+		HasHistory:         true,
+		Score:              entry.Score,
+		Currency:           entry.Currency,
+		MonthlyObligations: entry.MonthlyObligations.In(entry.Currency),
+		OutstandingBalance: entry.OutstandingBalance.In(entry.Currency),
+		ActiveAccounts:     entry.ActiveAccounts,
+		Defaults:           entry.Defaults,
+	}, nil
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"loan-module/apperror"
+	"loan-module/credit/service"
+)
+
+type CreditHandler struct {
+	creditService *service.CreditService
+}
+
+func NewCreditHandler(creditService *service.CreditService) *CreditHandler {
+	return &CreditHandler{creditService: creditService}
+}
+
+func (h *CreditHandler) GetCreditReport(c *gin.Context) {
+	loanID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return
+	}
+	report, err := h.creditService.GetReportByLoan(loanID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
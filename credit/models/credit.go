@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"loan-module/money"
+)
+
+// CreditReport is a credit bureau pull stored against the loan it was made
+// for. Reports younger than the cache TTL are reused for the same customer.
+// Amounts are in the Currency the bureau reported, empty when it did not.
+type CreditReport struct {
+	ID                 int            `gorm:"primaryKey" json:"id"`
+	LoanID             int            `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"loan_id"`
+	CustomerID         int            `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"customer_id"`
+	Provider           string         `gorm:"type:varchar(50);not null" json:"provider"`
+	BureauReference    string         `gorm:"type:varchar(100)" json:"bureau_reference,omitempty"`
+	HasHistory         bool           `gorm:"not null" json:"has_history"`
+	Score              int            `gorm:"not null" json:"score"`
+	Currency           money.Currency `gorm:"type:varchar(3)" json:"currency,omitempty"`
+	MonthlyObligations money.Money    `gorm:"not null" json:"monthly_obligations"`
+	OutstandingBalance money.Money    `gorm:"not null" json:"outstanding_balance"`
+	ActiveAccounts     int            `gorm:"not null" json:"active_accounts"`
+	Defaults           int            `gorm:"not null" json:"defaults"`
+	FromCache          bool           `gorm:"not null" json:"from_cache"`
+	PulledAt           time.Time      `gorm:"not null" json:"pulled_at"`
+	CreatedAt          time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// AfterFind labels the report's amounts with their currency.
+func (r *CreditReport) AfterFind(tx *gorm.DB) error {
+	r.MonthlyObligations = r.MonthlyObligations.In(r.Currency)
+	r.OutstandingBalance = r.OutstandingBalance.In(r.Currency)
+	return nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"time"
+
+	"loan-module/credit/models"
+	"loan-module/repository"
+)
+
+type CreditRepository struct {
+	db *database.Database
+}
+
+func NewCreditRepository(db *database.Database) *CreditRepository {
+	return &CreditRepository{db: db}
+}
+
+func (r *CreditRepository) AddReport(report *models.CreditReport) error {
+	return r.db.DB.Create(report).Error
+}
+
+// GetLatestFreshReport returns the newest report for a customer that was
+// pulled from the bureau after the given time.
+func (r *CreditRepository) GetLatestFreshReport(customerID int, since time.Time) (*models.CreditReport, bool) {
+	var report models.CreditReport
+	result := r.db.DB.
+		Where("customer_id = ? AND pulled_at > ?", customerID, since).
+		Order("pulled_at DESC").
+		First(&report)
+	return &report, result.Error == nil
+}
+
+// GetReportByLoan returns the applicant's report for a loan, ignoring those
+// pulled for co-applicants.
+func (r *CreditRepository) GetReportByLoan(loanID int) (*models.CreditReport, bool) {
+	var report models.CreditReport
+	result := r.db.DB.
+		Where("loan_id = ? AND customer_id = (SELECT customer_id FROM loans WHERE id = ?)", loanID, loanID).
+		Order("created_at DESC").
+		First(&report)
+	return &report, result.Error == nil
+}
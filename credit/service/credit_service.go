@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"loan-module/apperror"
+	"loan-module/constants"
+	"loan-module/credit/bureau"
+	"loan-module/credit/models"
+	"loan-module/credit/repository"
+	customerModels "loan-module/customer/models"
+	loanModels "loan-module/loan/models"
+)
+
+var ErrReportNotFound = apperror.NotFound("CREDIT_REPORT_NOT_FOUND", "credit report not found")
+
+type CreditService struct {
+	repo   *repository.CreditRepository
+	bureau bureau.CreditBureau
+	ttl    time.Duration
+}
+
+func NewCreditService(repo *repository.CreditRepository, creditBureau bureau.CreditBureau) *CreditService {
+	return &CreditService{
+		repo:   repo,
+		bureau: creditBureau,
+		ttl:    constants.CreditReportTTL,
+	}
+}
+
+// PullReport stores a credit report for the loan, reusing the customer's
+// latest bureau pull when it is younger than the cache TTL.
+func (s *CreditService) PullReport(ctx context.Context, loan *loanModels.Loan, customer *customerModels.Customer) (*models.CreditReport, error) {
+	now := time.Now()
+
+	if cached, exists := s.repo.GetLatestFreshReport(customer.ID, now.Add(-s.ttl)); exists {
+		report := *cached
+		report.ID = 0
+		report.LoanID = loan.ID
+		report.FromCache = true
+		report.CreatedAt = time.Time{}
+		if err := s.repo.AddReport(&report); err != nil {
+			return nil, err
+		}
+		return &report, nil
+	}
+
+	result, err := s.bureau.FetchReport(ctx, &bureau.Request{
+		CustomerID:  customer.ID,
+		FullName:    customer.Name,
+		Phone:       customer.Phone,
+		NationalID:  customer.NationalID,
+		DateOfBirth: customer.DateOfBirth,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("credit bureau %s: %w", s.bureau.Name(), err)
+	}
+
+	report := &models.CreditReport{
+		LoanID:             loan.ID,
+		CustomerID:         customer.ID,
+		Provider:           s.bureau.Name(),
+		BureauReference:    result.Reference,
+		HasHistory:         result.HasHistory,
+		Score:              result.Score,
+		Currency:           result.Currency,
+		MonthlyObligations: result.MonthlyObligations,
+		OutstandingBalance: result.OutstandingBalance,
+		ActiveAccounts:     result.ActiveAccounts,
+		Defaults:           result.Defaults,
+		PulledAt:           now,
+	}
+	if err := s.repo.AddReport(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *CreditService) GetReportByLoan(loanID int) (*models.CreditReport, error) {
+	report, exists := s.repo.GetReportByLoan(loanID)
+	if !exists {
+		return nil, ErrReportNotFound
+	}
+	return report, nil
+}
+
+// LatestScore returns the score from the customer's freshest cached report,
+// without calling the bureau.
+func (s *CreditService) LatestScore(customerID int) (int, bool) {
+	report, exists := s.repo.GetLatestFreshReport(customerID, time.Now().Add(-s.ttl))
+	if !exists || !report.HasHistory {
+		return 0, false
+	}
+	return report.Score, true
+}
+
+// LoanScore returns the score from the credit report pulled for a loan.
+func (s *CreditService) LoanScore(loanID int) (int, bool) {
+	report, exists := s.repo.GetReportByLoan(loanID)
+	if !exists || !report.HasHistory {
+		return 0, false
+	}
+	return report.Score, true
+}
@@ -5,6 +5,8 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"loan-module/apperror"
+	"loan-module/constants"
 	"loan-module/customer/models"
 	"loan-module/customer/service"
 )
@@ -20,33 +22,149 @@ func NewCustomerHandler(customerService *service.CustomerService) *CustomerHandl
 func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
 	var req models.CreateCustomerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	customer, err := h.customerService.CreateCustomer(&req)
+	if err != nil {
+		c.Error(err)
 		return
 	}
-	customer := h.customerService.CreateCustomer(&req)
 	c.JSON(http.StatusCreated, customer)
 }
 
 func (h *CustomerHandler) GetCustomerByID(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer ID"})
+	id, ok := customerID(c)
+	if !ok {
 		return
 	}
 	customer, exists := h.customerService.GetCustomerByID(id)
 	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		c.Error(service.ErrCustomerNotFound)
 		return
 	}
 	c.JSON(http.StatusOK, customer)
 }
 
-func (h *CustomerHandler) GetAllCustomers(c *gin.Context) {
-	customers := h.customerService.GetAllCustomers()
-	c.JSON(http.StatusOK, gin.H{"customers": customers})
+func (h *CustomerHandler) GetOverview(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+	overview, err := h.customerService.GetOverview(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, overview)
+}
+
+func (h *CustomerHandler) SearchCustomers(c *gin.Context) {
+	filter := models.CustomerFilter{
+		Phone:      c.Query("phone"),
+		Email:      c.Query("email"),
+		NamePrefix: c.Query("name"),
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
+	if page < constants.DefaultMinPage {
+		page = constants.DefaultPage
+	}
+	if size < constants.DefaultMinPageSize || size > constants.DefaultMaxPageSize {
+		size = constants.DefaultPageSize
+	}
+	customers, total, err := h.customerService.SearchCustomers(filter, page, size)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"customers": customers, "page": page, "size": size, "total": total})
 }
 
 func (h *CustomerHandler) GetTopCustomers(c *gin.Context) {
-	customers := h.customerService.GetTopCustomers()
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(constants.DefaultTopCustomers)))
+	if limit < 1 || limit > constants.MaxTopCustomers {
+		limit = constants.DefaultTopCustomers
+	}
+	customers, err := h.customerService.GetTopCustomers(limit, c.Query("currency"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"top_customers": customers})
 }
+
+func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+	var req models.UpdateCustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	customer, err := h.customerService.UpdateCustomer(id, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, customer)
+}
+
+func (h *CustomerHandler) PatchCustomer(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+	var req models.PatchCustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	customer, err := h.customerService.PatchCustomer(id, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, customer)
+}
+
+func (h *CustomerHandler) DeleteCustomer(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+	if err := h.customerService.DeleteCustomer(id); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Customer deleted successfully"})
+}
+
+func (h *CustomerHandler) MergeCustomers(c *gin.Context) {
+	id, ok := customerID(c)
+	if !ok {
+		return
+	}
+	var req models.MergeCustomersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	merged, err := h.customerService.MergeCustomers(id, req.DuplicateID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, merged)
+}
+
+func customerID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("customer ID"))
+		return 0, false
+	}
+	return id, true
+}
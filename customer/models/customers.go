@@ -1,13 +1,130 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
 
+	"gorm.io/gorm"
+	documentModels "loan-module/document/models"
+	loanModels "loan-module/loan/models"
+	"loan-module/money"
+	"loan-module/notification"
+	repaymentModels "loan-module/repayment/models"
+)
+
+type EmploymentType string
+
+const (
+	Salaried      EmploymentType = "SALARIED"
+	SelfEmployed  EmploymentType = "SELF_EMPLOYED"
+	BusinessOwner EmploymentType = "BUSINESS_OWNER"
+	Unemployed    EmploymentType = "UNEMPLOYED"
+	Retired       EmploymentType = "RETIRED"
+	Student       EmploymentType = "STUDENT"
+)
+
+// IsValid reports whether t is one of the known employment types.
+func (t EmploymentType) IsValid() bool {
+	switch t {
+	case Salaried, SelfEmployed, BusinessOwner, Unemployed, Retired, Student:
+		return true
+	}
+	return false
+}
+
+// Value stores an unset employment type as NULL: the column's CHECK rejects
+// an empty string.
+func (t EmploymentType) Value() (driver.Value, error) {
+	if t == "" {
+		return nil, nil
+	}
+	return string(t), nil
+}
+
+// Scan reads the column, leaving NULL as the unset employment type.
+func (t *EmploymentType) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*t = ""
+	case []byte:
+		*t = EmploymentType(v)
+	case string:
+		*t = EmploymentType(v)
+	default:
+		return fmt.Errorf("employment type: cannot scan %T", src)
+	}
+	return nil
+}
+
+type KYCStatus string
+
+const (
+	KYCPending  KYCStatus = "PENDING"
+	KYCVerified KYCStatus = "VERIFIED"
+	KYCFailed   KYCStatus = "FAILED"
+	KYCExpired  KYCStatus = "EXPIRED"
+)
+
+// Customer is a borrower or a party to a loan. Phones are stored in E.164;
+// PhoneVerifiedAt is set once the customer proves they hold the phone with an
+// OTP. MonthlyIncome is in IncomeCurrency, which is empty for incomes
+// recorded before it was. Deleted customers are soft deleted: their loans keep pointing at them,
+// but gorm no longer finds them and their phone can be used again.
 type Customer struct {
-	ID        int       `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"not null" json:"name"`
-	Phone     string    `gorm:"not null;uniqueIndex:idx_customers_phone" json:"phone"`
-	Email     string    `json:"email,omitempty"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ID              int            `gorm:"primaryKey" json:"id"`
+	Name            string         `gorm:"not null" json:"name"`
+	Phone           string         `gorm:"not null;uniqueIndex:idx_customers_phone,where:deleted_at IS NULL" json:"phone"`
+	PhoneVerifiedAt *time.Time     `json:"phone_verified_at,omitempty"`
+	Email           string         `json:"email,omitempty"`
+	DateOfBirth     *time.Time     `gorm:"type:date" json:"date_of_birth,omitempty"`
+	NationalID      string         `gorm:"type:varchar(50)" json:"national_id,omitempty"`
+	AddressLine1    string         `json:"address_line1,omitempty"`
+	AddressLine2    string         `json:"address_line2,omitempty"`
+	City            string         `json:"city,omitempty"`
+	State           string         `json:"state,omitempty"`
+	PostalCode      string         `gorm:"type:varchar(20)" json:"postal_code,omitempty"`
+	Country         string         `gorm:"type:varchar(2)" json:"country,omitempty"`
+	EmploymentType  EmploymentType `gorm:"type:varchar(20)" json:"employment_type,omitempty"`
+	EmployerName    string         `json:"employer_name,omitempty"`
+	MonthlyIncome   money.Money    `json:"monthly_income"`
+	IncomeCurrency  money.Currency `gorm:"type:varchar(3)" json:"income_currency,omitempty"`
+	KYCStatus       KYCStatus      `gorm:"column:kyc_status;type:varchar(20);not null;default:PENDING" json:"kyc_status"`
+	KYCExpiresAt    *time.Time     `gorm:"column:kyc_expires_at" json:"kyc_expires_at,omitempty"`
+	MergedIntoID    *int           `json:"merged_into_id,omitempty"`
+	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// AfterFind labels the customer's income with its currency.
+func (c *Customer) AfterFind(tx *gorm.DB) error {
+	c.MonthlyIncome = c.MonthlyIncome.In(c.IncomeCurrency)
+	return nil
+}
+
+// IsKYCVerified reports whether the customer holds a verified KYC that has
+// not expired at the given time.
+func (c *Customer) IsKYCVerified(at time.Time) bool {
+	if c.KYCStatus != KYCVerified {
+		return false
+	}
+	return c.KYCExpiresAt == nil || at.Before(*c.KYCExpiresAt)
+}
+
+const (
+	MinAge = 18
+	MaxAge = 100
+)
+
+// AgeOn returns the age in whole years of someone born on dob at the given
+// date.
+func AgeOn(dob, on time.Time) int {
+	age := on.Year() - dob.Year()
+	if on.Month() < dob.Month() || (on.Month() == dob.Month() && on.Day() < dob.Day()) {
+		age--
+	}
+	return age
 }
 
 type CreateCustomerRequest struct {
@@ -16,9 +133,169 @@ type CreateCustomerRequest struct {
 	Email string `json:"email"`
 }
 
+// UpdateCustomerRequest replaces the full customer profile (PUT). Phone is
+// left unchanged when empty, and IncomeCurrency defaults to the default
+// currency.
+type UpdateCustomerRequest struct {
+	Name           string         `json:"name" binding:"required"`
+	Phone          string         `json:"phone"`
+	Email          string         `json:"email"`
+	DateOfBirth    string         `json:"date_of_birth" binding:"required"`
+	NationalID     string         `json:"national_id" binding:"required"`
+	AddressLine1   string         `json:"address_line1" binding:"required"`
+	AddressLine2   string         `json:"address_line2"`
+	City           string         `json:"city" binding:"required"`
+	State          string         `json:"state"`
+	PostalCode     string         `json:"postal_code" binding:"required"`
+	Country        string         `json:"country" binding:"required,len=2"`
+	EmploymentType EmploymentType `json:"employment_type" binding:"required"`
+	EmployerName   string         `json:"employer_name"`
+	MonthlyIncome  money.Money    `json:"monthly_income"`
+	IncomeCurrency money.Currency `json:"income_currency"`
+}
+
+// PatchCustomerRequest updates only the fields that are present (PATCH).
+type PatchCustomerRequest struct {
+	Name           *string         `json:"name"`
+	Phone          *string         `json:"phone"`
+	Email          *string         `json:"email"`
+	DateOfBirth    *string         `json:"date_of_birth"`
+	NationalID     *string         `json:"national_id"`
+	AddressLine1   *string         `json:"address_line1"`
+	AddressLine2   *string         `json:"address_line2"`
+	City           *string         `json:"city"`
+	State          *string         `json:"state"`
+	PostalCode     *string         `json:"postal_code"`
+	Country        *string         `json:"country"`
+	EmploymentType *EmploymentType `json:"employment_type"`
+	EmployerName   *string         `json:"employer_name"`
+	MonthlyIncome  *money.Money    `json:"monthly_income"`
+	IncomeCurrency *money.Currency `json:"income_currency"`
+}
+
+// CustomerFilter narrows a customer search. Phone and email match exactly,
+// phone once normalised and email ignoring case, and name matches a
+// case-insensitive prefix. Empty fields match every customer.
+type CustomerFilter struct {
+	Phone      string
+	Email      string
+	NamePrefix string
+}
+
+type MergeCustomersRequest struct {
+	DuplicateID int `json:"duplicate_id" binding:"required"`
+}
+
+// MergeResponse is the customer kept by a merge and how many records moved
+// to them from the duplicate.
+type MergeResponse struct {
+	Customer         *Customer `json:"customer"`
+	MergedID         int       `json:"merged_id"`
+	Loans            int64     `json:"loans"`
+	Parties          int64     `json:"parties"`
+	KYCVerifications int64     `json:"kyc_verifications"`
+	CreditReports    int64     `json:"credit_reports"`
+}
+
+// PhoneNormalisation is the outcome of rewriting stored phones in E.164.
+// Customers whose phones normalise to the same number are left unchanged and
+// listed as collisions to be merged; phones that cannot be read are listed
+// as invalid.
+type PhoneNormalisation struct {
+	CustomersUpdated int              `json:"customers_updated"`
+	QuotesUpdated    int              `json:"quotes_updated"`
+	Collisions       []PhoneCollision `json:"collisions"`
+	Invalid          []InvalidPhone   `json:"invalid"`
+}
+
+// PhoneCollision is a number that the phones of several customers
+// normalise to.
+type PhoneCollision struct {
+	Phone       string `json:"phone"`
+	CustomerIDs []int  `json:"customer_ids"`
+}
+
+// InvalidPhone is a stored phone that cannot be normalised. Table is
+// customers or loan_quotes.
+type InvalidPhone struct {
+	Table string `json:"table"`
+	ID    int    `json:"id"`
+	Phone string `json:"phone"`
+	Error string `json:"error"`
+}
+
 type CustomerResponse struct {
 	ID    int    `json:"id"`
 	Name  string `json:"name"`
 	Phone string `json:"phone"`
 	Email string `json:"email,omitempty"`
 }
+
+// Open flags raised on a customer overview.
+const (
+	FlagPhoneNotVerified = "PHONE_NOT_VERIFIED"
+	FlagKYCNotVerified   = "KYC_NOT_VERIFIED"
+	FlagKYCExpired       = "KYC_EXPIRED"
+	FlagFraudReview      = "FRAUD_REVIEW"
+	FlagOverdue          = "OVERDUE_INSTALMENTS"
+)
+
+// ApplicantRole is the role of the customer on loans they applied for;
+// other loans carry their party role.
+const ApplicantRole = "APPLICANT"
+
+// Overview is everything known about a customer in one response.
+type Overview struct {
+	Customer      *Customer                    `json:"customer"`
+	Loans         []*OverviewLoan              `json:"loans"`
+	Exposure      Exposure                     `json:"exposure"`
+	Repayment     RepaymentBehaviour           `json:"repayment"`
+	Notifications []*notification.Notification `json:"notifications"`
+	Flags         []Flag                       `json:"flags"`
+}
+
+// OverviewLoan is a loan the customer applied for or is a party to, with
+// its documents and, once disbursed, how it is being repaid.
+type OverviewLoan struct {
+	ID              int                               `json:"loan_id"`
+	Role            string                            `json:"role"`
+	LoanType        loanModels.LoanType               `json:"loan_type"`
+	LoanAmount      money.Money                       `json:"loan_amount"`
+	Currency        money.Currency                    `json:"currency"`
+	Status          loanModels.LoanStatus             `json:"application_status"`
+	Channel         loanModels.Channel                `json:"channel"`
+	AssignedAgentID *int                              `json:"assigned_agent_id,omitempty"`
+	CreatedAt       time.Time                         `json:"created_at"`
+	UpdatedAt       time.Time                         `json:"updated_at"`
+	Documents       []*documentModels.LoanDocument    `json:"documents"`
+	Repayment       *repaymentModels.RepaymentSummary `json:"repayment,omitempty"`
+}
+
+// Exposure is the total of the customer's open and approved applications,
+// per currency and converted to the reporting currency. Total is left out
+// when a currency has no exchange rate.
+type Exposure struct {
+	ByCurrency []money.Money  `json:"by_currency"`
+	Total      *money.Money   `json:"total,omitempty"`
+	Currency   money.Currency `json:"currency"`
+}
+
+// RepaymentBehaviour adds up the repayment of every loan the customer
+// applied for. OnTimeRate is the share of paid instalments paid by their
+// due date.
+type RepaymentBehaviour struct {
+	LoansRepaying      int     `json:"loans_repaying"`
+	PaidInstalments    int     `json:"paid_instalments"`
+	LatePayments       int     `json:"late_payments"`
+	OnTimeRate         float64 `json:"on_time_rate"`
+	OverdueInstalments int     `json:"overdue_instalments"`
+	MaxDaysPastDue     int     `json:"max_days_past_due"`
+}
+
+// Flag is something about the customer that needs attention, on one of
+// their loans when LoanID is set.
+type Flag struct {
+	Code    string `json:"code"`
+	LoanID  *int   `json:"loan_id,omitempty"`
+	Message string `json:"message"`
+}
@@ -1,8 +1,14 @@
 package repository
 
 import (
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"loan-module/customer/models"
 	loanModels "loan-module/loan/models"
+	"loan-module/phone"
 	"loan-module/repository"
 )
 
@@ -14,9 +20,13 @@ func NewCustomerRepository(db *database.Database) *CustomerRepository {
 	return &CustomerRepository{db: db}
 }
 
-func (r *CustomerRepository) AddCustomer(customer *models.Customer) *models.Customer {
-	r.db.DB.Create(customer)
-	return customer
+// AddCustomer stores a new customer. A phone already used by another
+// customer fails with gorm.ErrDuplicatedKey.
+func (r *CustomerRepository) AddCustomer(customer *models.Customer) (*models.Customer, error) {
+	if err := r.db.DB.Create(customer).Error; err != nil {
+		return nil, err
+	}
+	return customer, nil
 }
 
 func (r *CustomerRepository) GetCustomerByID(id int) (*models.Customer, bool) {
@@ -31,26 +41,270 @@ func (r *CustomerRepository) GetCustomerByPhone(phone string) (*models.Customer,
 	return &customer, result.Error == nil
 }
 
-func (r *CustomerRepository) GetAllCustomers() []*models.Customer {
+// SearchCustomers returns a page of the customers matching the filter,
+// ordered by ID, and how many match in total.
+func (r *CustomerRepository) SearchCustomers(filter models.CustomerFilter, page, size int) ([]*models.Customer, int64, error) {
+	query := r.db.DB.Model(&models.Customer{})
+	if filter.Phone != "" {
+		query = query.Where("phone = ?", filter.Phone)
+	}
+	if filter.Email != "" {
+		query = query.Where("LOWER(email) = LOWER(?)", filter.Email)
+	}
+	if filter.NamePrefix != "" {
+		query = query.Where("LOWER(name) LIKE LOWER(?)", database.PrefixPattern(filter.NamePrefix))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	customers := []*models.Customer{}
+	err := query.Order("id").Offset((page - 1) * size).Limit(size).Find(&customers).Error
+	return customers, total, err
+}
+
+// profileColumns are the customer columns a profile edit writes. KYC and
+// phone verification are recorded by their own flows and are left as stored,
+// so an edit cannot undo a verification made meanwhile.
+var profileColumns = []string{
+	"name", "phone", "email", "date_of_birth", "national_id",
+	"address_line1", "address_line2", "city", "state", "postal_code", "country",
+	"employment_type", "employer_name", "monthly_income", "income_currency", "updated_at",
+}
+
+// UpdateCustomer saves the customer's profile, and the extra columns named,
+// such as a kyc_status reset by the edit. A phone already used by another
+// customer fails with gorm.ErrDuplicatedKey.
+func (r *CustomerRepository) UpdateCustomer(customer *models.Customer, extra ...string) error {
+	return r.db.DB.Model(customer).Select(slices.Concat(profileColumns, extra)).Updates(customer).Error
+}
+
+// DeleteCustomer soft deletes a customer.
+func (r *CustomerRepository) DeleteCustomer(id int) error {
+	return r.db.DB.Delete(&models.Customer{}, id).Error
+}
+
+// CountActiveLoans counts the open or approved loans the customer applied
+// for or is a party to.
+func (r *CustomerRepository) CountActiveLoans(customerID int) (int64, error) {
+	var count int64
+	err := r.db.DB.Model(&loanModels.Loan{}).
+		Where("application_status IN ?", loanModels.ActiveStatuses).
+		Where("customer_id = ? OR id IN (SELECT loan_id FROM loan_parties WHERE customer_id = ?)", customerID, customerID).
+		Count(&count).Error
+	return count, err
+}
+
+// NormalisePhones rewrites the phones of customers and saved quotes in
+// E.164, in one transaction. Customers whose phones normalise to the same
+// number keep their phones and are reported as collisions, so they can be
+// merged and the normalisation run again. Phones already in E.164 are left
+// alone, so running it twice changes nothing.
+func (r *CustomerRepository) NormalisePhones(phones *phone.Normalizer) (*models.PhoneNormalisation, error) {
+	result := &models.PhoneNormalisation{Collisions: []models.PhoneCollision{}, Invalid: []models.InvalidPhone{}}
+	err := r.db.DB.Transaction(func(tx *gorm.DB) error {
+		var customers []models.Customer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "phone").Order("id").Find(&customers).Error; err != nil {
+			return err
+		}
+		owners := map[string][]models.Customer{}
+		var numbers []string
+		for _, customer := range customers {
+			number, err := phones.Normalize(customer.Phone)
+			if err != nil {
+				result.Invalid = append(result.Invalid, models.InvalidPhone{
+					Table: "customers", ID: customer.ID, Phone: customer.Phone, Error: err.Error(),
+				})
+				continue
+			}
+			if _, seen := owners[number]; !seen {
+				numbers = append(numbers, number)
+			}
+			owners[number] = append(owners[number], customer)
+		}
+		for _, number := range numbers {
+			if len(owners[number]) > 1 {
+				collision := models.PhoneCollision{Phone: number}
+				for _, customer := range owners[number] {
+					collision.CustomerIDs = append(collision.CustomerIDs, customer.ID)
+				}
+				result.Collisions = append(result.Collisions, collision)
+				continue
+			}
+			customer := owners[number][0]
+			if customer.Phone == number {
+				continue
+			}
+			if err := tx.Model(&models.Customer{}).Where("id = ?", customer.ID).Update("phone", number).Error; err != nil {
+				return err
+			}
+			result.CustomersUpdated++
+		}
+
+		type quote struct {
+			ID            int
+			CustomerPhone string
+		}
+		var quotes []quote
+		if err := tx.Table("loan_quotes").Select("id", "customer_phone").Order("id").Scan(&quotes).Error; err != nil {
+			return err
+		}
+		for _, q := range quotes {
+			number, err := phones.Normalize(q.CustomerPhone)
+			if err != nil {
+				result.Invalid = append(result.Invalid, models.InvalidPhone{
+					Table: "loan_quotes", ID: q.ID, Phone: q.CustomerPhone, Error: err.Error(),
+				})
+				continue
+			}
+			if number == q.CustomerPhone {
+				continue
+			}
+			if err := tx.Table("loan_quotes").Where("id = ?", q.ID).Update("customer_phone", number).Error; err != nil {
+				return err
+			}
+			result.QuotesUpdated++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MergeCustomers moves the duplicate's loans, loan parties, KYC
+// verifications and credit reports to the customer kept, saves the kept
+// customer's profile and soft deletes the duplicate, all in one transaction.
+// Party rows that would name the kept customer twice on a loan, or as a
+// party to their own application, are dropped. It fails with
+// gorm.ErrRecordNotFound when either customer has been deleted meanwhile.
+func (r *CustomerRepository) MergeCustomers(keep, duplicate *models.Customer) (*models.MergeResponse, error) {
+	merged := &models.MergeResponse{Customer: keep, MergedID: duplicate.ID}
+	err := r.db.DB.Transaction(func(tx *gorm.DB) error {
+		var locked []models.Customer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []int{keep.ID, duplicate.ID}).Order("id").Find(&locked).Error; err != nil {
+			return err
+		}
+		if len(locked) != 2 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Exec(`
+			DELETE FROM loan_parties p
+			WHERE (p.customer_id = @duplicate AND (
+				EXISTS (SELECT 1 FROM loan_parties q WHERE q.loan_id = p.loan_id AND q.customer_id = @keep)
+				OR EXISTS (SELECT 1 FROM loans l WHERE l.id = p.loan_id AND l.customer_id = @keep)))
+			OR (p.customer_id = @keep AND EXISTS (SELECT 1 FROM loans l WHERE l.id = p.loan_id AND l.customer_id = @duplicate))
+		`, map[string]interface{}{"keep": keep.ID, "duplicate": duplicate.ID}).Error; err != nil {
+			return err
+		}
+
+		moves := []struct {
+			table string
+			count *int64
+		}{
+			{"loans", &merged.Loans},
+			{"loan_parties", &merged.Parties},
+			{"kyc_verifications", &merged.KYCVerifications},
+			{"credit_reports", &merged.CreditReports},
+		}
+		for _, move := range moves {
+			result := tx.Table(move.table).Where("customer_id = ?", duplicate.ID).Update("customer_id", keep.ID)
+			if result.Error != nil {
+				return result.Error
+			}
+			*move.count = result.RowsAffected
+		}
+
+		if err := tx.Save(keep).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(duplicate).Update("merged_into_id", keep.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(duplicate).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	duplicate.MergedIntoID = &keep.ID
+	return merged, nil
+}
+
+func (r *CustomerRepository) UpdateKYCStatus(id int, status models.KYCStatus, expiresAt *time.Time) error {
+	return r.db.DB.Model(&models.Customer{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"kyc_status":     status,
+		"kyc_expires_at": expiresAt,
+	}).Error
+}
+
+// MarkPhoneVerified records that the customer proved they hold the phone,
+// unless the customer's phone has changed since. It reports whether the
+// customer was updated.
+func (r *CustomerRepository) MarkPhoneVerified(id int, phone string, at time.Time) (bool, error) {
+	result := r.db.DB.Model(&models.Customer{}).
+		Where("id = ? AND phone = ?", id, phone).
+		Update("phone_verified_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetLoanPartyCustomers returns the co-applicants and guarantors on a loan,
+// excluding the applicant.
+func (r *CustomerRepository) GetLoanPartyCustomers(loanID int) []*models.Customer {
 	var customers []*models.Customer
-	r.db.DB.Find(&customers)
+	r.db.DB.Joins("JOIN loan_parties p ON p.customer_id = customers.id").
+		Where("p.loan_id = ?", loanID).
+		Order("p.id ASC").
+		Find(&customers)
 	return customers
 }
 
-func (r *CustomerRepository) UpdateCustomer(customer *models.Customer) {
-	r.db.DB.Save(customer)
+// GetLoanPartyPhones returns the phone numbers of the applicant and every
+// other party to a loan.
+func (r *CustomerRepository) GetLoanPartyPhones(loanID int) []string {
+	var phones []string
+	r.db.DB.Raw(`
+		SELECT c.phone FROM loans l JOIN customers c ON c.id = l.customer_id WHERE l.id = ?
+		UNION ALL
+		SELECT c.phone FROM loan_parties p JOIN customers c ON c.id = p.customer_id WHERE p.loan_id = ?
+	`, loanID, loanID).Scan(&phones)
+	return phones
 }
 
-func (r *CustomerRepository) GetTopCustomers() []loanModels.TopCustomerResponse {
+// GetTopCustomers returns the limit customers with the most approved loans.
+func (r *CustomerRepository) GetTopCustomers(limit int) []loanModels.TopCustomerResponse {
 	var results []loanModels.TopCustomerResponse
 	r.db.DB.Raw(`
-		SELECT c.name as customer_name, COUNT(*) as approved_loans
+		SELECT c.id as customer_id, c.name as customer_name, COUNT(*) as approved_loans
 		FROM loans l
 		JOIN customers c ON l.customer_id = c.id
-		WHERE l.application_status IN ('APPROVED_BY_SYSTEM', 'APPROVED_BY_AGENT')
-		GROUP BY c.name
-		ORDER BY approved_loans DESC
-		LIMIT 3
-	`).Scan(&results)
+		WHERE l.application_status IN ('APPROVED_BY_SYSTEM', 'APPROVED_BY_AGENT', 'CLOSED')
+		GROUP BY c.id, c.name
+		ORDER BY approved_loans DESC, c.id
+		LIMIT ?
+	`, limit).Scan(&results)
 	return results
 }
+
+// GetApprovedTotals sums the approved loans of the given customers per
+// currency.
+func (r *CustomerRepository) GetApprovedTotals(customerIDs []int) []loanModels.CustomerCurrencyTotal {
+	var totals []loanModels.CustomerCurrencyTotal
+	if len(customerIDs) == 0 {
+		return totals
+	}
+	r.db.DB.Raw(`
+		SELECT customer_id, currency, COUNT(*) as count, SUM(loan_amount) as amount
+		FROM loans
+		WHERE customer_id IN ? AND application_status IN ('APPROVED_BY_SYSTEM', 'APPROVED_BY_AGENT', 'CLOSED')
+		GROUP BY customer_id, currency
+	`, customerIDs).Scan(&totals)
+	for i := range totals {
+		totals[i].Amount = totals[i].Amount.In(totals[i].Currency)
+	}
+	return totals
+}
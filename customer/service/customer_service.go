@@ -1,36 +1,395 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"loan-module/apperror"
 	"loan-module/customer/models"
 	"loan-module/customer/repository"
+	documentRepo "loan-module/document/repository"
+	fx "loan-module/fx/service"
 	loanModels "loan-module/loan/models"
+	loanRepo "loan-module/loan/repository"
+	"loan-module/money"
+	"loan-module/notification"
+	"loan-module/phone"
+	repaymentRepo "loan-module/repayment/repository"
+)
+
+const dateOfBirthLayout = "2006-01-02"
+
+var (
+	ErrCustomerNotFound       = apperror.NotFound("CUSTOMER_NOT_FOUND", "customer not found")
+	ErrInvalidCustomer        = apperror.Validation("INVALID_CUSTOMER", "invalid customer details")
+	ErrPhoneTaken             = apperror.Conflict("PHONE_TAKEN", "phone is already registered to another customer")
+	ErrCustomerHasActiveLoans = apperror.Conflict("CUSTOMER_HAS_ACTIVE_LOANS", "customer has open or approved loans")
+	ErrSameCustomer           = apperror.Validation("SAME_CUSTOMER", "a customer cannot be merged into themselves")
 )
 
+var nationalIDPattern = regexp.MustCompile(`^[A-Za-z0-9-]{5,20}$`)
+
 type CustomerService struct {
-	repo *repository.CustomerRepository
+	repo             *repository.CustomerRepository
+	loanRepo         *loanRepo.LoanRepository
+	documentRepo     *documentRepo.DocumentRepository
+	repaymentRepo    *repaymentRepo.RepaymentRepository
+	notificationRepo *notification.Repository
+	fxService        *fx.FXService
+	phones           *phone.Normalizer
 }
 
-func NewCustomerService(repo *repository.CustomerRepository) *CustomerService {
-	return &CustomerService{repo: repo}
+func NewCustomerService(
+	repo *repository.CustomerRepository,
+	loanRepo *loanRepo.LoanRepository,
+	documentRepo *documentRepo.DocumentRepository,
+	repaymentRepo *repaymentRepo.RepaymentRepository,
+	notificationRepo *notification.Repository,
+	fxService *fx.FXService,
+	phones *phone.Normalizer,
+) *CustomerService {
+	return &CustomerService{
+		repo:             repo,
+		loanRepo:         loanRepo,
+		documentRepo:     documentRepo,
+		repaymentRepo:    repaymentRepo,
+		notificationRepo: notificationRepo,
+		fxService:        fxService,
+		phones:           phones,
+	}
 }
 
-func (s *CustomerService) CreateCustomer(req *models.CreateCustomerRequest) *models.Customer {
+func (s *CustomerService) CreateCustomer(req *models.CreateCustomerRequest) (*models.Customer, error) {
+	number, err := s.phones.Normalize(req.Phone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCustomer, err)
+	}
 	customer := &models.Customer{
 		Name:  req.Name,
-		Phone: req.Phone,
+		Phone: number,
 		Email: req.Email,
 	}
-	return s.repo.AddCustomer(customer)
+	customer, err = s.repo.AddCustomer(customer)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, ErrPhoneTaken
+	}
+	return customer, err
 }
 
 func (s *CustomerService) GetCustomerByID(id int) (*models.Customer, bool) {
 	return s.repo.GetCustomerByID(id)
 }
 
-func (s *CustomerService) GetAllCustomers() []*models.Customer {
-	return s.repo.GetAllCustomers()
+func (s *CustomerService) SearchCustomers(filter models.CustomerFilter, page, size int) ([]*models.Customer, int64, error) {
+	if filter.Phone != "" {
+		number, err := s.phones.Normalize(filter.Phone)
+		if err != nil {
+			return nil, 0, apperror.InvalidParameter(err.Error())
+		}
+		filter.Phone = number
+	}
+	return s.repo.SearchCustomers(filter, page, size)
 }
 
-func (s *CustomerService) GetTopCustomers() []loanModels.TopCustomerResponse {
-	return s.repo.GetTopCustomers()
+// DeleteCustomer soft deletes a customer who has no open or approved loans,
+// as applicant or party.
+func (s *CustomerService) DeleteCustomer(id int) error {
+	if _, exists := s.repo.GetCustomerByID(id); !exists {
+		return ErrCustomerNotFound
+	}
+	active, err := s.repo.CountActiveLoans(id)
+	if err != nil {
+		return err
+	}
+	if active > 0 {
+		return fmt.Errorf("%w: %d still active", ErrCustomerHasActiveLoans, active)
+	}
+	return s.repo.DeleteCustomer(id)
+}
+
+// MergeCustomers folds a duplicate record into the customer kept. The kept
+// customer's profile wins; only fields it leaves blank are taken from the
+// duplicate, along with the duplicate's KYC when only that one is verified.
+func (s *CustomerService) MergeCustomers(id, duplicateID int) (*models.MergeResponse, error) {
+	if id == duplicateID {
+		return nil, ErrSameCustomer
+	}
+	keep, exists := s.repo.GetCustomerByID(id)
+	if !exists {
+		return nil, ErrCustomerNotFound
+	}
+	duplicate, exists := s.repo.GetCustomerByID(duplicateID)
+	if !exists {
+		return nil, fmt.Errorf("%w: duplicate %d", ErrCustomerNotFound, duplicateID)
+	}
+
+	fillBlanks(keep, duplicate)
+	if now := time.Now(); !keep.IsKYCVerified(now) && duplicate.IsKYCVerified(now) {
+		keep.KYCStatus = duplicate.KYCStatus
+		keep.KYCExpiresAt = duplicate.KYCExpiresAt
+	}
+	merged, err := s.repo.MergeCustomers(keep, duplicate)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCustomerNotFound
+	}
+	return merged, err
+}
+
+// GetTopCustomers returns the limit customers with the most approved loans
+// and the total they were approved for, converted to the report currency.
+func (s *CustomerService) GetTopCustomers(limit int, currency string) ([]loanModels.TopCustomerResponse, error) {
+	reportCurrency, err := s.fxService.ReportCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	customers := s.repo.GetTopCustomers(limit)
+	ids := make([]int, 0, len(customers))
+	for _, customer := range customers {
+		ids = append(ids, customer.CustomerID)
+	}
+	amounts := make(map[int][]money.Money)
+	for _, total := range s.repo.GetApprovedTotals(ids) {
+		amounts[total.CustomerID] = append(amounts[total.CustomerID], total.Amount)
+	}
+	for i := range customers {
+		total, err := s.fxService.Total(fx.PurposeTopCustomers, reportCurrency, amounts[customers[i].CustomerID])
+		if err != nil {
+			return nil, err
+		}
+		customers[i].ApprovedAmount = total
+		customers[i].Currency = reportCurrency
+	}
+	return customers, nil
+}
+
+// UpdateCustomer replaces the whole profile of an existing customer.
+func (s *CustomerService) UpdateCustomer(id int, req *models.UpdateCustomerRequest) (*models.Customer, error) {
+	customer, exists := s.repo.GetCustomerByID(id)
+	if !exists {
+		return nil, ErrCustomerNotFound
+	}
+
+	dob, err := parseDateOfBirth(req.DateOfBirth)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := *customer
+	customer.Name = req.Name
+	if req.Phone != "" {
+		if err := s.changePhone(customer, req.Phone); err != nil {
+			return nil, err
+		}
+	}
+	customer.Email = req.Email
+	customer.DateOfBirth = &dob
+	customer.NationalID = req.NationalID
+	customer.AddressLine1 = req.AddressLine1
+	customer.AddressLine2 = req.AddressLine2
+	customer.City = req.City
+	customer.State = req.State
+	customer.PostalCode = req.PostalCode
+	customer.Country = strings.ToUpper(req.Country)
+	customer.EmploymentType = req.EmploymentType
+	customer.EmployerName = req.EmployerName
+	customer.MonthlyIncome = req.MonthlyIncome
+	customer.IncomeCurrency = req.IncomeCurrency
+	if customer.IncomeCurrency == "" {
+		customer.IncomeCurrency = money.DefaultCurrency
+	}
+
+	return s.saveProfile(customer, &previous)
+}
+
+// PatchCustomer updates only the profile fields present in the request.
+func (s *CustomerService) PatchCustomer(id int, req *models.PatchCustomerRequest) (*models.Customer, error) {
+	customer, exists := s.repo.GetCustomerByID(id)
+	if !exists {
+		return nil, ErrCustomerNotFound
+	}
+
+	previous := *customer
+	if req.Name != nil {
+		customer.Name = *req.Name
+	}
+	if req.Phone != nil {
+		if err := s.changePhone(customer, *req.Phone); err != nil {
+			return nil, err
+		}
+	}
+	if req.Email != nil {
+		customer.Email = *req.Email
+	}
+	if req.DateOfBirth != nil {
+		dob, err := parseDateOfBirth(*req.DateOfBirth)
+		if err != nil {
+			return nil, err
+		}
+		customer.DateOfBirth = &dob
+	}
+	if req.NationalID != nil {
+		customer.NationalID = *req.NationalID
+	}
+	if req.AddressLine1 != nil {
+		customer.AddressLine1 = *req.AddressLine1
+	}
+	if req.AddressLine2 != nil {
+		customer.AddressLine2 = *req.AddressLine2
+	}
+	if req.City != nil {
+		customer.City = *req.City
+	}
+	if req.State != nil {
+		customer.State = *req.State
+	}
+	if req.PostalCode != nil {
+		customer.PostalCode = *req.PostalCode
+	}
+	if req.Country != nil {
+		customer.Country = strings.ToUpper(*req.Country)
+	}
+	if req.EmploymentType != nil {
+		customer.EmploymentType = *req.EmploymentType
+	}
+	if req.EmployerName != nil {
+		customer.EmployerName = *req.EmployerName
+	}
+	if req.MonthlyIncome != nil {
+		customer.MonthlyIncome = *req.MonthlyIncome
+		if customer.IncomeCurrency == "" {
+			customer.IncomeCurrency = money.DefaultCurrency
+		}
+	}
+	if req.IncomeCurrency != nil {
+		customer.IncomeCurrency = *req.IncomeCurrency
+	}
+
+	return s.saveProfile(customer, &previous)
+}
+
+// saveProfile validates and stores the customer's edited profile. previous
+// is the customer as read before the edit: KYC verified a name, date of birth
+// and national ID, so changing any of them needs a new verification.
+func (s *CustomerService) saveProfile(customer, previous *models.Customer) (*models.Customer, error) {
+	if err := validateProfile(customer); err != nil {
+		return nil, err
+	}
+	var reset []string
+	if identityChanged(previous, customer) {
+		customer.KYCStatus = models.KYCPending
+		customer.KYCExpiresAt = nil
+		reset = append(reset, "kyc_status", "kyc_expires_at")
+	}
+	if customer.Phone != previous.Phone {
+		reset = append(reset, "phone_verified_at")
+	}
+	customer.MonthlyIncome = customer.MonthlyIncome.In(customer.IncomeCurrency)
+	if err := s.repo.UpdateCustomer(customer, reset...); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrPhoneTaken
+		}
+		return nil, err
+	}
+	return customer, nil
+}
+
+// changePhone normalises the customer's new phone. A different number has
+// to be verified again.
+func (s *CustomerService) changePhone(customer *models.Customer, raw string) error {
+	number, err := s.phones.Normalize(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCustomer, err)
+	}
+	if number != customer.Phone {
+		customer.Phone = number
+		customer.PhoneVerifiedAt = nil
+	}
+	return nil
+}
+
+// identityChanged reports whether the fields checked by KYC differ between
+// two versions of a customer.
+func identityChanged(before, after *models.Customer) bool {
+	if before.Name != after.Name || before.NationalID != after.NationalID {
+		return true
+	}
+	if before.DateOfBirth == nil || after.DateOfBirth == nil {
+		return before.DateOfBirth != after.DateOfBirth
+	}
+	return !before.DateOfBirth.Equal(*after.DateOfBirth)
+}
+
+// fillBlanks copies the profile fields the customer leaves blank from
+// another record of the same person.
+func fillBlanks(customer, other *models.Customer) {
+	fill := func(field *string, value string) {
+		if strings.TrimSpace(*field) == "" {
+			*field = value
+		}
+	}
+	fill(&customer.Email, other.Email)
+	fill(&customer.NationalID, other.NationalID)
+	fill(&customer.AddressLine1, other.AddressLine1)
+	fill(&customer.AddressLine2, other.AddressLine2)
+	fill(&customer.City, other.City)
+	fill(&customer.State, other.State)
+	fill(&customer.PostalCode, other.PostalCode)
+	fill(&customer.Country, other.Country)
+	fill(&customer.EmployerName, other.EmployerName)
+	if customer.DateOfBirth == nil {
+		customer.DateOfBirth = other.DateOfBirth
+	}
+	if customer.EmploymentType == "" {
+		customer.EmploymentType = other.EmploymentType
+	}
+	if customer.MonthlyIncome.IsZero() {
+		customer.MonthlyIncome = other.MonthlyIncome
+		customer.IncomeCurrency = other.IncomeCurrency
+	}
+}
+
+func parseDateOfBirth(value string) (time.Time, error) {
+	dob, err := time.Parse(dateOfBirthLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: date_of_birth must be in YYYY-MM-DD format", ErrInvalidCustomer)
+	}
+	return dob, nil
+}
+
+func validateProfile(customer *models.Customer) error {
+	if strings.TrimSpace(customer.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCustomer)
+	}
+	if strings.TrimSpace(customer.Phone) == "" {
+		return fmt.Errorf("%w: phone is required", ErrInvalidCustomer)
+	}
+	if customer.DateOfBirth != nil {
+		age := models.AgeOn(*customer.DateOfBirth, time.Now())
+		if age < models.MinAge || age > models.MaxAge {
+			return fmt.Errorf("%w: customer age must be between %d and %d", ErrInvalidCustomer, models.MinAge, models.MaxAge)
+		}
+	}
+	if customer.NationalID != "" && !nationalIDPattern.MatchString(customer.NationalID) {
+		return fmt.Errorf("%w: national_id must be 5-20 letters, digits or hyphens", ErrInvalidCustomer)
+	}
+	if customer.Country != "" && len(customer.Country) != 2 {
+		return fmt.Errorf("%w: country must be a 2-letter ISO code", ErrInvalidCustomer)
+	}
+	if customer.EmploymentType != "" && !customer.EmploymentType.IsValid() {
+		return fmt.Errorf("%w: invalid employment_type %q", ErrInvalidCustomer, customer.EmploymentType)
+	}
+	if customer.EmploymentType == models.Salaried && strings.TrimSpace(customer.EmployerName) == "" {
+		return fmt.Errorf("%w: employer_name is required for salaried customers", ErrInvalidCustomer)
+	}
+	if customer.MonthlyIncome.IsNegative() {
+		return fmt.Errorf("%w: monthly_income cannot be negative", ErrInvalidCustomer)
+	}
+	if customer.IncomeCurrency != "" && !customer.IncomeCurrency.IsValid() {
+		return fmt.Errorf("%w: unsupported income_currency %q", ErrInvalidCustomer, customer.IncomeCurrency)
+	}
+	return nil
 }
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"loan-module/constants"
+	"loan-module/customer/models"
+	documentModels "loan-module/document/models"
+	fx "loan-module/fx/service"
+	loanModels "loan-module/loan/models"
+	"loan-module/money"
+	"loan-module/notification"
+	repaymentModels "loan-module/repayment/models"
+)
+
+// GetOverview gathers a customer's profile, loans, exposure, repayment,
+// notifications and open flags. Each part is fetched with one query for all
+// of the customer's loans.
+func (s *CustomerService) GetOverview(id int) (*models.Overview, error) {
+	customer, exists := s.repo.GetCustomerByID(id)
+	if !exists {
+		return nil, ErrCustomerNotFound
+	}
+
+	loans := s.loanRepo.GetCustomerLoans(customer.ID)
+	ids := make([]int, 0, len(loans))
+	for _, loan := range loans {
+		ids = append(ids, loan.ID)
+	}
+	documents := make(map[int][]*documentModels.LoanDocument)
+	for _, document := range s.documentRepo.GetDocumentsByLoans(ids) {
+		documents[document.LoanID] = append(documents[document.LoanID], document)
+	}
+	now := time.Now()
+	repayments := make(map[int]*repaymentModels.RepaymentSummary)
+	for _, summary := range s.repaymentRepo.GetRepaymentSummaries(ids, now) {
+		repayments[summary.LoanID] = summary
+	}
+
+	overview := &models.Overview{
+		Customer:      customer,
+		Loans:         make([]*models.OverviewLoan, 0, len(loans)),
+		Notifications: s.notificationRepo.GetSent(notification.ChannelSMS, customer.Phone, customer.CreatedAt, constants.OverviewNotifications),
+		Flags:         customerFlags(customer, now),
+	}
+	for _, loan := range loans {
+		item := overviewLoan(customer.ID, loan, documents[loan.ID], repayments[loan.ID])
+		overview.Loans = append(overview.Loans, item)
+		overview.Flags = append(overview.Flags, loanFlags(item)...)
+		if item.Role == models.ApplicantRole && item.Repayment != nil {
+			addRepayment(&overview.Repayment, item.Repayment)
+		}
+	}
+	if paid := overview.Repayment.PaidInstalments; paid > 0 {
+		overview.Repayment.OnTimeRate = math.Round(float64(paid-overview.Repayment.LatePayments)/float64(paid)*10000) / 10000
+	}
+
+	exposure, err := s.exposure(customer.ID)
+	if err != nil {
+		return nil, err
+	}
+	overview.Exposure = *exposure
+	return overview, nil
+}
+
+// exposure totals the customer's active applications, leaving the total out
+// when an amount cannot be converted.
+func (s *CustomerService) exposure(customerID int) (*models.Exposure, error) {
+	amounts, err := s.loanRepo.SumActiveExposure(customerID, 0)
+	if err != nil {
+		return nil, err
+	}
+	exposure := &models.Exposure{ByCurrency: amounts, Currency: s.fxService.ReportingCurrency()}
+	if exposure.ByCurrency == nil {
+		exposure.ByCurrency = []money.Money{}
+	}
+	total, err := s.fxService.Total(fx.PurposeCustomerOverview, exposure.Currency, amounts)
+	switch {
+	case errors.Is(err, fx.ErrRateNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		exposure.Total = &total
+	}
+	return exposure, nil
+}
+
+func overviewLoan(customerID int, loan *loanModels.Loan, documents []*documentModels.LoanDocument, repayment *repaymentModels.RepaymentSummary) *models.OverviewLoan {
+	item := &models.OverviewLoan{
+		ID:              loan.ID,
+		Role:            models.ApplicantRole,
+		LoanType:        loan.LoanType,
+		LoanAmount:      loan.LoanAmount,
+		Currency:        loan.Currency,
+		Status:          loan.ApplicationStatus,
+		Channel:         loan.Channel,
+		AssignedAgentID: loan.AssignedAgentID,
+		CreatedAt:       loan.CreatedAt,
+		UpdatedAt:       loan.UpdatedAt,
+		Documents:       documents,
+		Repayment:       repayment,
+	}
+	if loan.CustomerID != customerID {
+		for _, party := range loan.Parties {
+			if party.CustomerID == customerID {
+				item.Role = string(party.Role)
+			}
+		}
+	}
+	if item.Documents == nil {
+		item.Documents = []*documentModels.LoanDocument{}
+	}
+	if repayment != nil {
+		repayment.OverdueAmount = repayment.OverdueAmount.In(loan.Currency)
+		repayment.OutstandingPrincipal = repayment.OutstandingPrincipal.In(loan.Currency)
+	}
+	return item
+}
+
+func addRepayment(behaviour *models.RepaymentBehaviour, summary *repaymentModels.RepaymentSummary) {
+	if summary.ScheduleStatus == repaymentModels.ScheduleActive {
+		behaviour.LoansRepaying++
+	}
+	behaviour.PaidInstalments += summary.PaidInstalments
+	behaviour.LatePayments += summary.LatePayments
+	behaviour.OverdueInstalments += summary.OverdueInstalments
+	if summary.DaysPastDue > behaviour.MaxDaysPastDue {
+		behaviour.MaxDaysPastDue = summary.DaysPastDue
+	}
+}
+
+func customerFlags(customer *models.Customer, now time.Time) []models.Flag {
+	flags := []models.Flag{}
+	if customer.PhoneVerifiedAt == nil {
+		flags = append(flags, models.Flag{Code: models.FlagPhoneNotVerified, Message: "Phone has not been verified with an OTP"})
+	}
+	switch {
+	case customer.IsKYCVerified(now):
+	case customer.KYCStatus == models.KYCExpired || customer.KYCStatus == models.KYCVerified:
+		flags = append(flags, models.Flag{Code: models.FlagKYCExpired, Message: "KYC verification has expired"})
+	default:
+		flags = append(flags, models.Flag{Code: models.FlagKYCNotVerified, Message: fmt.Sprintf("KYC is %s", customer.KYCStatus)})
+	}
+	return flags
+}
+
+func loanFlags(loan *models.OverviewLoan) []models.Flag {
+	var flags []models.Flag
+	if loan.Status == loanModels.FraudReview {
+		flags = append(flags, models.Flag{Code: models.FlagFraudReview, LoanID: &loan.ID, Message: "Held for fraud review"})
+	}
+	if loan.Repayment != nil && loan.Repayment.OverdueInstalments > 0 {
+		flags = append(flags, models.Flag{
+			Code:   models.FlagOverdue,
+			LoanID: &loan.ID,
+			Message: fmt.Sprintf("%d instalments (%s) overdue by up to %d days",
+				loan.Repayment.OverdueInstalments, loan.Repayment.OverdueAmount, loan.Repayment.DaysPastDue),
+		})
+	}
+	return flags
+}
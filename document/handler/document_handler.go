@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"loan-module/apperror"
+	"loan-module/constants"
+	"loan-module/document/models"
+	"loan-module/document/service"
+)
+
+const (
+	agentIDHeader    = "X-Agent-ID"
+	customerIDHeader = "X-Customer-ID"
+)
+
+type DocumentHandler struct {
+	documentService *service.DocumentService
+}
+
+func NewDocumentHandler(documentService *service.DocumentService) *DocumentHandler {
+	return &DocumentHandler{documentService: documentService}
+}
+
+func (h *DocumentHandler) UploadDocument(c *gin.Context) {
+	loanID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return
+	}
+	requester, ok := requesterFromHeaders(c)
+	if !ok {
+		return
+	}
+
+	// Leave room for the other multipart fields on top of the file itself.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, constants.MaxDocumentSize+(1<<20))
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.Error(service.ErrFileTooLarge)
+			return
+		}
+		c.Error(fmt.Errorf("%w: file is required", apperror.ErrInvalidBody))
+		return
+	}
+	if fileHeader.Size > constants.MaxDocumentSize {
+		c.Error(service.ErrFileTooLarge)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	defer file.Close()
+
+	document, err := h.documentService.UploadDocument(
+		c.Request.Context(),
+		loanID,
+		requester,
+		models.DocumentType(c.PostForm("document_type")),
+		fileHeader.Filename,
+		file,
+		c.PostForm("checksum_sha256"),
+	)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, document)
+}
+
+func (h *DocumentHandler) ListDocuments(c *gin.Context) {
+	loanID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return
+	}
+	requester, ok := requesterFromHeaders(c)
+	if !ok {
+		return
+	}
+	documents, err := h.documentService.ListDocuments(loanID, requester)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"documents": documents})
+}
+
+func (h *DocumentHandler) DownloadDocument(c *gin.Context) {
+	loanID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return
+	}
+	documentID, err := strconv.Atoi(c.Param("document_id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("document ID"))
+		return
+	}
+	requester, ok := requesterFromHeaders(c)
+	if !ok {
+		return
+	}
+	document, reader, err := h.documentService.OpenDocument(c.Request.Context(), loanID, documentID, requester)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", document.FileName))
+	c.Header("X-Checksum-SHA256", document.Checksum)
+	c.DataFromReader(http.StatusOK, document.SizeBytes, document.MIMEType, reader, nil)
+}
+
+func (h *DocumentHandler) GetChecklist(c *gin.Context) {
+	loanID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return
+	}
+	requester, ok := requesterFromHeaders(c)
+	if !ok {
+		return
+	}
+	checklist, err := h.documentService.GetChecklist(loanID, requester)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, checklist)
+}
+
+// ErrNoRequester is returned when a request does not say who is calling.
+var ErrNoRequester = apperror.Unauthorized("REQUESTER_REQUIRED", "X-Agent-ID or X-Customer-ID header is required")
+
+// requesterFromHeaders identifies the caller from the X-Agent-ID or
+// X-Customer-ID header, failing with ErrNoRequester when neither is usable.
+func requesterFromHeaders(c *gin.Context) (models.Requester, bool) {
+	if value := c.GetHeader(agentIDHeader); value != "" {
+		id, err := strconv.Atoi(value)
+		if err == nil {
+			return models.Requester{AgentID: &id}, true
+		}
+	}
+	if value := c.GetHeader(customerIDHeader); value != "" {
+		id, err := strconv.Atoi(value)
+		if err == nil {
+			return models.Requester{CustomerID: &id}, true
+		}
+	}
+	c.Error(ErrNoRequester)
+	return models.Requester{}, false
+}
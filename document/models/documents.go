@@ -0,0 +1,115 @@
+package models
+
+import (
+	"time"
+
+	loanModels "loan-module/loan/models"
+	"loan-module/money"
+)
+
+type DocumentType string
+
+const (
+	IDProof             DocumentType = "ID_PROOF"
+	IncomeProof         DocumentType = "INCOME_PROOF"
+	PropertyPapers      DocumentType = "PROPERTY_PAPERS"
+	FinancialStatements DocumentType = "FINANCIAL_STATEMENTS"
+)
+
+// IsValid reports whether t is one of the accepted loan document types.
+func (t DocumentType) IsValid() bool {
+	switch t {
+	case IDProof, IncomeProof, PropertyPapers, FinancialStatements:
+		return true
+	}
+	return false
+}
+
+// AllowedMIMETypes lists the content types accepted for uploads.
+var AllowedMIMETypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+}
+
+type LoanDocument struct {
+	ID             int          `gorm:"primaryKey" json:"id"`
+	LoanID         int          `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"loan_id"`
+	DocumentType   DocumentType `gorm:"type:varchar(30);not null" json:"document_type"`
+	FileName       string       `gorm:"not null" json:"file_name"`
+	MIMEType       string       `gorm:"column:mime_type;type:varchar(100);not null" json:"mime_type"`
+	SizeBytes      int64        `gorm:"not null" json:"size_bytes"`
+	Checksum       string       `gorm:"type:varchar(64);not null" json:"checksum_sha256"`
+	StorageKey     string       `gorm:"not null" json:"-"`
+	UploadedByType string       `gorm:"type:varchar(20);not null" json:"uploaded_by_type"`
+	UploadedByID   int          `gorm:"not null" json:"uploaded_by_id"`
+	CreatedAt      time.Time    `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ChecklistRule requires a set of documents for loans of one type whose
+// amount falls in [MinAmount, MaxAmount]. A zero MaxAmount is unbounded. A
+// rule with an amount band only applies to loans in the band's currency.
+type ChecklistRule struct {
+	LoanType  loanModels.LoanType
+	MinAmount money.Money
+	MaxAmount money.Money
+	Documents []DocumentType
+}
+
+// Matches reports whether the rule applies to a loan.
+func (r ChecklistRule) Matches(loanType loanModels.LoanType, amount money.Money) bool {
+	if r.LoanType != loanType {
+		return false
+	}
+	if r.MinAmount.IsZero() && r.MaxAmount.IsZero() {
+		return true
+	}
+	if amount.Currency() != r.MinAmount.Currency() || amount.LessThan(r.MinAmount) {
+		return false
+	}
+	return r.MaxAmount.IsZero() || !amount.GreaterThan(r.MaxAmount)
+}
+
+// DefaultChecklist is used when no checklist is configured.
+var DefaultChecklist = []ChecklistRule{
+	{LoanType: loanModels.Personal, Documents: []DocumentType{IDProof, IncomeProof}},
+	{LoanType: loanModels.Auto, Documents: []DocumentType{IDProof, IncomeProof}},
+	{LoanType: loanModels.Home, Documents: []DocumentType{IDProof, IncomeProof, PropertyPapers}},
+	{LoanType: loanModels.Business, Documents: []DocumentType{IDProof, FinancialStatements}},
+}
+
+type ChecklistItem struct {
+	DocumentType DocumentType `json:"document_type"`
+	Submitted    bool         `json:"submitted"`
+}
+
+type ChecklistResponse struct {
+	LoanID   int             `json:"loan_id"`
+	Complete bool            `json:"complete"`
+	Items    []ChecklistItem `json:"items"`
+	Missing  []DocumentType  `json:"missing"`
+}
+
+// Requester identifies who is calling a document endpoint. Exactly one of
+// AgentID or CustomerID is set.
+type Requester struct {
+	AgentID    *int
+	CustomerID *int
+}
+
+func (r Requester) Type() string {
+	if r.AgentID != nil {
+		return "AGENT"
+	}
+	return "CUSTOMER"
+}
+
+func (r Requester) ID() int {
+	if r.AgentID != nil {
+		return *r.AgentID
+	}
+	if r.CustomerID != nil {
+		return *r.CustomerID
+	}
+	return 0
+}
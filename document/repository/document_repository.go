@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"loan-module/document/models"
+	"loan-module/repository"
+)
+
+type DocumentRepository struct {
+	db *database.Database
+}
+
+func NewDocumentRepository(db *database.Database) *DocumentRepository {
+	return &DocumentRepository{db: db}
+}
+
+func (r *DocumentRepository) AddDocument(document *models.LoanDocument) error {
+	return r.db.DB.Create(document).Error
+}
+
+func (r *DocumentRepository) GetDocumentByID(loanID, id int) (*models.LoanDocument, bool) {
+	var document models.LoanDocument
+	result := r.db.DB.Where("loan_id = ?", loanID).First(&document, id)
+	return &document, result.Error == nil
+}
+
+func (r *DocumentRepository) GetDocumentsByLoan(loanID int) []*models.LoanDocument {
+	var documents []*models.LoanDocument
+	r.db.DB.Where("loan_id = ?", loanID).Order("created_at ASC").Find(&documents)
+	return documents
+}
+
+// GetDocumentsByLoans returns the documents of all the given loans.
+func (r *DocumentRepository) GetDocumentsByLoans(loanIDs []int) []*models.LoanDocument {
+	documents := []*models.LoanDocument{}
+	if len(loanIDs) == 0 {
+		return documents
+	}
+	r.db.DB.Where("loan_id IN ?", loanIDs).Order("loan_id ASC, created_at ASC").Find(&documents)
+	return documents
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"loan-module/document/models"
+	loanModels "loan-module/loan/models"
+	"loan-module/money"
+	"loan-module/providers"
+)
+
+// ChecklistFromConfig converts the configured checklist rules, falling back to
+// models.DefaultChecklist when none are configured.
+func ChecklistFromConfig(rules []providers.DocumentChecklistRule) ([]models.ChecklistRule, error) {
+	if len(rules) == 0 {
+		return models.DefaultChecklist, nil
+	}
+
+	checklist := make([]models.ChecklistRule, 0, len(rules))
+	for i, rule := range rules {
+		currency := money.Currency(rule.Currency)
+		if currency == "" {
+			currency = money.DefaultCurrency
+		}
+		if !currency.IsValid() {
+			return nil, fmt.Errorf("document checklist rule %d: unsupported currency %q", i, rule.Currency)
+		}
+		minAmount := money.FromFloat(rule.MinAmount, currency, money.HalfUp)
+		maxAmount := money.FromFloat(rule.MaxAmount, currency, money.HalfUp)
+		if !maxAmount.IsZero() && maxAmount.LessThan(minAmount) {
+			return nil, fmt.Errorf("document checklist rule %d: maxAmount is below minAmount", i)
+		}
+		documents := make([]models.DocumentType, 0, len(rule.Documents))
+		for _, doc := range rule.Documents {
+			documentType := models.DocumentType(doc)
+			if !documentType.IsValid() {
+				return nil, fmt.Errorf("document checklist rule %d: invalid document type %q", i, doc)
+			}
+			documents = append(documents, documentType)
+		}
+		checklist = append(checklist, models.ChecklistRule{
+			LoanType:  loanModels.LoanType(rule.LoanType),
+			MinAmount: minAmount,
+			MaxAmount: maxAmount,
+			Documents: documents,
+		})
+	}
+	return checklist, nil
+}
+
+// RequiredDocuments returns the union of documents required by the loan's
+// product and by every checklist rule that matches the loan.
+func (s *DocumentService) RequiredDocuments(loan *loanModels.Loan) []models.DocumentType {
+	seen := make(map[models.DocumentType]bool)
+	var required []models.DocumentType
+	add := func(doc models.DocumentType) {
+		if !seen[doc] {
+			seen[doc] = true
+			required = append(required, doc)
+		}
+	}
+
+	if product, exists := s.products.ProductAt(loan.LoanType, loan.CreatedAt); exists {
+		for _, doc := range product.RequiredDocuments {
+			add(models.DocumentType(doc))
+		}
+	}
+	for _, rule := range s.checklist {
+		if !rule.Matches(loan.LoanType, loan.LoanAmount) {
+			continue
+		}
+		for _, doc := range rule.Documents {
+			add(doc)
+		}
+	}
+	return required
+}
+
+// BuildChecklist compares the loan's required documents with those uploaded.
+func (s *DocumentService) BuildChecklist(loan *loanModels.Loan) *models.ChecklistResponse {
+	submitted := make(map[models.DocumentType]bool)
+	for _, document := range s.repo.GetDocumentsByLoan(loan.ID) {
+		submitted[document.DocumentType] = true
+	}
+
+	response := &models.ChecklistResponse{
+		LoanID:  loan.ID,
+		Items:   []models.ChecklistItem{},
+		Missing: []models.DocumentType{},
+	}
+	for _, doc := range s.RequiredDocuments(loan) {
+		response.Items = append(response.Items, models.ChecklistItem{DocumentType: doc, Submitted: submitted[doc]})
+		if !submitted[doc] {
+			response.Missing = append(response.Missing, doc)
+		}
+	}
+	response.Complete = len(response.Missing) == 0
+	return response
+}
+
+func (s *DocumentService) GetChecklist(loanID int, requester models.Requester) (*models.ChecklistResponse, error) {
+	loan, err := s.authorize(loanID, requester)
+	if err != nil {
+		return nil, err
+	}
+	return s.BuildChecklist(loan), nil
+}
+
+// MissingDocumentsMessage renders a customer-facing note listing missing
+// documents.
+func MissingDocumentsMessage(loanID int, missing []models.DocumentType) string {
+	names := make([]string, len(missing))
+	for i, doc := range missing {
+		names[i] = strings.ReplaceAll(strings.ToLower(string(doc)), "_", " ")
+	}
+	return fmt.Sprintf("Please upload the following documents for loan #%d: %s.", loanID, strings.Join(names, ", "))
+}
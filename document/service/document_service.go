@@ -0,0 +1,174 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"loan-module/apperror"
+	"loan-module/constants"
+	"loan-module/document/models"
+	"loan-module/document/repository"
+	"loan-module/document/storage"
+	loanModels "loan-module/loan/models"
+	loanRepo "loan-module/loan/repository"
+	productService "loan-module/product/service"
+)
+
+var (
+	ErrInvalidDocument  = apperror.Validation("INVALID_DOCUMENT", "invalid document")
+	ErrLoanNotFound     = apperror.NotFound("LOAN_NOT_FOUND", "loan not found")
+	ErrDocumentNotFound = apperror.NotFound("DOCUMENT_NOT_FOUND", "document not found")
+	ErrForbidden        = apperror.Forbidden("DOCUMENT_ACCESS_DENIED", "only the assigned agent or the loan owner can access its documents")
+	ErrFileTooLarge     = apperror.TooLarge("FILE_TOO_LARGE", fmt.Sprintf("file exceeds the maximum size of %d bytes", constants.MaxDocumentSize))
+)
+
+type DocumentService struct {
+	repo      *repository.DocumentRepository
+	loanRepo  *loanRepo.LoanRepository
+	storage   storage.Storage
+	checklist []models.ChecklistRule
+	products  *productService.ProductService
+}
+
+func NewDocumentService(
+	repo *repository.DocumentRepository,
+	loanRepo *loanRepo.LoanRepository,
+	storage storage.Storage,
+	checklist []models.ChecklistRule,
+	products *productService.ProductService,
+) *DocumentService {
+	return &DocumentService{
+		repo:      repo,
+		loanRepo:  loanRepo,
+		storage:   storage,
+		checklist: checklist,
+		products:  products,
+	}
+}
+
+// UploadDocument validates the file's size, content type and optional
+// client-supplied SHA-256 checksum, stores it and records it against the loan.
+func (s *DocumentService) UploadDocument(
+	ctx context.Context,
+	loanID int,
+	requester models.Requester,
+	documentType models.DocumentType,
+	fileName string,
+	file io.Reader,
+	declaredChecksum string,
+) (*models.LoanDocument, error) {
+	if _, err := s.authorize(loanID, requester); err != nil {
+		return nil, err
+	}
+	if !documentType.IsValid() {
+		return nil, fmt.Errorf("%w: invalid document_type %q", ErrInvalidDocument, documentType)
+	}
+
+	// Read one byte past the limit so oversized files can be detected.
+	data, err := io.ReadAll(io.LimitReader(file, constants.MaxDocumentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidDocument)
+	}
+	if len(data) > constants.MaxDocumentSize {
+		return nil, ErrFileTooLarge
+	}
+
+	mimeType := http.DetectContentType(data)
+	if idx := strings.Index(mimeType, ";"); idx != -1 {
+		mimeType = mimeType[:idx]
+	}
+	if !models.AllowedMIMETypes[mimeType] {
+		return nil, fmt.Errorf("%w: unsupported file type %q", ErrInvalidDocument, mimeType)
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	if declaredChecksum != "" && !strings.EqualFold(declaredChecksum, checksum) {
+		return nil, fmt.Errorf("%w: checksum does not match uploaded file", ErrInvalidDocument)
+	}
+
+	key := fmt.Sprintf("loans/%d/%d-%s", loanID, time.Now().UnixNano(), checksum[:16])
+	if err := s.storage.Save(ctx, key, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to store document: %w", err)
+	}
+
+	document := &models.LoanDocument{
+		LoanID:         loanID,
+		DocumentType:   documentType,
+		FileName:       filepath.Base(fileName),
+		MIMEType:       mimeType,
+		SizeBytes:      int64(len(data)),
+		Checksum:       checksum,
+		StorageKey:     key,
+		UploadedByType: requester.Type(),
+		UploadedByID:   requester.ID(),
+	}
+	if err := s.repo.AddDocument(document); err != nil {
+		s.storage.Delete(ctx, key)
+		return nil, err
+	}
+	return document, nil
+}
+
+func (s *DocumentService) ListDocuments(loanID int, requester models.Requester) ([]*models.LoanDocument, error) {
+	if _, err := s.authorize(loanID, requester); err != nil {
+		return nil, err
+	}
+	return s.repo.GetDocumentsByLoan(loanID), nil
+}
+
+// OpenDocument returns the document's metadata and a reader over its
+// contents. The caller must close the reader.
+func (s *DocumentService) OpenDocument(
+	ctx context.Context,
+	loanID, documentID int,
+	requester models.Requester,
+) (*models.LoanDocument, io.ReadCloser, error) {
+	if _, err := s.authorize(loanID, requester); err != nil {
+		return nil, nil, err
+	}
+	document, exists := s.repo.GetDocumentByID(loanID, documentID)
+	if !exists {
+		return nil, nil, ErrDocumentNotFound
+	}
+	reader, err := s.storage.Open(ctx, document.StorageKey)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, nil, ErrDocumentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return document, reader, nil
+}
+
+// authorize checks that the requester is the loan's assigned agent or the
+// customer who owns it.
+func (s *DocumentService) authorize(loanID int, requester models.Requester) (*loanModels.Loan, error) {
+	loan, exists := s.loanRepo.GetLoanByID(loanID)
+	if !exists {
+		return nil, ErrLoanNotFound
+	}
+	switch {
+	case requester.AgentID != nil:
+		if loan.AssignedAgentID != nil && *loan.AssignedAgentID == *requester.AgentID {
+			return loan, nil
+		}
+	case requester.CustomerID != nil:
+		if loan.CustomerID == *requester.CustomerID {
+			return loan, nil
+		}
+	}
+	return nil, ErrForbidden
+}
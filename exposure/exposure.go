@@ -0,0 +1,154 @@
+package exposure
+
+import (
+	"fmt"
+	"time"
+
+	"loan-module/apperror"
+	fx "loan-module/fx/service"
+	loanModels "loan-module/loan/models"
+	"loan-module/money"
+	"loan-module/providers"
+)
+
+// ErrLimitExceeded is what every LimitError unwraps to.
+var ErrLimitExceeded = apperror.Unprocessable("EXPOSURE_LIMIT_EXCEEDED", "exposure limit exceeded")
+
+// LimitError reports a breached exposure rule. It is answered with a 422
+// naming the rule.
+type LimitError struct {
+	Rule    string
+	Message string
+}
+
+func (e *LimitError) Error() string {
+	return e.Message
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// ProblemExtensions adds the breached rule to the problem details.
+func (e *LimitError) ProblemExtensions() map[string]interface{} {
+	return map[string]interface{}{"rule": e.Rule}
+}
+
+const (
+	RuleMaxActiveExposure   = "MAX_ACTIVE_EXPOSURE"
+	RuleMaxOpenApplications = "MAX_OPEN_APPLICATIONS"
+	RuleRejectionCooldown   = "REJECTION_COOLDOWN"
+)
+
+// LoanStore is the loan data the rules are checked against. The loan
+// repository implements it.
+type LoanStore interface {
+	SumActiveExposure(customerID, excludeLoanID int) ([]money.Money, error)
+	CountOpenApplications(customerID int, loanType loanModels.LoanType, excludeLoanID int) (int64, error)
+	GetLastRejection(customerID int) (*loanModels.Loan, bool)
+}
+
+// Checker enforces the per-customer exposure, concurrent-application and
+// rejection cooldown rules. Exposure across currencies is converted to the
+// limit's currency.
+type Checker struct {
+	loanRepo  LoanStore
+	fxService *fx.FXService
+	limits    providers.ExposureLimitsConfig
+}
+
+func NewChecker(loanRepo LoanStore, fxService *fx.FXService, limits providers.ExposureLimitsConfig) *Checker {
+	return &Checker{loanRepo: loanRepo, fxService: fxService, limits: limits}
+}
+
+// CheckSubmission validates a new application before it is created.
+func (c *Checker) CheckSubmission(customerID int, loanType loanModels.LoanType, amount money.Money) error {
+	if err := c.checkCooldown(customerID); err != nil {
+		return err
+	}
+	if err := c.checkOpenApplications(customerID, loanType, 0); err != nil {
+		return err
+	}
+	return c.checkExposure(customerID, amount, 0)
+}
+
+// CheckDecision re-validates an application before it is approved, leaving
+// the application itself out of the counts. A rejection since the
+// application was submitted blocks its approval too.
+func (c *Checker) CheckDecision(loan *loanModels.Loan) error {
+	if err := c.checkCooldown(loan.CustomerID); err != nil {
+		return err
+	}
+	if err := c.checkOpenApplications(loan.CustomerID, loan.LoanType, loan.ID); err != nil {
+		return err
+	}
+	return c.checkExposure(loan.CustomerID, loan.LoanAmount, loan.ID)
+}
+
+func (c *Checker) checkExposure(customerID int, amount money.Money, excludeLoanID int) error {
+	if c.limits.MaxActiveExposure <= 0 {
+		return nil
+	}
+	currency := money.Currency(c.limits.Currency)
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	limit := money.FromFloat(c.limits.MaxActiveExposure, currency, money.HalfUp)
+	amounts, err := c.loanRepo.SumActiveExposure(customerID, excludeLoanID)
+	if err != nil {
+		return err
+	}
+	total := money.New(0, currency)
+	for _, amount := range append(amounts, amount) {
+		converted, err := c.fxService.Convert(amount, currency)
+		if err != nil {
+			return err
+		}
+		total = total.Add(converted)
+	}
+	if total.GreaterThan(limit) {
+		return &LimitError{
+			Rule: RuleMaxActiveExposure,
+			Message: fmt.Sprintf("total active exposure %s would exceed the limit of %s",
+				total, limit),
+		}
+	}
+	return nil
+}
+
+func (c *Checker) checkOpenApplications(customerID int, loanType loanModels.LoanType, excludeLoanID int) error {
+	if c.limits.MaxOpenApplicationsPerType <= 0 {
+		return nil
+	}
+	open, err := c.loanRepo.CountOpenApplications(customerID, loanType, excludeLoanID)
+	if err != nil {
+		return err
+	}
+	if open >= int64(c.limits.MaxOpenApplicationsPerType) {
+		return &LimitError{
+			Rule: RuleMaxOpenApplications,
+			Message: fmt.Sprintf("customer already has %d open %s application(s); the limit is %d",
+				open, loanType, c.limits.MaxOpenApplicationsPerType),
+		}
+	}
+	return nil
+}
+
+func (c *Checker) checkCooldown(customerID int) error {
+	if c.limits.RejectionCooldownDays <= 0 {
+		return nil
+	}
+	rejected, exists := c.loanRepo.GetLastRejection(customerID)
+	if !exists {
+		return nil
+	}
+	until := rejected.UpdatedAt.AddDate(0, 0, c.limits.RejectionCooldownDays)
+	if time.Now().Before(until) {
+		return &LimitError{
+			Rule: RuleRejectionCooldown,
+			Message: fmt.Sprintf("loan #%d was rejected recently; new applications are accepted from %s",
+				rejected.ID, until.Format("2006-01-02")),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package exposure
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	loanModels "loan-module/loan/models"
+	"loan-module/money"
+	"loan-module/providers"
+)
+
+type stubLoans struct {
+	open      int64
+	rejection *loanModels.Loan
+}
+
+func (s *stubLoans) SumActiveExposure(customerID, excludeLoanID int) ([]money.Money, error) {
+	return nil, nil
+}
+
+func (s *stubLoans) CountOpenApplications(customerID int, loanType loanModels.LoanType, excludeLoanID int) (int64, error) {
+	return s.open, nil
+}
+
+func (s *stubLoans) GetLastRejection(customerID int) (*loanModels.Loan, bool) {
+	return s.rejection, s.rejection != nil
+}
+
+func TestCheckDecision(t *testing.T) {
+	limits := providers.ExposureLimitsConfig{MaxOpenApplicationsPerType: 2, RejectionCooldownDays: 30}
+	loan := &loanModels.Loan{ID: 7, CustomerID: 3, LoanType: loanModels.Personal, LoanAmount: money.New(100000, money.DefaultCurrency)}
+	tests := []struct {
+		name  string
+		loans *stubLoans
+		rule  string
+	}{
+		{"clean", &stubLoans{}, ""},
+		{"open applications", &stubLoans{open: 2}, RuleMaxOpenApplications},
+		{"recent rejection", &stubLoans{rejection: &loanModels.Loan{ID: 5, UpdatedAt: time.Now().AddDate(0, 0, -3)}}, RuleRejectionCooldown},
+		{"old rejection", &stubLoans{rejection: &loanModels.Loan{ID: 5, UpdatedAt: time.Now().AddDate(0, 0, -31)}}, ""},
+	}
+	for _, tt := range tests {
+		err := NewChecker(tt.loans, nil, limits).CheckDecision(loan)
+		if tt.rule == "" {
+			if err != nil {
+				t.Errorf("%s: CheckDecision = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || limitErr.Rule != tt.rule {
+			t.Errorf("%s: CheckDecision = %v, want %s", tt.name, err, tt.rule)
+		}
+		if !errors.Is(err, ErrLimitExceeded) {
+			t.Errorf("%s: CheckDecision = %v, want it to wrap ErrLimitExceeded", tt.name, err)
+		}
+	}
+}
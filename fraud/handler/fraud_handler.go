@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"loan-module/apperror"
+	"loan-module/fraud/models"
+	"loan-module/fraud/service"
+)
+
+type FraudHandler struct {
+	fraudService *service.FraudService
+}
+
+func NewFraudHandler(fraudService *service.FraudService) *FraudHandler {
+	return &FraudHandler{fraudService: fraudService}
+}
+
+func (h *FraudHandler) GetQueue(c *gin.Context) {
+	checks := h.fraudService.GetQueue()
+	c.JSON(http.StatusOK, gin.H{"fraud_reviews": checks, "total": len(checks)})
+}
+
+func (h *FraudHandler) Review(c *gin.Context) {
+	loanID, err := strconv.Atoi(c.Param("loan_id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return
+	}
+	var req models.ReviewDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	check, err := h.fraudService.Review(loanID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Fraud review recorded successfully", "fraud_check": check})
+}
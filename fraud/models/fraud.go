@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+type CheckStatus string
+
+const (
+	Passed        CheckStatus = "PASSED"
+	PendingReview CheckStatus = "PENDING_REVIEW"
+	Cleared       CheckStatus = "CLEARED"
+	Confirmed     CheckStatus = "CONFIRMED"
+)
+
+const (
+	RuleVelocity           = "VELOCITY"
+	RuleNearDuplicateName  = "NEAR_DUPLICATE_NAME"
+	RuleThresholdAvoidance = "THRESHOLD_AVOIDANCE"
+)
+
+// Signal is one fraud rule that fired for a loan.
+type Signal struct {
+	Rule   string `json:"rule"`
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
+
+// FraudCheck is the outcome of screening a loan before processing.
+type FraudCheck struct {
+	ID         int         `gorm:"primaryKey" json:"id"`
+	LoanID     int         `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"loan_id"`
+	Score      int         `gorm:"not null" json:"score"`
+	Signals    []Signal    `gorm:"type:jsonb;serializer:json;not null" json:"signals"`
+	Status     CheckStatus `gorm:"type:varchar(20);not null" json:"status"`
+	ReviewedBy *int        `json:"reviewed_by,omitempty"`
+	ReviewNote string      `json:"review_note,omitempty"`
+	ReviewedAt *time.Time  `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time   `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type ReviewDecisionRequest struct {
+	Decision   string `json:"decision" binding:"required"`
+	ReviewerID int    `json:"reviewer_id" binding:"required"`
+	Note       string `json:"note"`
+}
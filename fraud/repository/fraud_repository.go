@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"time"
+
+	"loan-module/fraud/models"
+	loanModels "loan-module/loan/models"
+	"loan-module/repository"
+)
+
+type FraudRepository struct {
+	db *database.Database
+}
+
+func NewFraudRepository(db *database.Database) *FraudRepository {
+	return &FraudRepository{db: db}
+}
+
+func (r *FraudRepository) AddCheck(check *models.FraudCheck) error {
+	return r.db.DB.Create(check).Error
+}
+
+func (r *FraudRepository) UpdateCheck(check *models.FraudCheck) error {
+	return r.db.DB.Save(check).Error
+}
+
+func (r *FraudRepository) GetLatestCheck(loanID int) (*models.FraudCheck, bool) {
+	var check models.FraudCheck
+	result := r.db.DB.Where("loan_id = ?", loanID).Order("created_at DESC").First(&check)
+	return &check, result.Error == nil
+}
+
+func (r *FraudRepository) GetChecksByStatus(status models.CheckStatus) []*models.FraudCheck {
+	var checks []*models.FraudCheck
+	r.db.DB.Where("status = ?", status).Order("score DESC, created_at ASC").Find(&checks)
+	return checks
+}
+
+// CountLoansByPhoneSince counts loans submitted with a phone number since the
+// given time.
+func (r *FraudRepository) CountLoansByPhoneSince(phone string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.DB.Model(&loanModels.Loan{}).
+		Joins("JOIN customers c ON c.id = loans.customer_id").
+		Where("c.phone = ? AND loans.created_at >= ?", phone, since).
+		Count(&count).Error
+	return count, err
+}
+
+// CountLoansByEmailSince counts loans submitted by any customer with the
+// given email since the given time.
+func (r *FraudRepository) CountLoansByEmailSince(email string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.DB.Model(&loanModels.Loan{}).
+		Joins("JOIN customers c ON c.id = loans.customer_id").
+		Where("LOWER(c.email) = LOWER(?) AND loans.created_at >= ?", email, since).
+		Count(&count).Error
+	return count, err
+}
+
+// GetApplicantNames returns the distinct names submitted on a customer's loans.
+func (r *FraudRepository) GetApplicantNames(customerID int) ([]string, error) {
+	var names []string
+	err := r.db.DB.Model(&loanModels.Loan{}).
+		Where("customer_id = ? AND applicant_name <> ''", customerID).
+		Distinct().
+		Pluck("applicant_name", &names).Error
+	return names, err
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"loan-module/apperror"
+	"loan-module/constants"
+	customerModels "loan-module/customer/models"
+	customerRepo "loan-module/customer/repository"
+	"loan-module/fraud/models"
+	"loan-module/fraud/repository"
+	loanModels "loan-module/loan/models"
+	loanRepo "loan-module/loan/repository"
+	"loan-module/money"
+	"loan-module/notification"
+	"loan-module/underwriting"
+)
+
+var (
+	ErrLoanNotFound    = apperror.NotFound("LOAN_NOT_FOUND", "loan not found")
+	ErrNotUnderReview  = apperror.InvalidTransition("NOT_UNDER_FRAUD_REVIEW", "loan is not under fraud review")
+	ErrInvalidDecision = apperror.Validation("INVALID_DECISION", "invalid decision. Must be CLEAR or CONFIRM")
+)
+
+const (
+	velocityScore      = 40
+	nearDuplicateScore = 35
+	thresholdScore     = 30
+)
+
+type FraudService struct {
+	repo                *repository.FraudRepository
+	loanRepo            *loanRepo.LoanRepository
+	customerRepo        *customerRepo.CustomerRepository
+	notificationService *notification.NotificationService
+	thresholds          underwriting.ApprovalThresholds
+}
+
+func NewFraudService(
+	repo *repository.FraudRepository,
+	loanRepo *loanRepo.LoanRepository,
+	customerRepo *customerRepo.CustomerRepository,
+	notificationService *notification.NotificationService,
+	thresholds underwriting.ApprovalThresholds,
+) *FraudService {
+	return &FraudService{
+		repo:                repo,
+		loanRepo:            loanRepo,
+		customerRepo:        customerRepo,
+		notificationService: notificationService,
+		thresholds:          thresholds,
+	}
+}
+
+// Screen runs the fraud rules against a loan and stores the result. A loan
+// whose previous check was cleared by a reviewer is not screened again.
+func (s *FraudService) Screen(loan *loanModels.Loan, customer *customerModels.Customer) (*models.FraudCheck, error) {
+	if previous, exists := s.repo.GetLatestCheck(loan.ID); exists && previous.Status == models.Cleared {
+		return previous, nil
+	}
+
+	var signals []models.Signal
+	since := time.Now().Add(-constants.FraudVelocityWindow)
+
+	phoneCount, err := s.repo.CountLoansByPhoneSince(customer.Phone, since)
+	if err != nil {
+		return nil, err
+	}
+	if phoneCount > constants.FraudVelocityMaxApplications {
+		signals = append(signals, models.Signal{
+			Rule:   models.RuleVelocity,
+			Score:  velocityScore,
+			Reason: fmt.Sprintf("%d applications from phone %s in the last %s", phoneCount, customer.Phone, constants.FraudVelocityWindow),
+		})
+	} else if customer.Email != "" {
+		emailCount, err := s.repo.CountLoansByEmailSince(customer.Email, since)
+		if err != nil {
+			return nil, err
+		}
+		if emailCount > constants.FraudVelocityMaxApplications {
+			signals = append(signals, models.Signal{
+				Rule:   models.RuleVelocity,
+				Score:  velocityScore,
+				Reason: fmt.Sprintf("%d applications from email %s in the last %s", emailCount, customer.Email, constants.FraudVelocityWindow),
+			})
+		}
+	}
+
+	names, err := s.repo.GetApplicantNames(customer.ID)
+	if err != nil {
+		return nil, err
+	}
+	if similar := nearDuplicateNames(append(names, customer.Name)); len(similar) > 0 {
+		signals = append(signals, models.Signal{
+			Rule:   models.RuleNearDuplicateName,
+			Score:  nearDuplicateScore,
+			Reason: fmt.Sprintf("phone %s used with near-duplicate names: %s", customer.Phone, strings.Join(similar, ", ")),
+		})
+	}
+
+	if threshold, ok := s.thresholds.For(loan.Currency); ok {
+		floor := threshold.Max.Mul(1-constants.FraudThresholdMargin, money.Up)
+		if !loan.LoanAmount.LessThan(floor) && !loan.LoanAmount.GreaterThan(threshold.Max) {
+			signals = append(signals, models.Signal{
+				Rule:   models.RuleThresholdAvoidance,
+				Score:  thresholdScore,
+				Reason: fmt.Sprintf("amount %s %s is just under the system limit of %s", loan.LoanAmount, loan.Currency, threshold.Max),
+			})
+		}
+	}
+
+	check := &models.FraudCheck{
+		LoanID:  loan.ID,
+		Signals: signals,
+		Status:  models.Passed,
+	}
+	for _, signal := range signals {
+		check.Score += signal.Score
+	}
+	if check.Signals == nil {
+		check.Signals = []models.Signal{}
+	}
+	if check.Score >= constants.FraudReviewScore {
+		check.Status = models.PendingReview
+	}
+
+	if err := s.repo.AddCheck(check); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// GetQueue lists the checks waiting for a fraud reviewer, highest score first.
+func (s *FraudService) GetQueue() []*models.FraudCheck {
+	return s.repo.GetChecksByStatus(models.PendingReview)
+}
+
+// Review records a reviewer's decision on a flagged loan. CLEAR sends the loan
+// back into the processing pipeline; CONFIRM rejects it.
+func (s *FraudService) Review(loanID int, req *models.ReviewDecisionRequest) (*models.FraudCheck, error) {
+	loan, exists := s.loanRepo.GetLoanByID(loanID)
+	if !exists {
+		return nil, ErrLoanNotFound
+	}
+	check, exists := s.repo.GetLatestCheck(loanID)
+	if !exists || check.Status != models.PendingReview || loan.ApplicationStatus != loanModels.FraudReview {
+		return nil, ErrNotUnderReview
+	}
+
+	var newStatus loanModels.LoanStatus
+	switch req.Decision {
+	case "CLEAR":
+		check.Status = models.Cleared
+		newStatus = loanModels.Applied
+	case "CONFIRM":
+		check.Status = models.Confirmed
+		newStatus = loanModels.RejectedBySystem
+	default:
+		return nil, ErrInvalidDecision
+	}
+
+	// The loan is moved first, so only one of two concurrent reviews counts
+	loan.ApplicationStatus = newStatus
+	if err := s.loanRepo.UpdateLoan(loan, loanModels.FraudReview); err != nil {
+		if errors.Is(err, loanRepo.ErrStatusChanged) {
+			return nil, ErrNotUnderReview
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	check.ReviewedBy = &req.ReviewerID
+	check.ReviewNote = req.Note
+	check.ReviewedAt = &now
+	if err := s.repo.UpdateCheck(check); err != nil {
+		return nil, err
+	}
+
+	if newStatus == loanModels.RejectedBySystem {
+		s.notificationService.SendSMSToAll(s.customerRepo.GetLoanPartyPhones(loan.ID), "loan application has been rejected by system.")
+	}
+	log.Printf("Fraud review for loan %d: %s by reviewer %d", loan.ID, req.Decision, req.ReviewerID)
+	return check, nil
+}
+
+// nearDuplicateNames returns the names that differ from another name in the
+// list only by a few characters.
+func nearDuplicateNames(names []string) []string {
+	normalized := make(map[string]string)
+	for _, name := range names {
+		key := strings.Join(strings.Fields(strings.ToLower(name)), " ")
+		if key != "" {
+			normalized[key] = name
+		}
+	}
+
+	seen := make(map[string]bool)
+	var similar []string
+	for a := range normalized {
+		for b := range normalized {
+			if a >= b {
+				continue
+			}
+			if levenshtein(a, b) <= constants.FraudNameMaxDistance {
+				for _, key := range []string{a, b} {
+					if !seen[key] {
+						seen[key] = true
+						similar = append(similar, normalized[key])
+					}
+				}
+			}
+		}
+	}
+	return similar
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
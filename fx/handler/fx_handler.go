@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"loan-module/apperror"
+	"loan-module/fx/models"
+	"loan-module/fx/service"
+)
+
+type FXHandler struct {
+	fxService *service.FXService
+}
+
+func NewFXHandler(fxService *service.FXService) *FXHandler {
+	return &FXHandler{fxService: fxService}
+}
+
+func (h *FXHandler) GetRates(c *gin.Context) {
+	rates := h.fxService.GetRates()
+	c.JSON(http.StatusOK, gin.H{"reporting_currency": h.fxService.ReportingCurrency(), "rates": rates})
+}
+
+func (h *FXHandler) SetRates(c *gin.Context) {
+	var req models.SetRatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	rates, err := h.fxService.SetRates(&req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"rates": rates})
+}
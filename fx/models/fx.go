@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"loan-module/money"
+)
+
+type RateSource string
+
+const (
+	SourceFile  RateSource = "FILE"
+	SourceAdmin RateSource = "ADMIN"
+)
+
+// ExchangeRate is the price of one unit of BaseCurrency in QuoteCurrency.
+// Rates are never updated in place: a new rate for a pair is a new row, and
+// the latest row wins.
+type ExchangeRate struct {
+	ID            int            `gorm:"primaryKey" json:"id"`
+	BaseCurrency  money.Currency `gorm:"type:varchar(3);not null" json:"base_currency"`
+	QuoteCurrency money.Currency `gorm:"type:varchar(3);not null" json:"quote_currency"`
+	Rate          float64        `gorm:"not null" json:"rate"`
+	Source        RateSource     `gorm:"type:varchar(10);not null" json:"source"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// CurrencyConversion records an amount converted for a report and the rate used,
+// so that a report can be reproduced after the rates table changes. Rate is
+// the rate applied, which is the inverse of the stored rate when the pair was
+// only quoted the other way round.
+type CurrencyConversion struct {
+	ID              int            `gorm:"primaryKey" json:"id"`
+	Purpose         string         `gorm:"type:varchar(50);not null" json:"purpose"`
+	RateID          int            `gorm:"not null" json:"rate_id"`
+	FromCurrency    money.Currency `gorm:"type:varchar(3);not null" json:"from_currency"`
+	ToCurrency      money.Currency `gorm:"type:varchar(3);not null" json:"to_currency"`
+	Rate            float64        `gorm:"not null" json:"rate"`
+	Amount          money.Money    `gorm:"not null" json:"amount"`
+	ConvertedAmount money.Money    `gorm:"not null" json:"converted_amount"`
+	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// RateRequest sets the rate of one currency pair. The rates file is a JSON
+// array of these.
+type RateRequest struct {
+	BaseCurrency  money.Currency `json:"base_currency" binding:"required"`
+	QuoteCurrency money.Currency `json:"quote_currency" binding:"required"`
+	Rate          float64        `json:"rate" binding:"required,gt=0"`
+}
+
+type SetRatesRequest struct {
+	Rates []RateRequest `json:"rates" binding:"required,min=1,dive"`
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"loan-module/fx/models"
+	"loan-module/money"
+	"loan-module/repository"
+)
+
+type FXRepository struct {
+	db *database.Database
+}
+
+func NewFXRepository(db *database.Database) *FXRepository {
+	return &FXRepository{db: db}
+}
+
+func (r *FXRepository) AddRates(rates []*models.ExchangeRate) error {
+	return r.db.DB.Create(rates).Error
+}
+
+// GetLatestRate returns the most recent rate for a currency pair.
+func (r *FXRepository) GetLatestRate(base, quote money.Currency) (*models.ExchangeRate, bool) {
+	var rate models.ExchangeRate
+	result := r.db.DB.
+		Where("base_currency = ? AND quote_currency = ?", base, quote).
+		Order("created_at DESC, id DESC").
+		First(&rate)
+	return &rate, result.Error == nil
+}
+
+// GetLatestRates returns the most recent rate of every currency pair.
+func (r *FXRepository) GetLatestRates() []*models.ExchangeRate {
+	var rates []*models.ExchangeRate
+	r.db.DB.Raw(`
+		SELECT DISTINCT ON (base_currency, quote_currency) *
+		FROM exchange_rates
+		ORDER BY base_currency, quote_currency, created_at DESC, id DESC
+	`).Scan(&rates)
+	return rates
+}
+
+func (r *FXRepository) AddConversions(conversions []*models.CurrencyConversion) error {
+	return r.db.DB.Create(conversions).Error
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"loan-module/apperror"
+	"loan-module/fx/models"
+	"loan-module/fx/repository"
+	"loan-module/money"
+)
+
+var (
+	ErrRateNotFound    = apperror.Unprocessable("EXCHANGE_RATE_NOT_FOUND", "no exchange rate")
+	ErrInvalidRate     = apperror.Validation("INVALID_EXCHANGE_RATE", "invalid exchange rate")
+	ErrInvalidCurrency = apperror.Validation("UNSUPPORTED_CURRENCY", "unsupported currency")
+)
+
+// Report purposes recorded against currency conversions.
+const (
+	PurposeStatusCount  = "STATUS_COUNT"
+	PurposeTopCustomers = "TOP_CUSTOMERS"
+	PurposePortfolio    = "PORTFOLIO"
+
+	PurposeCustomerOverview = "CUSTOMER_OVERVIEW"
+)
+
+// FXService keeps the exchange rates table and converts amounts between
+// currencies. Reports convert with Total, which records every conversion
+// and the rate used; limit checks and pricing convert with Convert, which
+// records nothing.
+type FXService struct {
+	repo              *repository.FXRepository
+	reportingCurrency money.Currency
+}
+
+func NewFXService(repo *repository.FXRepository, reportingCurrency money.Currency) *FXService {
+	if reportingCurrency == "" {
+		reportingCurrency = money.DefaultCurrency
+	}
+	return &FXService{repo: repo, reportingCurrency: reportingCurrency}
+}
+
+// ReportingCurrency is the currency reports are converted to by default.
+func (s *FXService) ReportingCurrency() money.Currency {
+	return s.reportingCurrency
+}
+
+// ReportCurrency resolves the currency a report was asked for, defaulting to
+// the reporting currency.
+func (s *FXService) ReportCurrency(requested string) (money.Currency, error) {
+	if requested == "" {
+		return s.reportingCurrency, nil
+	}
+	currency := money.Currency(requested)
+	if !currency.IsValid() {
+		return "", fmt.Errorf("%w %q", ErrInvalidCurrency, requested)
+	}
+	return currency, nil
+}
+
+// LoadFile loads rates from a JSON file of rate requests. Rates that match
+// the latest stored rate for their pair are skipped, so loading the same file
+// on every start does not grow the table.
+func (s *FXService) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read exchange rates file: %w", err)
+	}
+	var requests []models.RateRequest
+	if err := json.Unmarshal(data, &requests); err != nil {
+		return fmt.Errorf("failed to parse exchange rates file: %w", err)
+	}
+	rates, err := newRates(requests, models.SourceFile)
+	if err != nil {
+		return err
+	}
+
+	changed := make([]*models.ExchangeRate, 0, len(rates))
+	for _, rate := range rates {
+		latest, exists := s.repo.GetLatestRate(rate.BaseCurrency, rate.QuoteCurrency)
+		if !exists || latest.Rate != rate.Rate {
+			changed = append(changed, rate)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	return s.repo.AddRates(changed)
+}
+
+// SetRates adds rates entered through the admin API.
+func (s *FXService) SetRates(req *models.SetRatesRequest) ([]*models.ExchangeRate, error) {
+	rates, err := newRates(req.Rates, models.SourceAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.AddRates(rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (s *FXService) GetRates() []*models.ExchangeRate {
+	return s.repo.GetLatestRates()
+}
+
+// Convert converts an amount at the latest rate without recording the
+// conversion. Amounts without a currency are taken to be in to already.
+func (s *FXService) Convert(amount money.Money, to money.Currency) (money.Money, error) {
+	from := amount.Currency()
+	if from == "" || from == to {
+		return amount.In(to), nil
+	}
+	_, rate, err := s.rate(from, to)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return amount.Convert(to, rate, money.HalfUp), nil
+}
+
+// Total converts amounts in any currency to one currency for a report and
+// adds them up. Each conversion is recorded with the rate that was used.
+func (s *FXService) Total(purpose string, to money.Currency, amounts []money.Money) (money.Money, error) {
+	total := money.New(0, to)
+	var conversions []*models.CurrencyConversion
+	for _, amount := range amounts {
+		from := amount.Currency()
+		if from == "" || from == to {
+			total = total.Add(amount.In(to))
+			continue
+		}
+		stored, rate, err := s.rate(from, to)
+		if err != nil {
+			return money.Money{}, err
+		}
+		converted := amount.Convert(to, rate, money.HalfUp)
+		conversions = append(conversions, &models.CurrencyConversion{
+			Purpose:         purpose,
+			RateID:          stored.ID,
+			FromCurrency:    from,
+			ToCurrency:      to,
+			Rate:            rate,
+			Amount:          amount,
+			ConvertedAmount: converted,
+		})
+		total = total.Add(converted)
+	}
+	if len(conversions) > 0 {
+		if err := s.repo.AddConversions(conversions); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// rate finds the latest rate from one currency to another, inverting the
+// reverse pair when only that is quoted.
+func (s *FXService) rate(from, to money.Currency) (*models.ExchangeRate, float64, error) {
+	if stored, exists := s.repo.GetLatestRate(from, to); exists {
+		return stored, stored.Rate, nil
+	}
+	if stored, exists := s.repo.GetLatestRate(to, from); exists {
+		return stored, 1 / stored.Rate, nil
+	}
+	return nil, 0, fmt.Errorf("%w from %s to %s", ErrRateNotFound, from, to)
+}
+
+func newRates(requests []models.RateRequest, source models.RateSource) ([]*models.ExchangeRate, error) {
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("%w: no rates given", ErrInvalidRate)
+	}
+	rates := make([]*models.ExchangeRate, 0, len(requests))
+	for _, req := range requests {
+		switch {
+		case !req.BaseCurrency.IsValid() || !req.QuoteCurrency.IsValid():
+			return nil, fmt.Errorf("%w: unsupported currency pair %s/%s", ErrInvalidRate, req.BaseCurrency, req.QuoteCurrency)
+		case req.BaseCurrency == req.QuoteCurrency:
+			return nil, fmt.Errorf("%w: %s/%s is not a currency pair", ErrInvalidRate, req.BaseCurrency, req.QuoteCurrency)
+		case req.Rate <= 0:
+			return nil, fmt.Errorf("%w: %s/%s rate must be greater than 0", ErrInvalidRate, req.BaseCurrency, req.QuoteCurrency)
+		}
+		rates = append(rates, &models.ExchangeRate{
+			BaseCurrency:  req.BaseCurrency,
+			QuoteCurrency: req.QuoteCurrency,
+			Rate:          req.Rate,
+			Source:        source,
+		})
+	}
+	return rates, nil
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"loan-module/apperror"
+	"loan-module/kyc/models"
+	"loan-module/kyc/service"
+)
+
+type KYCHandler struct {
+	kycService *service.KYCService
+}
+
+func NewKYCHandler(kycService *service.KYCService) *KYCHandler {
+	return &KYCHandler{kycService: kycService}
+}
+
+func (h *KYCHandler) VerifyKYC(c *gin.Context) {
+	customerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("customer ID"))
+		return
+	}
+	var req models.VerifyKYCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	verification, err := h.kycService.Verify(c.Request.Context(), customerID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, verification)
+}
+
+func (h *KYCHandler) GetKYCStatus(c *gin.Context) {
+	customerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("customer ID"))
+		return
+	}
+	status, err := h.kycService.GetStatus(customerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
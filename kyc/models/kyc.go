@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	customerModels "loan-module/customer/models"
+)
+
+type DocumentType string
+
+const (
+	Passport       DocumentType = "PASSPORT"
+	NationalIDCard DocumentType = "NATIONAL_ID_CARD"
+	DrivingLicense DocumentType = "DRIVING_LICENSE"
+	VoterIDCard    DocumentType = "VOTER_ID_CARD"
+)
+
+// IsValid reports whether t is an accepted identity document type.
+func (t DocumentType) IsValid() bool {
+	switch t {
+	case Passport, NationalIDCard, DrivingLicense, VoterIDCard:
+		return true
+	}
+	return false
+}
+
+// KYCVerification is a stored result of a single verification attempt.
+type KYCVerification struct {
+	ID             int                      `gorm:"primaryKey" json:"id"`
+	CustomerID     int                      `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"customer_id"`
+	Provider       string                   `gorm:"type:varchar(50);not null" json:"provider"`
+	DocumentType   DocumentType             `gorm:"type:varchar(30);not null" json:"document_type"`
+	DocumentNumber string                   `gorm:"type:varchar(50);not null" json:"document_number"`
+	Status         customerModels.KYCStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Reason         string                   `json:"reason,omitempty"`
+	ReferenceID    string                   `gorm:"type:varchar(100)" json:"reference_id,omitempty"`
+	VerifiedAt     time.Time                `gorm:"not null" json:"verified_at"`
+	ExpiresAt      *time.Time               `json:"expires_at,omitempty"`
+	CreatedAt      time.Time                `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type VerifyKYCRequest struct {
+	DocumentType       DocumentType `json:"document_type" binding:"required"`
+	DocumentNumber     string       `json:"document_number" binding:"required"`
+	DocumentExpiryDate string       `json:"document_expiry_date"`
+}
+
+type KYCStatusResponse struct {
+	CustomerID    int                      `json:"customer_id"`
+	Status        customerModels.KYCStatus `json:"status"`
+	ExpiresAt     *time.Time               `json:"expires_at,omitempty"`
+	Verifications []*KYCVerification       `json:"verifications"`
+}
@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	kycModels "loan-module/kyc/models"
+)
+
+// VerificationRequest carries the identity data sent to a KYC provider.
+type VerificationRequest struct {
+	CustomerID         int
+	FullName           string
+	DateOfBirth        *time.Time
+	NationalID         string
+	DocumentType       kycModels.DocumentType
+	DocumentNumber     string
+	DocumentExpiryDate *time.Time
+}
+
+// VerificationResult is the provider's verdict on a VerificationRequest.
+type VerificationResult struct {
+	Verified    bool
+	Reason      string
+	ReferenceID string
+}
+
+// KYCProvider checks a customer's identity documents against an external
+// registry.
+type KYCProvider interface {
+	Name() string
+	Verify(ctx context.Context, req *VerificationRequest) (*VerificationResult, error)
+}
@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	kycModels "loan-module/kyc/models"
+)
+
+// StubProvider is a deterministic local KYCProvider for development. It runs
+// the basic document checks and treats any document number ending in "000"
+// as unknown to the registry, so failures can be reproduced on demand.
+type StubProvider struct {
+	now func() time.Time
+}
+
+func NewStubProvider() *StubProvider {
+	return &StubProvider{now: time.Now}
+}
+
+func (p *StubProvider) Name() string {
+	return "local-stub"
+}
+
+func (p *StubProvider) Verify(ctx context.Context, req *VerificationRequest) (*VerificationResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if reason := p.check(req); reason != "" {
+		return &VerificationResult{Verified: false, Reason: reason}, nil
+	}
+
+	return &VerificationResult{
+		Verified:    true,
+		ReferenceID: fmt.Sprintf("STUB-%d-%s", req.CustomerID, strings.ToUpper(req.DocumentNumber)),
+	}, nil
+}
+
+func (p *StubProvider) check(req *VerificationRequest) string {
+	switch {
+	case strings.TrimSpace(req.FullName) == "":
+		return "full name is missing"
+	case req.DateOfBirth == nil:
+		return "date of birth is missing from customer profile"
+	case req.NationalID == "":
+		return "national ID is missing from customer profile"
+	case !req.DocumentType.IsValid():
+		return fmt.Sprintf("unsupported document type %q", req.DocumentType)
+	case len(req.DocumentNumber) < 6:
+		return "document number is too short"
+	case req.DocumentExpiryDate != nil && !req.DocumentExpiryDate.After(p.now()):
+		return "document has expired"
+	case req.DocumentType == kycModels.NationalIDCard && !strings.EqualFold(req.DocumentNumber, req.NationalID):
+		return "document number does not match national ID on profile"
+	case strings.HasSuffix(req.DocumentNumber, "000"):
+		return "document not found in registry"
+	}
+	return ""
+}
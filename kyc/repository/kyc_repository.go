@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"loan-module/kyc/models"
+	"loan-module/repository"
+)
+
+type KYCRepository struct {
+	db *database.Database
+}
+
+func NewKYCRepository(db *database.Database) *KYCRepository {
+	return &KYCRepository{db: db}
+}
+
+func (r *KYCRepository) AddVerification(verification *models.KYCVerification) error {
+	return r.db.DB.Create(verification).Error
+}
+
+func (r *KYCRepository) GetVerificationsByCustomer(customerID int) []*models.KYCVerification {
+	var verifications []*models.KYCVerification
+	r.db.DB.Where("customer_id = ?", customerID).Order("created_at DESC").Find(&verifications)
+	return verifications
+}
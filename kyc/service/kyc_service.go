@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"loan-module/apperror"
+	"loan-module/constants"
+	customerModels "loan-module/customer/models"
+	customerRepo "loan-module/customer/repository"
+	"loan-module/kyc/models"
+	"loan-module/kyc/provider"
+	"loan-module/kyc/repository"
+	loanModels "loan-module/loan/models"
+	loanRepo "loan-module/loan/repository"
+	"loan-module/notification"
+)
+
+var (
+	ErrCustomerNotFound = apperror.NotFound("CUSTOMER_NOT_FOUND", "customer not found")
+	ErrInvalidKYC       = apperror.Validation("INVALID_KYC_REQUEST", "invalid KYC request")
+)
+
+type KYCService struct {
+	repo                *repository.KYCRepository
+	customerRepo        *customerRepo.CustomerRepository
+	loanRepo            *loanRepo.LoanRepository
+	provider            provider.KYCProvider
+	notificationService *notification.NotificationService
+}
+
+func NewKYCService(
+	repo *repository.KYCRepository,
+	customerRepo *customerRepo.CustomerRepository,
+	loanRepo *loanRepo.LoanRepository,
+	kycProvider provider.KYCProvider,
+	notificationService *notification.NotificationService,
+) *KYCService {
+	return &KYCService{
+		repo:                repo,
+		customerRepo:        customerRepo,
+		loanRepo:            loanRepo,
+		provider:            kycProvider,
+		notificationService: notificationService,
+	}
+}
+
+// Verify runs the customer's documents through the KYC provider, stores the
+// result and, on success, releases any loans that were waiting on KYC.
+func (s *KYCService) Verify(ctx context.Context, customerID int, req *models.VerifyKYCRequest) (*models.KYCVerification, error) {
+	customer, exists := s.customerRepo.GetCustomerByID(customerID)
+	if !exists {
+		return nil, ErrCustomerNotFound
+	}
+	if !req.DocumentType.IsValid() {
+		return nil, fmt.Errorf("%w: invalid document_type %q", ErrInvalidKYC, req.DocumentType)
+	}
+
+	providerReq := &provider.VerificationRequest{
+		CustomerID:     customer.ID,
+		FullName:       customer.Name,
+		DateOfBirth:    customer.DateOfBirth,
+		NationalID:     customer.NationalID,
+		DocumentType:   req.DocumentType,
+		DocumentNumber: req.DocumentNumber,
+	}
+	if req.DocumentExpiryDate != "" {
+		expiry, err := time.Parse("2006-01-02", req.DocumentExpiryDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: document_expiry_date must be in YYYY-MM-DD format", ErrInvalidKYC)
+		}
+		providerReq.DocumentExpiryDate = &expiry
+	}
+
+	result, err := s.provider.Verify(ctx, providerReq)
+	if err != nil {
+		return nil, fmt.Errorf("kyc provider %s: %w", s.provider.Name(), err)
+	}
+
+	now := time.Now()
+	verification := &models.KYCVerification{
+		CustomerID:     customer.ID,
+		Provider:       s.provider.Name(),
+		DocumentType:   req.DocumentType,
+		DocumentNumber: req.DocumentNumber,
+		Status:         customerModels.KYCFailed,
+		Reason:         result.Reason,
+		ReferenceID:    result.ReferenceID,
+		VerifiedAt:     now,
+	}
+	if result.Verified {
+		expiresAt := now.Add(constants.KYCValidityPeriod)
+		verification.Status = customerModels.KYCVerified
+		verification.ExpiresAt = &expiresAt
+	}
+
+	if err := s.repo.AddVerification(verification); err != nil {
+		return nil, err
+	}
+	if err := s.customerRepo.UpdateKYCStatus(customer.ID, verification.Status, verification.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	if verification.Status == customerModels.KYCVerified {
+		released, err := s.loanRepo.UpdateCustomerLoansStatus(customer.ID, loanModels.KYCPending, loanModels.Applied)
+		if err != nil {
+			log.Printf("Error releasing KYC pending loans for customer %d: %v", customer.ID, err)
+		} else if released > 0 {
+			log.Printf("Released %d KYC pending loans for customer %d", released, customer.ID)
+		}
+		s.notificationService.SendSMS(customer.Phone, "Your KYC verification is complete.")
+	} else {
+		s.notificationService.SendSMS(customer.Phone, "KYC verification failed: "+verification.Reason)
+	}
+
+	return verification, nil
+}
+
+func (s *KYCService) GetStatus(customerID int) (*models.KYCStatusResponse, error) {
+	customer, exists := s.customerRepo.GetCustomerByID(customerID)
+	if !exists {
+		return nil, ErrCustomerNotFound
+	}
+	status := s.currentStatus(customer, time.Now())
+	return &models.KYCStatusResponse{
+		CustomerID:    customer.ID,
+		Status:        status,
+		ExpiresAt:     customer.KYCExpiresAt,
+		Verifications: s.repo.GetVerificationsByCustomer(customer.ID),
+	}, nil
+}
+
+// currentStatus returns the customer's effective KYC status, marking a
+// verified KYC as expired once its expiry date has passed.
+func (s *KYCService) currentStatus(customer *customerModels.Customer, at time.Time) customerModels.KYCStatus {
+	if customer.KYCStatus == customerModels.KYCVerified && !customer.IsKYCVerified(at) {
+		if err := s.customerRepo.UpdateKYCStatus(customer.ID, customerModels.KYCExpired, customer.KYCExpiresAt); err != nil {
+			log.Printf("Error expiring KYC for customer %d: %v", customer.ID, err)
+		}
+		customer.KYCStatus = customerModels.KYCExpired
+	}
+	return customer.KYCStatus
+}
+
+// IsVerified reports whether the customer currently holds a valid KYC.
+func (s *KYCService) IsVerified(customer *customerModels.Customer) bool {
+	return s.currentStatus(customer, time.Now()) == customerModels.KYCVerified
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"loan-module/apperror"
 	"loan-module/loan/models"
 	"loan-module/loan/service"
 )
@@ -21,18 +22,54 @@ func NewLoanHandler(loanService *service.LoanService) *LoanHandler {
 func (h *LoanHandler) SubmitLoan(c *gin.Context) {
 	var req models.SubmitLoanRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	loan, err := h.loanService.SubmitLoan(&req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, loan)
+}
+
+func (h *LoanHandler) TopUp(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return
+	}
+	var req models.TopUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	loan, err := h.loanService.TopUp(id, &req)
+	if err != nil {
+		c.Error(err)
 		return
 	}
-	loan, _ := h.loanService.SubmitLoan(&req)
 	c.JSON(http.StatusCreated, loan)
 }
 
 func (h *LoanHandler) GetStatusCount(c *gin.Context) {
-	counts := h.loanService.GetStatusCount()
+	counts, err := h.loanService.GetStatusCount(c.Query("currency"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
 	c.JSON(http.StatusOK, counts)
 }
 
+func (h *LoanHandler) GetPortfolio(c *gin.Context) {
+	portfolio, err := h.loanService.GetPortfolio(c.Query("currency"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, portfolio)
+}
+
 func (h *LoanHandler) GetLoansByStatus(c *gin.Context) {
 	status := models.LoanStatus(c.Query("status"))
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -51,13 +88,64 @@ func (h *LoanHandler) GetLoansByStatus(c *gin.Context) {
 func (h *LoanHandler) GetLoanByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loan ID"})
+		c.Error(apperror.InvalidParameter("loan ID"))
 		return
 	}
 	loan, exists := h.loanService.GetLoanByID(id)
 	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Loan not found"})
+		c.Error(service.ErrLoanNotFound)
 		return
 	}
 	c.JSON(http.StatusOK, loan)
 }
+
+func (h *LoanHandler) GetParties(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return
+	}
+	parties, err := h.loanService.GetParties(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"parties": parties})
+}
+
+func (h *LoanHandler) AddParty(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return
+	}
+	var req models.PartyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	party, err := h.loanService.AddParty(id, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, party)
+}
+
+func (h *LoanHandler) RemoveParty(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return
+	}
+	partyID, err := strconv.Atoi(c.Param("party_id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("party ID"))
+		return
+	}
+	if err := h.loanService.RemoveParty(id, partyID); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Party removed successfully"})
+}
@@ -1,10 +1,17 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"loan-module/money"
+)
 
 type LoanType string
 type LoanStatus string
 
+// Codes of the products seeded by schema.sql. Loan types are product codes
+// from the loan_products catalogue, so other values are valid too.
 const (
 	Personal LoanType = "PERSONAL"
 	Home     LoanType = "HOME"
@@ -14,39 +21,170 @@ const (
 
 const (
 	Applied          LoanStatus = "APPLIED"
+	OTPPending       LoanStatus = "OTP_PENDING"
+	KYCPending       LoanStatus = "KYC_PENDING"
 	Processing       LoanStatus = "PROCESSING"
 	ApprovedBySystem LoanStatus = "APPROVED_BY_SYSTEM"
 	RejectedBySystem LoanStatus = "REJECTED_BY_SYSTEM"
+	FraudReview      LoanStatus = "FRAUD_REVIEW"
 	UnderReview      LoanStatus = "UNDER_REVIEW"
 	ApprovedByAgent  LoanStatus = "APPROVED_BY_AGENT"
 	RejectedByAgent  LoanStatus = "REJECTED_BY_AGENT"
+	Closed           LoanStatus = "CLOSED"
 )
 
+type Channel string
+
+// Channels an application can arrive through. Applications that do not name
+// one are DIRECT.
+const (
+	ChannelDirect  Channel = "DIRECT"
+	ChannelBranch  Channel = "BRANCH"
+	ChannelMobile  Channel = "MOBILE"
+	ChannelPartner Channel = "PARTNER"
+)
+
+func (c Channel) IsValid() bool {
+	switch c {
+	case ChannelDirect, ChannelBranch, ChannelMobile, ChannelPartner:
+		return true
+	}
+	return false
+}
+
+// OpenStatuses are the statuses of applications still awaiting a decision.
+var OpenStatuses = []LoanStatus{Applied, OTPPending, KYCPending, Processing, FraudReview, UnderReview}
+
+// IsOpen reports whether the status is one of OpenStatuses.
+func (s LoanStatus) IsOpen() bool {
+	for _, open := range OpenStatuses {
+		if s == open {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveStatuses are the statuses that count towards a customer's exposure:
+// open applications and approved loans.
+var ActiveStatuses = []LoanStatus{
+	Applied, OTPPending, KYCPending, Processing, FraudReview, UnderReview, ApprovedBySystem, ApprovedByAgent,
+}
+
+// RejectedStatuses are the terminal rejection statuses.
+var RejectedStatuses = []LoanStatus{RejectedBySystem, RejectedByAgent}
+
+// ApprovedStatuses are the approval statuses.
+var ApprovedStatuses = []LoanStatus{ApprovedBySystem, ApprovedByAgent}
+
+// DecisionStatuses are the statuses an application is decided into.
+var DecisionStatuses = []LoanStatus{ApprovedBySystem, RejectedBySystem, ApprovedByAgent, RejectedByAgent}
+
+// Loan is an application and, once approved, the loan itself. A loan under
+// review is assigned to an agent; ReviewTeamID is the manager whose team
+// shares its review queue, from which an unclaimed loan can be claimed.
 type Loan struct {
-	ID                int        `gorm:"primaryKey" json:"loan_id"`
-	CustomerID        int        `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"customer_id"`
-	LoanAmount        float64    `gorm:"not null" json:"loan_amount"`
-	LoanType          LoanType   `gorm:"type:varchar(20);not null" json:"loan_type"`
-	ApplicationStatus LoanStatus `gorm:"type:varchar(30);not null" json:"application_status"`
-	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"created_at"`
-	AssignedAgentID   *int       `gorm:"index;constraint:OnDelete:SET NULL" json:"assigned_agent_id,omitempty"`
+	ID                int            `gorm:"primaryKey" json:"loan_id"`
+	CustomerID        int            `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"customer_id"`
+	ApplicantName     string         `gorm:"type:varchar(255)" json:"applicant_name,omitempty"`
+	LoanAmount        money.Money    `gorm:"not null" json:"loan_amount"`
+	Currency          money.Currency `gorm:"type:varchar(3);not null;default:INR" json:"currency"`
+	LoanType          LoanType       `gorm:"type:varchar(30);not null" json:"loan_type"`
+	Channel           Channel        `gorm:"type:varchar(20);not null;default:DIRECT" json:"channel"`
+	ApplicationStatus LoanStatus     `gorm:"type:varchar(30);not null" json:"application_status"`
+	CreatedAt         time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	AssignedAgentID   *int           `gorm:"index;constraint:OnDelete:SET NULL" json:"assigned_agent_id,omitempty"`
+	ReviewTeamID      *int           `gorm:"index;constraint:OnDelete:SET NULL" json:"review_team_id,omitempty"`
+	TenureMonths      int            `gorm:"not null;default:0" json:"tenure_months"`
+	RiskGrade         string         `gorm:"type:varchar(5)" json:"risk_grade,omitempty"`
+	QuotedRate        *float64       `json:"quoted_rate,omitempty"`
+	QuotedAPR         *float64       `gorm:"column:quoted_apr" json:"quoted_apr,omitempty"`
+	FinalRate         *float64       `json:"final_rate,omitempty"`
+	FinalAPR          *float64       `gorm:"column:final_apr" json:"final_apr,omitempty"`
+	ProcessingFee     *money.Money   `json:"processing_fee,omitempty"`
+	RateCardVersion   string         `gorm:"type:varchar(50)" json:"rate_card_version,omitempty"`
+	QuoteID           *int           `json:"quote_id,omitempty"`
+	ParentLoanID      *int           `gorm:"index" json:"parent_loan_id,omitempty"`
+
+	Affordability *AffordabilityAssessment `gorm:"foreignKey:LoanID" json:"affordability,omitempty"`
+	Parties       []*LoanParty             `gorm:"foreignKey:LoanID" json:"parties,omitempty"`
+}
+
+// AfterFind labels the loan's amounts with its currency.
+func (l *Loan) AfterFind(tx *gorm.DB) error {
+	l.LoanAmount = l.LoanAmount.In(l.Currency)
+	if l.ProcessingFee != nil {
+		fee := l.ProcessingFee.In(l.Currency)
+		l.ProcessingFee = &fee
+	}
+	return nil
 }
 
+// SubmitLoanRequest applies for a loan. Currency defaults to the default
+// currency and Channel to DIRECT.
 type SubmitLoanRequest struct {
-	CustomerName  string   `json:"customer_name" binding:"required"`
-	CustomerPhone string   `json:"customer_phone" binding:"required"`
-	LoanAmount    float64  `json:"loan_amount" binding:"required,gt=0"`
-	LoanType      LoanType `json:"loan_type" binding:"required"`
+	CustomerName  string         `json:"customer_name" binding:"required"`
+	CustomerPhone string         `json:"customer_phone" binding:"required"`
+	LoanAmount    money.Money    `json:"loan_amount"`
+	Currency      money.Currency `json:"currency"`
+	LoanType      LoanType       `json:"loan_type" binding:"required"`
+	Channel       Channel        `json:"channel"`
+	TenureMonths  int            `json:"tenure_months" binding:"omitempty,gte=1,lte=360"`
+	QuoteID       *int           `json:"quote_id"`
+	Parties       []PartyRequest `json:"parties" binding:"omitempty,dive"`
 }
 
+// TopUpRequest asks for more money on top of an active loan. The top-up is a
+// new loan of the same type and parties.
+type TopUpRequest struct {
+	LoanAmount   money.Money `json:"loan_amount"`
+	TenureMonths int         `json:"tenure_months" binding:"omitempty,gte=1,lte=360"`
+}
+
+// StatusCountResponse counts the loans in a status. TotalAmount is their
+// combined amount converted to Currency.
 type StatusCountResponse struct {
-	Status string `json:"status"`
-	Count  int    `json:"count"`
+	Status      string         `json:"status"`
+	Count       int            `json:"count"`
+	TotalAmount money.Money    `json:"total_amount"`
+	Currency    money.Currency `json:"currency"`
 }
 
 type TopCustomerResponse struct {
-	CustomerName  string `json:"customer_name"`
-	ApprovedLoans int    `json:"approved_loans"`
+	CustomerID     int            `json:"-"`
+	CustomerName   string         `json:"customer_name"`
+	ApprovedLoans  int            `json:"approved_loans"`
+	ApprovedAmount money.Money    `json:"approved_amount"`
+	Currency       money.Currency `json:"currency"`
+}
+
+// CurrencyTotal counts and sums loan amounts in one currency.
+type CurrencyTotal struct {
+	Currency money.Currency `json:"currency"`
+	Count    int            `json:"count"`
+	Amount   money.Money    `json:"amount"`
+}
+
+// StatusCurrencyTotal is a CurrencyTotal for the loans in one status.
+type StatusCurrencyTotal struct {
+	Status LoanStatus
+	CurrencyTotal
+}
+
+// CustomerCurrencyTotal is a CurrencyTotal for one customer's loans.
+type CustomerCurrencyTotal struct {
+	CustomerID int
+	CurrencyTotal
+}
+
+// PortfolioResponse totals the sanctioned amount of active loans, per loan
+// currency and converted to Currency.
+type PortfolioResponse struct {
+	Currency    money.Currency   `json:"currency"`
+	Loans       int              `json:"loans"`
+	TotalAmount money.Money      `json:"total_amount"`
+	ByCurrency  []*CurrencyTotal `json:"by_currency"`
 }
 
 type LoanAssignment struct {
@@ -55,3 +193,78 @@ type LoanAssignment struct {
 	AgentID    int       `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"agent_id"`
 	AssignedAt time.Time `gorm:"autoCreateTime" json:"assigned_at"`
 }
+
+// LoanStatusChange records a loan moving from one status to another.
+// FromStatus is nil for the APPLIED entry written when the loan is created;
+// AgentID is the agent the loan was assigned to at the time.
+type LoanStatusChange struct {
+	ID         int         `gorm:"primaryKey" json:"id"`
+	LoanID     int         `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"loan_id"`
+	FromStatus *LoanStatus `gorm:"type:varchar(30)" json:"from_status,omitempty"`
+	ToStatus   LoanStatus  `gorm:"type:varchar(30);not null" json:"to_status"`
+	AgentID    *int        `json:"agent_id,omitempty"`
+	ChangedAt  time.Time   `gorm:"autoCreateTime" json:"changed_at"`
+}
+
+type PartyRole string
+
+const (
+	CoApplicant PartyRole = "CO_APPLICANT"
+	Guarantor   PartyRole = "GUARANTOR"
+)
+
+func (r PartyRole) IsValid() bool {
+	return r == CoApplicant || r == Guarantor
+}
+
+// LoanParty is a customer other than the applicant who is party to a loan.
+// Co-applicants' income and obligations count towards affordability;
+// guarantors only back the loan. Every party must pass KYC.
+type LoanParty struct {
+	ID         int       `gorm:"primaryKey" json:"id"`
+	LoanID     int       `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"loan_id"`
+	CustomerID int       `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"customer_id"`
+	Role       PartyRole `gorm:"type:varchar(20);not null" json:"role"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// PartyRequest names a co-applicant or guarantor by phone; unknown phones
+// become new customers.
+type PartyRequest struct {
+	Name  string    `json:"name" binding:"required"`
+	Phone string    `json:"phone" binding:"required"`
+	Role  PartyRole `json:"role" binding:"required"`
+}
+
+type AffordabilityOutcome string
+
+const (
+	AffordabilityPass   AffordabilityOutcome = "PASS"
+	AffordabilityRefer  AffordabilityOutcome = "REFER"
+	AffordabilityReject AffordabilityOutcome = "REJECT"
+)
+
+// AffordabilityAssessment records the EMI, DTI and FOIR calculation made for
+// a loan so reviewers can see why the system passed, referred or rejected it.
+// DTI is existing obligations over income; FOIR adds the proposed EMI.
+type AffordabilityAssessment struct {
+	ID                     int                  `gorm:"primaryKey" json:"-"`
+	LoanID                 int                  `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"-"`
+	MonthlyIncome          money.Money          `gorm:"not null" json:"monthly_income"`
+	ExistingObligations    money.Money          `gorm:"not null" json:"existing_obligations"`
+	CoApplicantIncome      money.Money          `gorm:"not null;default:0" json:"co_applicant_income"`
+	CoApplicantObligations money.Money          `gorm:"not null;default:0" json:"co_applicant_obligations"`
+	TenureMonths           int                  `gorm:"not null" json:"tenure_months"`
+	AnnualRate             float64              `gorm:"not null" json:"annual_rate"`
+	ProposedEMI            money.Money          `gorm:"column:proposed_emi;not null" json:"proposed_emi"`
+	DTI                    float64              `gorm:"column:dti;not null" json:"dti"`
+	FOIR                   float64              `gorm:"column:foir;not null" json:"foir"`
+	ReferDTI               float64              `gorm:"column:refer_dti;not null" json:"refer_dti"`
+	MaxDTI                 float64              `gorm:"column:max_dti;not null" json:"max_dti"`
+	ReferFOIR              float64              `gorm:"column:refer_foir;not null" json:"refer_foir"`
+	MaxFOIR                float64              `gorm:"column:max_foir;not null" json:"max_foir"`
+	MaxAffordableAmount    money.Money          `gorm:"not null" json:"max_affordable_amount"`
+	Outcome                AffordabilityOutcome `gorm:"type:varchar(10);not null" json:"outcome"`
+	Reason                 string               `json:"reason,omitempty"`
+	CreatedAt              time.Time            `gorm:"autoCreateTime" json:"created_at"`
+}
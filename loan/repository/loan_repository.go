@@ -1,12 +1,31 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	customerModels "loan-module/customer/models"
 	"loan-module/loan/models"
+	"loan-module/money"
+	quoteModels "loan-module/quote/models"
 	"loan-module/repository"
 )
 
+// ErrQuoteConverted is returned by AddLoan when the loan's quote already
+// backs another loan.
+var ErrQuoteConverted = errors.New("quote has already been converted to a loan")
+
+// ErrStatusChanged is returned by UpdateLoan when the loan's stored status is
+// no longer the one the caller expected.
+var ErrStatusChanged = errors.New("loan status changed concurrently")
+
+// ErrTopUpOpen is returned by AddLoan for a top-up of a loan that already
+// has an undecided top-up.
+var ErrTopUpOpen = errors.New("loan already has an open top-up")
+
 type LoanRepository struct {
 	db *database.Database
 }
@@ -15,7 +34,13 @@ func NewLoanRepository(db *database.Database) *LoanRepository {
 	return &LoanRepository{db: db}
 }
 
-func (r *LoanRepository) AddLoan(loan *models.Loan) (*models.Loan, error) {
+// AddLoan stores a new application with its applicant and parties. New
+// customers (ID 0) among them are registered in the same transaction, so a
+// failed application leaves no customers behind; parties, when given, are the
+// customers of loan.Parties in order. A loan on a saved quote claims the
+// quote in the same transaction, so a quote backs at most one loan. A top-up
+// locks its parent loan, so a loan has at most one open top-up.
+func (r *LoanRepository) AddLoan(loan *models.Loan, applicant *customerModels.Customer, parties []*customerModels.Customer) (*models.Loan, error) {
 	tx := r.db.DB.Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -23,11 +48,60 @@ func (r *LoanRepository) AddLoan(loan *models.Loan) (*models.Loan, error) {
 		}
 	}()
 
+	if err := registerCustomer(tx, applicant); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	loan.CustomerID = applicant.ID
+	for i, party := range parties {
+		if err := registerCustomer(tx, party); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		loan.Parties[i].CustomerID = party.ID
+	}
+
+	if loan.ParentLoanID != nil {
+		var parent models.Loan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&parent, *loan.ParentLoanID).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		var open int64
+		if err := tx.Model(&models.Loan{}).
+			Where("parent_loan_id = ? AND application_status IN ?", parent.ID, models.OpenStatuses).
+			Count(&open).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if open > 0 {
+			tx.Rollback()
+			return nil, ErrTopUpOpen
+		}
+	}
+
 	loan.ApplicationStatus = models.Applied
 	if err := tx.Create(loan).Error; err != nil {
 		tx.Rollback()
 		return nil, err
 	}
+	if err := recordStatusChange(tx, loan.ID, nil, loan.ApplicationStatus, loan.AssignedAgentID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if loan.QuoteID != nil {
+		result := tx.Model(&quoteModels.LoanQuote{}).
+			Where("id = ? AND converted_loan_id IS NULL", *loan.QuoteID).
+			Update("converted_loan_id", loan.ID)
+		if result.Error != nil {
+			tx.Rollback()
+			return nil, result.Error
+		}
+		if result.RowsAffected != 1 {
+			tx.Rollback()
+			return nil, ErrQuoteConverted
+		}
+	}
 
 	if err := tx.Commit().Error; err != nil {
 		return nil, err
@@ -38,7 +112,7 @@ func (r *LoanRepository) AddLoan(loan *models.Loan) (*models.Loan, error) {
 
 func (r *LoanRepository) GetLoanByID(id int) (*models.Loan, bool) {
 	var loan models.Loan
-	result := r.db.DB.First(&loan, id)
+	result := r.db.DB.Preload("Affordability").Preload("Parties").First(&loan, id)
 	return &loan, result.Error == nil
 }
 
@@ -48,13 +122,88 @@ func (r *LoanRepository) GetLoansByStatus(status models.LoanStatus) []*models.Lo
 	return loans
 }
 
+func (r *LoanRepository) GetLoansByCustomerAndStatus(customerID int, status models.LoanStatus) []*models.Loan {
+	var loans []*models.Loan
+	r.db.DB.Where("customer_id = ? AND application_status = ?", customerID, status).Find(&loans)
+	return loans
+}
+
+// UpdateCustomerLoansStatus moves every loan a customer applied for or is a
+// party to from one status to another and returns the number of loans moved.
+func (r *LoanRepository) UpdateCustomerLoansStatus(customerID int, from, to models.LoanStatus) (int64, error) {
+	var moved int64
+	err := r.db.DB.Transaction(func(tx *gorm.DB) error {
+		var loans []*models.Loan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "assigned_agent_id").
+			Where("application_status = ?", from).
+			Where("customer_id = ? OR id IN (SELECT loan_id FROM loan_parties WHERE customer_id = ?)", customerID, customerID).
+			Find(&loans).Error; err != nil {
+			return err
+		}
+		for _, loan := range loans {
+			if err := tx.Model(&models.Loan{}).Where("id = ?", loan.ID).Update("application_status", to).Error; err != nil {
+				return err
+			}
+			if err := recordStatusChange(tx, loan.ID, &from, to, loan.AssignedAgentID); err != nil {
+				return err
+			}
+		}
+		moved = int64(len(loans))
+		return nil
+	})
+	return moved, err
+}
+
+// AddParty adds a party to a loan, registering the customer in the same
+// transaction when they are new.
+func (r *LoanRepository) AddParty(party *models.LoanParty, customer *customerModels.Customer) error {
+	return r.db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := registerCustomer(tx, customer); err != nil {
+			return err
+		}
+		party.CustomerID = customer.ID
+		return tx.Create(party).Error
+	})
+}
+
+// registerCustomer stores a customer who has no ID yet. A customer registered
+// with the same phone by a concurrent request is used instead.
+func registerCustomer(tx *gorm.DB, customer *customerModels.Customer) error {
+	if customer.ID != 0 {
+		return nil
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(customer).Error; err != nil {
+		return err
+	}
+	if customer.ID != 0 {
+		return nil
+	}
+	return tx.Where("phone = ?", customer.Phone).First(customer).Error
+}
+
+func (r *LoanRepository) GetParties(loanID int) []*models.LoanParty {
+	var parties []*models.LoanParty
+	r.db.DB.Where("loan_id = ?", loanID).Order("id ASC").Find(&parties)
+	return parties
+}
+
+// RemoveParty deletes a party from a loan and reports whether it existed.
+func (r *LoanRepository) RemoveParty(loanID, partyID int) (bool, error) {
+	result := r.db.DB.Where("loan_id = ?", loanID).Delete(&models.LoanParty{}, partyID)
+	return result.RowsAffected > 0, result.Error
+}
+
 func (r *LoanRepository) GetAllLoans() []*models.Loan {
 	var loans []*models.Loan
 	r.db.DB.Find(&loans)
 	return loans
 }
 
-func (r *LoanRepository) UpdateLoan(loan *models.Loan) error {
+// UpdateLoan saves the loan, provided its stored status is still from, the
+// status the caller read before changing it. Otherwise the loan was moved on
+// concurrently and nothing is saved: it fails with ErrStatusChanged.
+func (r *LoanRepository) UpdateLoan(loan *models.Loan, from models.LoanStatus) error {
 	tx := r.db.DB.Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -62,26 +211,152 @@ func (r *LoanRepository) UpdateLoan(loan *models.Loan) error {
 		}
 	}()
 
-	if err := tx.Save(loan).Error; err != nil {
+	previous, err := lockStatus(tx, loan.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if previous != from {
+		tx.Rollback()
+		return fmt.Errorf("%w: loan %d is %s, not %s", ErrStatusChanged, loan.ID, previous, from)
+	}
+	if err := tx.Omit(clause.Associations).Save(loan).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
+	if previous != loan.ApplicationStatus {
+		if err := recordStatusChange(tx, loan.ID, &previous, loan.ApplicationStatus, loan.AssignedAgentID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
 
 	return tx.Commit().Error
 }
 
-func (r *LoanRepository) GetStatusCount() map[models.LoanStatus]int {
-	var loans []models.Loan
-	r.db.DB.Find(&loans)
+// lockStatus locks a loan's row for the rest of the transaction and returns
+// its stored status.
+func lockStatus(tx *gorm.DB, loanID int) (models.LoanStatus, error) {
+	var status models.LoanStatus
+	err := tx.Model(&models.Loan{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("application_status").
+		Where("id = ?", loanID).
+		Row().Scan(&status)
+	return status, err
+}
+
+func recordStatusChange(tx *gorm.DB, loanID int, from *models.LoanStatus, to models.LoanStatus, agentID *int) error {
+	return tx.Create(&models.LoanStatusChange{
+		LoanID:     loanID,
+		FromStatus: from,
+		ToStatus:   to,
+		AgentID:    agentID,
+	}).Error
+}
+
+func (r *LoanRepository) AddAffordabilityAssessment(assessment *models.AffordabilityAssessment) error {
+	return r.db.DB.Create(assessment).Error
+}
+
+// GetCustomerLoans returns every loan the customer applied for or is a
+// party to, newest first, with its parties.
+func (r *LoanRepository) GetCustomerLoans(customerID int) []*models.Loan {
+	loans := []*models.Loan{}
+	r.db.DB.Preload("Parties").
+		Where("customer_id = ? OR id IN (SELECT loan_id FROM loan_parties WHERE customer_id = ?)", customerID, customerID).
+		Order("created_at DESC, id DESC").
+		Find(&loans)
+	return loans
+}
+
+// GetActiveTopUps returns the open and approved top-ups of a loan.
+func (r *LoanRepository) GetActiveTopUps(parentID int) []*models.Loan {
+	loans := []*models.Loan{}
+	r.db.DB.Where("parent_loan_id = ? AND application_status IN ?", parentID, models.ActiveStatuses).
+		Order("id").
+		Find(&loans)
+	return loans
+}
+
+// SumActiveExposure totals the amounts of a customer's active loans per
+// currency, leaving out the given loan ID.
+func (r *LoanRepository) SumActiveExposure(customerID, excludeLoanID int) ([]money.Money, error) {
+	rows, err := r.db.DB.Model(&models.Loan{}).
+		Select("currency, SUM(loan_amount)").
+		Where("customer_id = ? AND application_status IN ? AND id <> ?", customerID, models.ActiveStatuses, excludeLoanID).
+		Group("currency").
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []money.Money
+	for rows.Next() {
+		var currency money.Currency
+		var total money.Money
+		if err := rows.Scan(&currency, &total); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total.In(currency))
+	}
+	return totals, rows.Err()
+}
 
-	counts := make(map[models.LoanStatus]int)
-	for _, loan := range loans {
-		counts[loan.ApplicationStatus]++
+// CountOpenApplications counts a customer's undecided applications of one
+// loan type, leaving out the given loan ID.
+func (r *LoanRepository) CountOpenApplications(customerID int, loanType models.LoanType, excludeLoanID int) (int64, error) {
+	var count int64
+	err := r.db.DB.Model(&models.Loan{}).
+		Where("customer_id = ? AND loan_type = ? AND application_status IN ? AND id <> ?",
+			customerID, loanType, models.OpenStatuses, excludeLoanID).
+		Count(&count).Error
+	return count, err
+}
+
+// GetLastRejection returns the customer's most recently rejected loan.
+func (r *LoanRepository) GetLastRejection(customerID int) (*models.Loan, bool) {
+	var loan models.Loan
+	result := r.db.DB.
+		Where("customer_id = ? AND application_status IN ?", customerID, models.RejectedStatuses).
+		Order("updated_at DESC").
+		First(&loan)
+	return &loan, result.Error == nil
+}
+
+// GetStatusTotals counts and sums loans per status and currency.
+func (r *LoanRepository) GetStatusTotals() []models.StatusCurrencyTotal {
+	var totals []models.StatusCurrencyTotal
+	r.db.DB.Model(&models.Loan{}).
+		Select("application_status AS status, currency, COUNT(*) AS count, SUM(loan_amount) AS amount").
+		Group("application_status, currency").
+		Scan(&totals)
+	for i := range totals {
+		totals[i].Amount = totals[i].Amount.In(totals[i].Currency)
 	}
-	return counts
+	return totals
 }
 
-func (r *LoanRepository) AssignLoanToAgent(loan *models.Loan, agentID int) error {
+// GetPortfolioTotals counts and sums approved loans that are still being
+// repaid, per currency.
+func (r *LoanRepository) GetPortfolioTotals() []*models.CurrencyTotal {
+	var totals []*models.CurrencyTotal
+	r.db.DB.Model(&models.Loan{}).
+		Select("currency, COUNT(*) AS count, SUM(loan_amount) AS amount").
+		Where("application_status IN ?", []models.LoanStatus{models.ApprovedBySystem, models.ApprovedByAgent}).
+		Group("currency").
+		Order("currency").
+		Scan(&totals)
+	for _, total := range totals {
+		total.Amount = total.Amount.In(total.Currency)
+	}
+	return totals
+}
+
+// AssignLoanToAgent puts a loan under review with an agent, sharing it with
+// the agent's team when they have a manager.
+func (r *LoanRepository) AssignLoanToAgent(loan *models.Loan, agentID int, teamID *int) error {
 	tx := r.db.DB.Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -89,14 +364,27 @@ func (r *LoanRepository) AssignLoanToAgent(loan *models.Loan, agentID int) error
 		}
 	}()
 
+	previous, err := lockStatus(tx, loan.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// Update loan with agent ID and status
 	if err := tx.Model(loan).Updates(map[string]interface{}{
-		"assigned_agent_id": agentID,
+		"assigned_agent_id":  agentID,
+		"review_team_id":     teamID,
 		"application_status": models.UnderReview,
 	}).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
+	if previous != models.UnderReview {
+		if err := recordStatusChange(tx, loan.ID, &previous, models.UnderReview, &agentID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
 
 	// Create assignment record
 	assignment := models.LoanAssignment{
@@ -111,3 +399,39 @@ func (r *LoanRepository) AssignLoanToAgent(loan *models.Loan, agentID int) error
 
 	return tx.Commit().Error
 }
+
+// GetAgentReviews returns the loans under review that are assigned to the
+// agent or waiting in the queue of the team they manage.
+func (r *LoanRepository) GetAgentReviews(agentID int) []*models.Loan {
+	var loans []*models.Loan
+	r.db.DB.Where("application_status = ? AND (assigned_agent_id = ? OR (assigned_agent_id IS NULL AND review_team_id = ?))",
+		models.UnderReview, agentID, agentID).Order("id").Find(&loans)
+	return loans
+}
+
+// ClaimLoan assigns an unclaimed loan under review in one of the teams'
+// queues to an agent and reports whether the loan was still there.
+func (r *LoanRepository) ClaimLoan(loanID, agentID int, teamIDs []int) (bool, error) {
+	claimed := false
+	err := r.db.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Loan{}).
+			Where("id = ? AND application_status = ? AND assigned_agent_id IS NULL AND review_team_id IN ?",
+				loanID, models.UnderReview, teamIDs).
+			Updates(map[string]interface{}{"assigned_agent_id": agentID, "updated_at": time.Now()})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		claimed = true
+		return tx.Create(&models.LoanAssignment{LoanID: loanID, AgentID: agentID, AssignedAt: time.Now()}).Error
+	})
+	return claimed, err
+}
+
+// UnclaimLoan returns a loan under review from an agent to their team's
+// queue and reports whether the agent still held it.
+func (r *LoanRepository) UnclaimLoan(loanID, agentID int) (bool, error) {
+	result := r.db.DB.Model(&models.Loan{}).
+		Where("id = ? AND application_status = ? AND assigned_agent_id = ?", loanID, models.UnderReview, agentID).
+		Updates(map[string]interface{}{"assigned_agent_id": nil, "updated_at": time.Now()})
+	return result.RowsAffected > 0, result.Error
+}
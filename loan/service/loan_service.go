@@ -2,21 +2,42 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"loan-module/apperror"
 	"loan-module/constants"
 	"log"
-	"math/rand"
 	"sync"
 	"time"
 
 	agent "loan-module/agent/repository"
+	collateral "loan-module/collateral/service"
+	creditModels "loan-module/credit/models"
+	credit "loan-module/credit/service"
 	"loan-module/customer/models"
 	customer "loan-module/customer/repository"
+	document "loan-module/document/service"
+	"loan-module/exposure"
+	fraudModels "loan-module/fraud/models"
+	fraud "loan-module/fraud/service"
+	fx "loan-module/fx/service"
+	kyc "loan-module/kyc/service"
 	loanModels "loan-module/loan/models"
 	"loan-module/loan/repository"
+	"loan-module/money"
 	"loan-module/notification"
+	otp "loan-module/otp/service"
+	"loan-module/phone"
+	"loan-module/pricing"
+	product "loan-module/product/service"
+	quoteModels "loan-module/quote/models"
+	quote "loan-module/quote/service"
+	repayment "loan-module/repayment/service"
+	"loan-module/underwriting"
 )
 
+var ErrInvalidLoanRequest = apperror.Validation("INVALID_LOAN_REQUEST", "invalid loan request")
+
 // Worker pool config
 
 type LoanService struct {
@@ -24,6 +45,21 @@ type LoanService struct {
 	agentRepo           *agent.AgentRepository
 	customerRepo        *customer.CustomerRepository
 	notificationService *notification.NotificationService
+	kycService          *kyc.KYCService
+	otpService          *otp.OTPService
+	documentService     *document.DocumentService
+	creditService       *credit.CreditService
+	policies            underwriting.Policies
+	exposureChecker     *exposure.Checker
+	fraudService        *fraud.FraudService
+	pricingEngine       *pricing.Engine
+	quoteService        *quote.QuoteService
+	productService      *product.ProductService
+	collateralService   *collateral.CollateralService
+	repaymentService    *repayment.RepaymentService
+	thresholds          underwriting.ApprovalThresholds
+	fxService           *fx.FXService
+	phones              *phone.Normalizer
 }
 
 func NewLoanService(
@@ -31,38 +67,153 @@ func NewLoanService(
 	agentRepo *agent.AgentRepository,
 	customerRepo *customer.CustomerRepository,
 	notificationService *notification.NotificationService,
+	kycService *kyc.KYCService,
+	otpService *otp.OTPService,
+	documentService *document.DocumentService,
+	creditService *credit.CreditService,
+	policies underwriting.Policies,
+	exposureChecker *exposure.Checker,
+	fraudService *fraud.FraudService,
+	pricingEngine *pricing.Engine,
+	quoteService *quote.QuoteService,
+	productService *product.ProductService,
+	collateralService *collateral.CollateralService,
+	repaymentService *repayment.RepaymentService,
+	thresholds underwriting.ApprovalThresholds,
+	fxService *fx.FXService,
+	phones *phone.Normalizer,
 ) *LoanService {
 	return &LoanService{
 		repo:                repo,
 		agentRepo:           agentRepo,
 		customerRepo:        customerRepo,
 		notificationService: notificationService,
+		kycService:          kycService,
+		otpService:          otpService,
+		documentService:     documentService,
+		creditService:       creditService,
+		policies:            policies,
+		exposureChecker:     exposureChecker,
+		fraudService:        fraudService,
+		pricingEngine:       pricingEngine,
+		quoteService:        quoteService,
+		productService:      productService,
+		collateralService:   collateralService,
+		repaymentService:    repaymentService,
+		thresholds:          thresholds,
+		fxService:           fxService,
+		phones:              phones,
 	}
 }
 
 func (s *LoanService) SubmitLoan(req *loanModels.SubmitLoanRequest) (*loanModels.Loan, error) {
+	if !req.LoanAmount.IsPositive() {
+		return nil, fmt.Errorf("%w: loan_amount must be greater than 0", ErrInvalidLoanRequest)
+	}
+	if req.Currency == "" {
+		req.Currency = money.DefaultCurrency
+	}
+	if !req.Currency.IsValid() {
+		return nil, fmt.Errorf("%w: unsupported currency %q", ErrInvalidLoanRequest, req.Currency)
+	}
+	req.LoanAmount = req.LoanAmount.In(req.Currency)
+	if req.Channel == "" {
+		req.Channel = loanModels.ChannelDirect
+	}
+	if !req.Channel.IsValid() {
+		return nil, fmt.Errorf("%w: unsupported channel %q", ErrInvalidLoanRequest, req.Channel)
+	}
+	// Phones are matched to customers in E.164, however they were written
+	var err error
+	if req.CustomerPhone, err = s.normalisePhone(req.CustomerPhone); err != nil {
+		return nil, err
+	}
+	for i := range req.Parties {
+		if req.Parties[i].Phone, err = s.normalisePhone(req.Parties[i].Phone); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateParties(req.CustomerPhone, req.Parties); err != nil {
+		return nil, err
+	}
+
 	// Check if customer exists by phone number
 	customer, exists := s.customerRepo.GetCustomerByPhone(req.CustomerPhone)
 
-	if !exists {
-		// Create new customer
-		newCustomer := &models.Customer{
-			Name:  req.CustomerName,
-			Phone: req.CustomerPhone,
+	var score pricing.CreditScore
+	var segment models.EmploymentType
+	if exists {
+		if err := s.exposureChecker.CheckSubmission(customer.ID, req.LoanType, req.LoanAmount); err != nil {
+			return nil, err
 		}
-		customer = s.customerRepo.AddCustomer(newCustomer)
+		score.Score, score.Scored = s.creditService.LatestScore(customer.ID)
+		segment = customer.EmploymentType
 	}
 
 	loan := &loanModels.Loan{
-		CustomerID: customer.ID,
-		LoanAmount: req.LoanAmount,
-		LoanType:   req.LoanType,
+		ApplicantName: req.CustomerName,
+		LoanAmount:    req.LoanAmount,
+		Currency:      req.Currency,
+		LoanType:      req.LoanType,
+		Channel:       req.Channel,
+		TenureMonths:  req.TenureMonths,
+	}
+
+	var savedQuote *quoteModels.LoanQuote
+	if req.QuoteID != nil {
+		q, err := s.quoteService.ValidateForApplication(*req.QuoteID, req.CustomerPhone, req.LoanType, req.LoanAmount, req.TenureMonths)
+		if err != nil {
+			return nil, err
+		}
+		savedQuote = q
+		if loan.TenureMonths == 0 {
+			loan.TenureMonths = q.TenureMonths
+		}
 	}
-	loan, err := s.repo.AddLoan(loan)
+
+	// The application must fit the loan type's active product
+	policy, ok := s.policies.For(loan.LoanType)
+	if !ok {
+		return nil, fmt.Errorf("%w: loan type %s cannot be underwritten", ErrInvalidLoanRequest, loan.LoanType)
+	}
+	_, tenure, err := s.productService.ValidateApplication(
+		loan.LoanType, loan.LoanAmount, loan.TenureMonths,
+		policy.DefaultTenureMonths, segment)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLoanRequest, err)
+	}
+	loan.TenureMonths = tenure
+
+	if q := savedQuote; q != nil {
+		// A saved quote fixes the price the customer was shown
+		loan.QuoteID = &q.ID
+		loan.QuotedRate = &q.AnnualRate
+		loan.QuotedAPR = &q.APR
+		loan.ProcessingFee = &q.ProcessingFee
+		loan.RiskGrade = q.RiskGrade
+		loan.RateCardVersion = q.RateCardVersion
+	} else if _, err := s.pricingEngine.QuoteLoan(loan, score); err != nil {
+		// Quote the rate before anything is stored so an unpriceable request
+		// leaves no trace
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLoanRequest, err)
 	}
 
+	// New customers are registered along with the loan
+	if !exists {
+		customer = &models.Customer{Name: req.CustomerName, Phone: req.CustomerPhone}
+	}
+	parties := make([]*models.Customer, 0, len(req.Parties))
+	for _, partyReq := range req.Parties {
+		parties = append(parties, s.customerByPhone(partyReq.Name, partyReq.Phone))
+		loan.Parties = append(loan.Parties, &loanModels.LoanParty{Role: partyReq.Role})
+	}
+	loan, err = s.repo.AddLoan(loan, customer, parties)
+	if errors.Is(err, repository.ErrQuoteConverted) {
+		return nil, quote.ErrQuoteUsed
+	}
+	if err != nil {
+		return nil, err
+	}
 	return loan, nil
 }
 
@@ -85,7 +236,7 @@ func (s *LoanService) StartLoanProcessor(ctx context.Context) {
 						return
 					}
 					log.Printf("[Worker %d] Processing loan %d", workerID, loan.ID)
-					s.processLoan(loan)
+					s.processLoan(ctx, loan)
 				case <-ctx.Done():
 					log.Printf("[Worker %d] Context cancelled, shutting down", workerID)
 					return
@@ -103,9 +254,31 @@ func (s *LoanService) StartLoanProcessor(ctx context.Context) {
 		case <-ticker.C:
 			loans := s.repo.GetLoansByStatus(loanModels.Applied)
 			for _, loan := range loans {
+				customer, exists := s.customerRepo.GetCustomerByID(loan.CustomerID)
+				if !exists {
+					log.Printf("Customer not found for loan %d", loan.ID)
+					continue
+				}
+
+				// Loans wait until the applicant has verified their phone with an OTP
+				if s.holdForOTP(loan, customer) {
+					continue
+				}
+
+				// Loans from customers without a valid KYC wait until it is verified
+				if s.holdForKYC(loan, customer) {
+					continue
+				}
+
+				// Suspicious loans go to fraud review instead of being processed.
+				// Top-ups come from customers already repaying a loan with us.
+				if loan.ParentLoanID == nil && s.holdForFraudReview(loan, customer) {
+					continue
+				}
+
 				// Update loan status
 				loan.ApplicationStatus = loanModels.Processing
-				if err := s.repo.UpdateLoan(loan); err != nil {
+				if err := s.repo.UpdateLoan(loan, loanModels.Applied); err != nil {
 					log.Printf("Error updating loan %d status: %v", loan.ID, err)
 					continue
 				}
@@ -122,11 +295,74 @@ func (s *LoanService) StartLoanProcessor(ctx context.Context) {
 	}
 }
 
-func (s *LoanService) processLoan(loan *loanModels.Loan) {
-	delay := time.Duration(rand.Intn(20)+5) * time.Second
-	log.Printf("Processing loan %d, waiting %v seconds...", loan.ID, delay.Seconds())
-	time.Sleep(delay)
+// holdForOTP parks the loan in OTP_PENDING when the applicant has not
+// verified their phone, sending them an OTP, and reports whether it did so.
+func (s *LoanService) holdForOTP(loan *loanModels.Loan, customer *models.Customer) bool {
+	if s.otpService.IsVerified(customer) {
+		return false
+	}
+
+	loan.ApplicationStatus = loanModels.OTPPending
+	if err := s.repo.UpdateLoan(loan, loanModels.Applied); err != nil {
+		log.Printf("Error updating loan %d status: %v", loan.ID, err)
+		return true
+	}
+	log.Printf("Loan %d is waiting for customer %d to verify their phone", loan.ID, customer.ID)
+	if err := s.otpService.RequestVerification(customer); err != nil {
+		log.Printf("Error sending OTP to customer %d: %v", customer.ID, err)
+	}
+	return true
+}
+
+// holdForKYC parks the loan in KYC_PENDING when its applicant or any other
+// party has no valid KYC and reports whether it did so.
+func (s *LoanService) holdForKYC(loan *loanModels.Loan, customer *models.Customer) bool {
+	var unverified []*models.Customer
+	for _, party := range append([]*models.Customer{customer}, s.customerRepo.GetLoanPartyCustomers(loan.ID)...) {
+		if !s.kycService.IsVerified(party) {
+			unverified = append(unverified, party)
+		}
+	}
+	if len(unverified) == 0 {
+		return false
+	}
+
+	loan.ApplicationStatus = loanModels.KYCPending
+	if err := s.repo.UpdateLoan(loan, loanModels.Applied); err != nil {
+		log.Printf("Error updating loan %d status: %v", loan.ID, err)
+		return true
+	}
+	for _, party := range unverified {
+		log.Printf("Loan %d is waiting for KYC of customer %d", loan.ID, party.ID)
+		s.notificationService.SendSMS(party.Phone, fmt.Sprintf("Please complete your KYC verification so we can process loan #%d.", loan.ID))
+	}
+	return true
+}
+
+// holdForFraudReview screens the loan and parks it in FRAUD_REVIEW when the
+// fraud rules flag it, reporting whether it held the loan back. A loan that
+// could not be screened is held in APPLIED, to be screened again on the
+// next pass, rather than processed unscreened.
+func (s *LoanService) holdForFraudReview(loan *loanModels.Loan, customer *models.Customer) bool {
+	check, err := s.fraudService.Screen(loan, customer)
+	if err != nil {
+		log.Printf("Error screening loan %d for fraud, will retry: %v", loan.ID, err)
+		return true
+	}
+	if check.Status != fraudModels.PendingReview {
+		return false
+	}
+
+	loan.ApplicationStatus = loanModels.FraudReview
+	if err := s.repo.UpdateLoan(loan, loanModels.Applied); err != nil {
+		log.Printf("Error updating loan %d status: %v", loan.ID, err)
+		return true
+	}
+	log.Printf("Loan %d flagged for fraud review (score %d)", loan.ID, check.Score)
+	return true
+}
 
+func (s *LoanService) processLoan(ctx context.Context, loan *loanModels.Loan) {
 	// Get customer for notification without locking first
 	customer, exists := s.customerRepo.GetCustomerByID(loan.CustomerID)
 	if !exists {
@@ -134,28 +370,81 @@ func (s *LoanService) processLoan(loan *loanModels.Loan) {
 		return
 	}
 
-	// Determine the loan status based on amount
-	var newStatus loanModels.LoanStatus
-	switch {
-	case loan.LoanAmount < constants.MinAmountApproveBySystem:
-
-		newStatus = loanModels.ApprovedBySystem
-		s.notificationService.SendSMS(customer.Phone, "Your loan has been approved by system.")
+	if loan.ParentLoanID != nil {
+		s.processTopUp(ctx, loan, customer)
+		return
+	}
 
-		loan.ApplicationStatus = newStatus
-		if err := s.repo.UpdateLoan(loan); err != nil {
-			log.Printf("Error updating loan %d: %v", loan.ID, err)
+	report, err := s.creditService.PullReport(ctx, loan, customer)
+	if err != nil {
+		// Without a credit report the loan is left for an agent to assess
+		log.Printf("Error pulling credit report for loan %d: %v", loan.ID, err)
+		if err := s.assignToAgent(loan, customer); err != nil {
+			log.Printf("Error assigning loan %d to agent: %v", loan.ID, err)
 		}
+		return
+	}
+	log.Printf("Loan %d credit score %d (history: %t, defaults: %d)", loan.ID, report.Score, report.HasHistory, report.Defaults)
 
-	case loan.LoanAmount > constants.MaxAmountApproveBySystem:
+	coApplicants, err := s.pullCoApplicantReports(ctx, loan)
+	if err != nil {
+		log.Printf("Error pulling co-applicant credit reports for loan %d: %v", loan.ID, err)
+		if err := s.assignToAgent(loan, customer); err != nil {
+			log.Printf("Error assigning loan %d to agent: %v", loan.ID, err)
+		}
+		return
+	}
 
-		newStatus = loanModels.RejectedBySystem
-		s.notificationService.SendSMS(customer.Phone, "loan application has been rejected by system.")
+	income, obligations, err := s.incomeAndObligations(loan, customer, report)
+	if err != nil {
+		// Amounts that cannot be put in the loan's currency are left for an agent
+		log.Printf("Error assessing affordability of loan %d: %v", loan.ID, err)
+		if err := s.assignToAgent(loan, customer); err != nil {
+			log.Printf("Error assigning loan %d to agent: %v", loan.ID, err)
+		}
+		return
+	}
 
-		loan.ApplicationStatus = newStatus
-		if err := s.repo.UpdateLoan(loan); err != nil {
-			log.Printf("Error updating loan %d: %v", loan.ID, err)
+	assessment, err := s.assessAffordability(loan, income, obligations, coApplicants)
+	if err != nil {
+		log.Printf("Error assessing affordability of loan %d: %v", loan.ID, err)
+		if err := s.assignToAgent(loan, customer); err != nil {
+			log.Printf("Error assigning loan %d to agent: %v", loan.ID, err)
 		}
+		return
+	}
+	log.Printf("Loan %d affordability %s (EMI %s, DTI %.2f, FOIR %.2f)",
+		loan.ID, assessment.Outcome, assessment.ProposedEMI, assessment.DTI, assessment.FOIR)
+
+	security := s.collateralService.Summarize(loan)
+	threshold, hasThreshold := s.thresholds.For(loan.Currency)
+	if security.Secured {
+		if security.LTV != nil {
+			log.Printf("Loan %d LTV %.2f (limit %.2f)", loan.ID, *security.LTV, security.MaxLTV)
+		} else {
+			log.Printf("Loan %d has no valued collateral", loan.ID)
+		}
+	}
+
+	// Determine the loan status based on credit history, affordability, collateral and amount.
+	// Loans in a currency without approval thresholds are left to an agent.
+	switch {
+	case report.HasHistory && (report.Defaults > 0 || report.Score < constants.MinCreditScore):
+		s.decideBySystem(loan, loanModels.RejectedBySystem, "loan application has been rejected by system.")
+
+	case assessment.Outcome == loanModels.AffordabilityReject:
+		s.decideBySystem(loan, loanModels.RejectedBySystem, "loan application has been rejected by system.")
+
+	case security.LTV != nil && !security.WithinLimit:
+		s.decideBySystem(loan, loanModels.RejectedBySystem, "loan application has been rejected by system.")
+
+	case hasThreshold && loan.LoanAmount.GreaterThan(threshold.Max):
+		s.decideBySystem(loan, loanModels.RejectedBySystem, "loan application has been rejected by system.")
+
+	case hasThreshold && loan.LoanAmount.LessThan(threshold.Min) &&
+		report.HasHistory && report.Score >= constants.AutoApproveMinCreditScore && coApplicants.Defaults == 0 &&
+		assessment.Outcome == loanModels.AffordabilityPass && security.WithinLimit:
+		s.approveBySystem(loan, customer, pricing.CreditScore{Score: report.Score, Scored: report.HasHistory})
 
 	default:
 		err := s.assignToAgent(loan, customer)
@@ -165,6 +454,139 @@ func (s *LoanService) processLoan(loan *loanModels.Loan) {
 	}
 }
 
+// approveBySystem approves a loan that passed the automatic rules, after
+// re-checking exposure limits and fixing its final price.
+func (s *LoanService) approveBySystem(loan *loanModels.Loan, customer *models.Customer, score pricing.CreditScore) {
+	if err := s.exposureChecker.CheckDecision(loan); err != nil {
+		log.Printf("Loan %d breaches exposure limits: %v", loan.ID, err)
+		s.decideBySystem(loan, loanModels.RejectedBySystem, "loan application has been rejected by system.")
+		return
+	}
+	if _, err := s.pricingEngine.PriceApproval(loan, score); err != nil {
+		// Leave pricing failures for an agent rather than approving unpriced
+		log.Printf("Error pricing loan %d: %v", loan.ID, err)
+		if err := s.assignToAgent(loan, customer); err != nil {
+			log.Printf("Error assigning loan %d to agent: %v", loan.ID, err)
+		}
+		return
+	}
+	s.decideBySystem(loan, loanModels.ApprovedBySystem, "Your loan has been approved by system.")
+}
+
+// coApplicantCredit totals the credit position of a loan's co-applicants in
+// the loan's currency.
+type coApplicantCredit struct {
+	Income      money.Money
+	Obligations money.Money
+	Defaults    int
+}
+
+// pullCoApplicantReports pulls a credit report for each co-applicant on the
+// loan and totals their income, obligations and defaults. Guarantors are not
+// assessed.
+func (s *LoanService) pullCoApplicantReports(ctx context.Context, loan *loanModels.Loan) (coApplicantCredit, error) {
+	var total coApplicantCredit
+	roles := make(map[int]loanModels.PartyRole)
+	for _, party := range s.repo.GetParties(loan.ID) {
+		roles[party.CustomerID] = party.Role
+	}
+	for _, party := range s.customerRepo.GetLoanPartyCustomers(loan.ID) {
+		if roles[party.ID] != loanModels.CoApplicant {
+			continue
+		}
+		report, err := s.creditService.PullReport(ctx, loan, party)
+		if err != nil {
+			return total, fmt.Errorf("customer %d: %w", party.ID, err)
+		}
+		income, obligations, err := s.incomeAndObligations(loan, party, report)
+		if err != nil {
+			return total, err
+		}
+		total.Income = total.Income.Add(income)
+		total.Obligations = total.Obligations.Add(obligations)
+		total.Defaults += report.Defaults
+	}
+	return total, nil
+}
+
+// incomeAndObligations returns a customer's monthly income and the monthly
+// obligations on their credit report, both converted to the loan's currency.
+func (s *LoanService) incomeAndObligations(loan *loanModels.Loan, customer *models.Customer, report *creditModels.CreditReport) (money.Money, money.Money, error) {
+	income, err := s.inLoanCurrency(loan, customer.MonthlyIncome)
+	if err != nil {
+		return money.Money{}, money.Money{}, fmt.Errorf("customer %d monthly income: %w", customer.ID, err)
+	}
+	obligations, err := s.inLoanCurrency(loan, report.MonthlyObligations)
+	if err != nil {
+		return money.Money{}, money.Money{}, fmt.Errorf("customer %d credit report obligations: %w", customer.ID, err)
+	}
+	return income, obligations, nil
+}
+
+// inLoanCurrency converts an amount to the loan's currency at the latest
+// rate. A nonzero amount recorded without a currency cannot be compared with
+// the loan and fails rather than being taken to be in it.
+func (s *LoanService) inLoanCurrency(loan *loanModels.Loan, amount money.Money) (money.Money, error) {
+	if amount.IsZero() {
+		return money.New(0, loan.Currency), nil
+	}
+	if !amount.Currency().IsValid() {
+		return money.Money{}, fmt.Errorf("%s has no currency", amount)
+	}
+	return s.fxService.Convert(amount, loan.Currency)
+}
+
+// assessAffordability runs the affordability check for the loan's type,
+// pooling co-applicants' income and obligations with the applicant's, stores
+// the breakdown on the loan and fixes its tenure. Amounts are in the loan's
+// currency. Loan types without a policy are not assessed.
+func (s *LoanService) assessAffordability(loan *loanModels.Loan, income, obligations money.Money, coApplicants coApplicantCredit) (*loanModels.AffordabilityAssessment, error) {
+	policy, ok := s.policies.For(loan.LoanType)
+	if !ok {
+		return nil, fmt.Errorf("no affordability policy for loan type %s", loan.LoanType)
+	}
+	// Assess against the quoted rate rather than the policy's assumed one
+	if loan.QuotedRate != nil {
+		policy.AnnualRate = *loan.QuotedRate
+	}
+	assessment := underwriting.Assess(policy, underwriting.Input{
+		LoanAmount:             loan.LoanAmount,
+		TenureMonths:           loan.TenureMonths,
+		MonthlyIncome:          income,
+		ExistingObligations:    obligations,
+		CoApplicantIncome:      coApplicants.Income,
+		CoApplicantObligations: coApplicants.Obligations,
+	})
+	assessment.LoanID = loan.ID
+	if err := s.repo.AddAffordabilityAssessment(assessment); err != nil {
+		log.Printf("Error saving affordability assessment for loan %d: %v", loan.ID, err)
+	}
+	if loan.TenureMonths != assessment.TenureMonths {
+		loan.TenureMonths = assessment.TenureMonths
+		if err := s.repo.UpdateLoan(loan, loan.ApplicationStatus); err != nil {
+			log.Printf("Error updating loan %d tenure: %v", loan.ID, err)
+		}
+	}
+	loan.Affordability = assessment
+	return assessment, nil
+}
+
+// decideBySystem records the system's decision on a loan being processed
+// and tells every party, once the decision is saved.
+func (s *LoanService) decideBySystem(loan *loanModels.Loan, status loanModels.LoanStatus, message string) {
+	loan.ApplicationStatus = status
+	if err := s.repo.UpdateLoan(loan, loanModels.Processing); err != nil {
+		log.Printf("Error updating loan %d: %v", loan.ID, err)
+		return
+	}
+	s.notificationService.SendSMSToAll(s.customerRepo.GetLoanPartyPhones(loan.ID), message)
+	if status == loanModels.ApprovedBySystem {
+		if _, err := s.repaymentService.CreateSchedule(loan, time.Now()); err != nil {
+			log.Printf("Error creating repayment schedule for loan %d: %v", loan.ID, err)
+		}
+	}
+}
+
 func (s *LoanService) assignToAgent(loan *loanModels.Loan, customer *models.Customer) error {
 	agent := s.agentRepo.GetAvailableAgent()
 	if agent == nil {
@@ -172,12 +594,13 @@ func (s *LoanService) assignToAgent(loan *loanModels.Loan, customer *models.Cust
 		return fmt.Errorf("no available agent for loan %d", loan.ID)
 	}
 
-	// Assign the loan to agent first
+	// Assign the loan to agent first, sharing it with the agent's team
 	loan.AssignedAgentID = &agent.ID
+	loan.ReviewTeamID = agent.ManagerID
 	loan.ApplicationStatus = loanModels.UnderReview
 
 	// Create assignment record using transaction
-	if err := s.repo.AssignLoanToAgent(loan, agent.ID); err != nil {
+	if err := s.repo.AssignLoanToAgent(loan, agent.ID, agent.ManagerID); err != nil {
 		log.Printf("Error assigning loan %d to agent %d: %v", loan.ID, agent.ID, err)
 		return err
 	}
@@ -191,21 +614,67 @@ func (s *LoanService) assignToAgent(loan *loanModels.Loan, customer *models.Cust
 			fmt.Sprintf("Loan #%d assigned to your team member %s", loan.ID, agent.Name))
 	}
 
+	// Let the customer know which documents the agent still needs
+	if checklist := s.documentService.BuildChecklist(loan); !checklist.Complete {
+		s.notificationService.SendSMS(customer.Phone, document.MissingDocumentsMessage(loan.ID, checklist.Missing))
+	}
+
 	log.Printf("Loan %d assigned to agent %d (%s)", loan.ID, agent.ID, agent.Name)
 	return nil
 }
 
-func (s *LoanService) GetStatusCount() []loanModels.StatusCountResponse {
-	counts := s.repo.GetStatusCount()
+// GetStatusCount counts loans per status, with each status's total amount
+// converted to the report currency.
+func (s *LoanService) GetStatusCount(currency string) ([]loanModels.StatusCountResponse, error) {
+	reportCurrency, err := s.fxService.ReportCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[loanModels.LoanStatus]int)
+	amounts := make(map[loanModels.LoanStatus][]money.Money)
+	for _, total := range s.repo.GetStatusTotals() {
+		counts[total.Status] += total.Count
+		amounts[total.Status] = append(amounts[total.Status], total.Amount)
+	}
+
 	var result []loanModels.StatusCountResponse
 	allStatuses := []loanModels.LoanStatus{
-		loanModels.Applied, loanModels.Processing, loanModels.ApprovedBySystem, loanModels.RejectedBySystem,
-		loanModels.UnderReview, loanModels.ApprovedByAgent, loanModels.RejectedByAgent,
+		loanModels.Applied, loanModels.OTPPending, loanModels.KYCPending, loanModels.Processing, loanModels.ApprovedBySystem, loanModels.RejectedBySystem,
+		loanModels.FraudReview, loanModels.UnderReview, loanModels.ApprovedByAgent, loanModels.RejectedByAgent, loanModels.Closed,
 	}
 	for _, status := range allStatuses {
-		result = append(result, loanModels.StatusCountResponse{Status: string(status), Count: counts[status]})
+		total, err := s.fxService.Total(fx.PurposeStatusCount, reportCurrency, amounts[status])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, loanModels.StatusCountResponse{
+			Status:      string(status),
+			Count:       counts[status],
+			TotalAmount: total,
+			Currency:    reportCurrency,
+		})
 	}
-	return result
+	return result, nil
+}
+
+// GetPortfolio totals the sanctioned amount of approved loans that are still
+// being repaid, per currency and converted to the report currency.
+func (s *LoanService) GetPortfolio(currency string) (*loanModels.PortfolioResponse, error) {
+	reportCurrency, err := s.fxService.ReportCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	portfolio := &loanModels.PortfolioResponse{Currency: reportCurrency, ByCurrency: s.repo.GetPortfolioTotals()}
+	amounts := make([]money.Money, 0, len(portfolio.ByCurrency))
+	for _, total := range portfolio.ByCurrency {
+		portfolio.Loans += total.Count
+		amounts = append(amounts, total.Amount)
+	}
+	portfolio.TotalAmount, err = s.fxService.Total(fx.PurposePortfolio, reportCurrency, amounts)
+	if err != nil {
+		return nil, err
+	}
+	return portfolio, nil
 }
 
 func (s *LoanService) GetLoansByStatus(status loanModels.LoanStatus, page, size int) []*loanModels.Loan {
@@ -230,10 +699,6 @@ func (s *LoanService) GetLoanByID(id int) (*loanModels.Loan, bool) {
 	return s.repo.GetLoanByID(id)
 }
 
-func (s *LoanService) UpdateLoan(loan *loanModels.Loan) error {
-	return s.repo.UpdateLoan(loan)
-}
-
-func (s *LoanService) GetTopCustomers() []loanModels.TopCustomerResponse {
-	return s.customerRepo.GetTopCustomers()
+func (s *LoanService) UpdateLoan(loan *loanModels.Loan, from loanModels.LoanStatus) error {
+	return s.repo.UpdateLoan(loan, from)
 }
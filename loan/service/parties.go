@@ -0,0 +1,119 @@
+package service
+
+import (
+	"fmt"
+
+	"loan-module/apperror"
+	"loan-module/customer/models"
+	loanModels "loan-module/loan/models"
+)
+
+var (
+	ErrLoanNotFound     = apperror.NotFound("LOAN_NOT_FOUND", "loan not found")
+	ErrCustomerNotFound = apperror.NotFound("CUSTOMER_NOT_FOUND", "customer not found")
+	ErrPartyNotFound    = apperror.NotFound("PARTY_NOT_FOUND", "party not found")
+	ErrPartiesLocked    = apperror.InvalidTransition("PARTIES_LOCKED", "parties can only be changed before the loan is processed")
+)
+
+// partiesEditable reports whether parties can still be added or removed: only
+// until the loan is picked up for processing.
+func partiesEditable(status loanModels.LoanStatus) bool {
+	return status == loanModels.Applied || status == loanModels.OTPPending || status == loanModels.KYCPending
+}
+
+// validateParties checks that no one is named twice and that the applicant is
+// not also a party.
+func validateParties(applicantPhone string, parties []loanModels.PartyRequest) error {
+	seen := map[string]bool{applicantPhone: true}
+	for _, party := range parties {
+		if !party.Role.IsValid() {
+			return fmt.Errorf("%w: unknown party role %q", ErrInvalidLoanRequest, party.Role)
+		}
+		if seen[party.Phone] {
+			return fmt.Errorf("%w: %s is named more than once on the application", ErrInvalidLoanRequest, party.Phone)
+		}
+		seen[party.Phone] = true
+	}
+	return nil
+}
+
+// normalisePhone writes a phone from a loan application in E.164.
+func (s *LoanService) normalisePhone(raw string) (string, error) {
+	number, err := s.phones.Normalize(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidLoanRequest, err)
+	}
+	return number, nil
+}
+
+// customerByPhone finds the customer with the phone, or returns a new,
+// unsaved customer for the repository to register along with the loan.
+func (s *LoanService) customerByPhone(name, phone string) *models.Customer {
+	if customer, exists := s.customerRepo.GetCustomerByPhone(phone); exists {
+		return customer
+	}
+	return &models.Customer{Name: name, Phone: phone}
+}
+
+func (s *LoanService) GetParties(loanID int) ([]*loanModels.LoanParty, error) {
+	if _, exists := s.repo.GetLoanByID(loanID); !exists {
+		return nil, ErrLoanNotFound
+	}
+	return s.repo.GetParties(loanID), nil
+}
+
+func (s *LoanService) AddParty(loanID int, req *loanModels.PartyRequest) (*loanModels.LoanParty, error) {
+	loan, exists := s.repo.GetLoanByID(loanID)
+	if !exists {
+		return nil, ErrLoanNotFound
+	}
+	if !partiesEditable(loan.ApplicationStatus) {
+		return nil, ErrPartiesLocked
+	}
+	applicant, exists := s.customerRepo.GetCustomerByID(loan.CustomerID)
+	if !exists {
+		return nil, ErrCustomerNotFound
+	}
+
+	number, err := s.normalisePhone(req.Phone)
+	if err != nil {
+		return nil, err
+	}
+	req.Phone = number
+	if err := validateParties(applicant.Phone, []loanModels.PartyRequest{*req}); err != nil {
+		return nil, err
+	}
+	customer := s.customerByPhone(req.Name, req.Phone)
+	for _, existing := range loan.Parties {
+		if customer.ID != 0 && existing.CustomerID == customer.ID {
+			return nil, fmt.Errorf("%w: %s is already a party to this loan", ErrInvalidLoanRequest, req.Phone)
+		}
+	}
+
+	party := &loanModels.LoanParty{
+		LoanID: loan.ID,
+		Role:   req.Role,
+	}
+	if err := s.repo.AddParty(party, customer); err != nil {
+		return nil, err
+	}
+	return party, nil
+}
+
+func (s *LoanService) RemoveParty(loanID, partyID int) error {
+	loan, exists := s.repo.GetLoanByID(loanID)
+	if !exists {
+		return ErrLoanNotFound
+	}
+	if !partiesEditable(loan.ApplicationStatus) {
+		return ErrPartiesLocked
+	}
+	removed, err := s.repo.RemoveParty(loanID, partyID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrPartyNotFound
+	}
+	return nil
+}
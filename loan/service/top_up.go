@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"loan-module/apperror"
+	"loan-module/constants"
+	"loan-module/customer/models"
+	loanModels "loan-module/loan/models"
+	"loan-module/loan/repository"
+	"loan-module/pricing"
+)
+
+var ErrTopUpNotEligible = apperror.Unprocessable("TOP_UP_NOT_ELIGIBLE", "loan is not eligible for a top-up")
+
+// TopUp applies for more money on an active loan that has a clean repayment
+// record. The top-up is a new loan of the same type and parties that goes
+// through the lighter processTopUp path.
+func (s *LoanService) TopUp(parentID int, req *loanModels.TopUpRequest) (*loanModels.Loan, error) {
+	if !req.LoanAmount.IsPositive() {
+		return nil, fmt.Errorf("%w: loan_amount must be greater than 0", ErrInvalidLoanRequest)
+	}
+	parent, exists := s.repo.GetLoanByID(parentID)
+	if !exists {
+		return nil, ErrLoanNotFound
+	}
+	if parent.ApplicationStatus != loanModels.ApprovedBySystem && parent.ApplicationStatus != loanModels.ApprovedByAgent {
+		return nil, fmt.Errorf("%w: loan is not active", ErrTopUpNotEligible)
+	}
+	standing, err := s.repaymentService.GetStanding(parent.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTopUpNotEligible, err)
+	}
+	if standing.OverdueInstalments > 0 {
+		return nil, fmt.Errorf("%w: %d instalments are overdue", ErrTopUpNotEligible, standing.OverdueInstalments)
+	}
+	if standing.PaidInstalments < constants.TopUpMinPaidInstalments {
+		return nil, fmt.Errorf("%w: at least %d instalments must be repaid first", ErrTopUpNotEligible, constants.TopUpMinPaidInstalments)
+	}
+
+	customer, exists := s.customerRepo.GetCustomerByID(parent.CustomerID)
+	if !exists {
+		return nil, ErrCustomerNotFound
+	}
+	// A top-up is lent in the currency of the loan it tops up
+	amount := req.LoanAmount.In(parent.Currency)
+	if err := s.exposureChecker.CheckSubmission(customer.ID, parent.LoanType, amount); err != nil {
+		return nil, err
+	}
+
+	loan := &loanModels.Loan{
+		CustomerID:    customer.ID,
+		ApplicantName: customer.Name,
+		LoanAmount:    amount,
+		Currency:      parent.Currency,
+		Channel:       parent.Channel,
+		LoanType:      parent.LoanType,
+		TenureMonths:  req.TenureMonths,
+		ParentLoanID:  &parent.ID,
+	}
+	policy, ok := s.policies.For(loan.LoanType)
+	if !ok {
+		return nil, fmt.Errorf("%w: loan type %s cannot be underwritten", ErrInvalidLoanRequest, loan.LoanType)
+	}
+	_, tenure, err := s.productService.ValidateApplication(
+		loan.LoanType, loan.LoanAmount, loan.TenureMonths,
+		policy.DefaultTenureMonths, customer.EmploymentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLoanRequest, err)
+	}
+	loan.TenureMonths = tenure
+
+	var score pricing.CreditScore
+	score.Score, score.Scored = s.creditService.LatestScore(customer.ID)
+	if _, err := s.pricingEngine.QuoteLoan(loan, score); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLoanRequest, err)
+	}
+
+	for _, party := range parent.Parties {
+		loan.Parties = append(loan.Parties, &loanModels.LoanParty{CustomerID: party.CustomerID, Role: party.Role})
+	}
+	loan, err = s.repo.AddLoan(loan, customer, nil)
+	if errors.Is(err, repository.ErrTopUpOpen) {
+		return nil, fmt.Errorf("%w: another top-up of the loan is awaiting a decision", ErrTopUpNotEligible)
+	}
+	return loan, err
+}
+
+// processTopUp is the lighter underwriting path for top-ups. The parent
+// loan's repayment record stands in for a full review: only the applicant's
+// and co-applicants' credit is pulled, the parent's EMI is added to the
+// applicant's obligations, and there is no lower amount limit on automatic
+// approval.
+func (s *LoanService) processTopUp(ctx context.Context, loan *loanModels.Loan, customer *models.Customer) {
+	report, err := s.creditService.PullReport(ctx, loan, customer)
+	if err != nil {
+		log.Printf("Error pulling credit report for top-up %d: %v", loan.ID, err)
+		if err := s.assignToAgent(loan, customer); err != nil {
+			log.Printf("Error assigning loan %d to agent: %v", loan.ID, err)
+		}
+		return
+	}
+	coApplicants, err := s.pullCoApplicantReports(ctx, loan)
+	if err != nil {
+		log.Printf("Error pulling co-applicant credit reports for top-up %d: %v", loan.ID, err)
+		if err := s.assignToAgent(loan, customer); err != nil {
+			log.Printf("Error assigning loan %d to agent: %v", loan.ID, err)
+		}
+		return
+	}
+	standing, err := s.repaymentService.GetStanding(*loan.ParentLoanID)
+	if err != nil {
+		log.Printf("Error reading repayment standing of loan %d for top-up %d: %v", *loan.ParentLoanID, loan.ID, err)
+		if err := s.assignToAgent(loan, customer); err != nil {
+			log.Printf("Error assigning loan %d to agent: %v", loan.ID, err)
+		}
+		return
+	}
+
+	income, obligations, err := s.incomeAndObligations(loan, customer, report)
+	if err != nil {
+		log.Printf("Error assessing affordability of top-up %d: %v", loan.ID, err)
+		if err := s.assignToAgent(loan, customer); err != nil {
+			log.Printf("Error assigning loan %d to agent: %v", loan.ID, err)
+		}
+		return
+	}
+
+	assessment, err := s.assessAffordability(loan, income, obligations.Add(standing.EMI), coApplicants)
+	if err != nil {
+		log.Printf("Error assessing affordability of top-up %d: %v", loan.ID, err)
+		if err := s.assignToAgent(loan, customer); err != nil {
+			log.Printf("Error assigning loan %d to agent: %v", loan.ID, err)
+		}
+		return
+	}
+	security := s.collateralService.Summarize(loan)
+	threshold, hasThreshold := s.thresholds.For(loan.Currency)
+	log.Printf("Top-up %d of loan %d: score %d, affordability %s",
+		loan.ID, *loan.ParentLoanID, report.Score, assessment.Outcome)
+
+	switch {
+	case report.HasHistory && (report.Defaults > 0 || report.Score < constants.MinCreditScore),
+		standing.OverdueInstalments > 0,
+		assessment.Outcome == loanModels.AffordabilityReject,
+		security.LTV != nil && !security.WithinLimit,
+		hasThreshold && loan.LoanAmount.GreaterThan(threshold.Max):
+		s.decideBySystem(loan, loanModels.RejectedBySystem, "loan application has been rejected by system.")
+
+	case report.HasHistory && report.Score >= constants.AutoApproveMinCreditScore && coApplicants.Defaults == 0 &&
+		assessment.Outcome == loanModels.AffordabilityPass && security.WithinLimit:
+		s.approveBySystem(loan, customer, pricing.CreditScore{Score: report.Score, Scored: report.HasHistory})
+
+	default:
+		if err := s.assignToAgent(loan, customer); err != nil {
+			log.Printf("Error assigning loan %d to agent: %v", loan.ID, err)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"loan-module/apperror"
+	"loan-module/constants"
 	"loan-module/providers"
 	"log"
 	"net/http"
@@ -12,17 +14,68 @@ import (
 	agentRepo "loan-module/agent/repository"
 	agentService "loan-module/agent/service"
 
+	collateralHandler "loan-module/collateral/handler"
+	collateralRepo "loan-module/collateral/repository"
+	collateralService "loan-module/collateral/service"
+
+	creditBureau "loan-module/credit/bureau"
+	creditHandler "loan-module/credit/handler"
+	creditRepo "loan-module/credit/repository"
+	creditService "loan-module/credit/service"
+
 	customerHandler "loan-module/customer/handler"
 	customerRepo "loan-module/customer/repository"
 	customerService "loan-module/customer/service"
 
+	documentHandler "loan-module/document/handler"
+	documentRepo "loan-module/document/repository"
+	documentService "loan-module/document/service"
+	documentStorage "loan-module/document/storage"
+
+	fraudHandler "loan-module/fraud/handler"
+	fraudRepo "loan-module/fraud/repository"
+	fraudService "loan-module/fraud/service"
+
+	fxHandler "loan-module/fx/handler"
+	fxRepo "loan-module/fx/repository"
+	fxService "loan-module/fx/service"
+
+	kycHandler "loan-module/kyc/handler"
+	kycProvider "loan-module/kyc/provider"
+	kycRepo "loan-module/kyc/repository"
+	kycService "loan-module/kyc/service"
+
+	otpHandler "loan-module/otp/handler"
+	otpRepo "loan-module/otp/repository"
+	otpService "loan-module/otp/service"
+
+	productHandler "loan-module/product/handler"
+	productRepo "loan-module/product/repository"
+	productService "loan-module/product/service"
+
+	repaymentHandler "loan-module/repayment/handler"
+	repaymentRepo "loan-module/repayment/repository"
+	repaymentService "loan-module/repayment/service"
+
+	quoteHandler "loan-module/quote/handler"
+	quoteRepo "loan-module/quote/repository"
+	quoteService "loan-module/quote/service"
+
 	loanHandler "loan-module/loan/handler"
 	loanRepo "loan-module/loan/repository"
 	loanService "loan-module/loan/service"
 
-	agentModels "loan-module/agent/models"
+	reportHandler "loan-module/report/handler"
+	reportRepo "loan-module/report/repository"
+	reportService "loan-module/report/service"
+
+	"loan-module/exposure"
+	"loan-module/money"
 	"loan-module/notification"
+	"loan-module/phone"
+	"loan-module/pricing"
 	database "loan-module/repository"
+	"loan-module/underwriting"
 )
 
 func main() {
@@ -43,55 +96,203 @@ func main() {
 	customerRepository := customerRepo.NewCustomerRepository(db)
 	agentRepository := agentRepo.NewAgentRepository(db)
 	loanRepository := loanRepo.NewLoanRepository(db)
+	kycRepository := kycRepo.NewKYCRepository(db)
+	otpRepository := otpRepo.NewOTPRepository(db)
+	documentRepository := documentRepo.NewDocumentRepository(db)
+	creditRepository := creditRepo.NewCreditRepository(db)
+	fraudRepository := fraudRepo.NewFraudRepository(db)
+	quoteRepository := quoteRepo.NewQuoteRepository(db)
+	productRepository := productRepo.NewProductRepository(db)
+	collateralRepository := collateralRepo.NewCollateralRepository(db)
+	repaymentRepository := repaymentRepo.NewRepaymentRepository(db)
+	fxRepository := fxRepo.NewFXRepository(db)
+	reportRepository := reportRepo.NewReportRepository(db)
+	notificationRepository := notification.NewRepository(db)
+
+	// Initialize document storage
+	storagePath := config.Storage.LocalPath
+	if storagePath == "" {
+		storagePath = constants.DefaultDocumentStoragePath
+	}
+	documentStore, err := documentStorage.NewLocalStorage(storagePath)
+	if err != nil {
+		log.Fatal("Failed to initialize document storage: ", err)
+	}
+
+	// Initialize credit bureau
+	bureau, err := creditBureau.NewFileBureau(config.CreditBureau.StubFile)
+	if err != nil {
+		log.Fatal("Failed to initialize credit bureau: ", err)
+	}
+
+	// Initialize exchange rates
+	fxService := fxService.NewFXService(fxRepository, money.Currency(config.FX.ReportingCurrency))
+	if !fxService.ReportingCurrency().IsValid() {
+		log.Fatal("Invalid fx configuration: unsupported reporting currency ", fxService.ReportingCurrency())
+	}
+	if config.FX.RatesFile != "" {
+		if err := fxService.LoadFile(config.FX.RatesFile); err != nil {
+			log.Fatal("Failed to load exchange rates: ", err)
+		}
+	}
 
 	// Initialize notification
-	notificationService := notification.NewNotificationService()
-	customerService := customerService.NewCustomerService(customerRepository)
-	loanService := loanService.NewLoanService(loanRepository, agentRepository, customerRepository, notificationService)
-	agentService := agentService.NewAgentService(agentRepository, loanRepository, customerRepository, notificationService)
+	notificationService := notification.NewNotificationService(notificationRepository)
+
+	// Load rule configuration
+	documentChecklist, err := documentService.ChecklistFromConfig(config.DocumentChecklist)
+	if err != nil {
+		log.Fatal("Invalid document checklist configuration: ", err)
+	}
+	affordabilityPolicies, err := underwriting.PoliciesFromConfig(config.Underwriting.Affordability)
+	if err != nil {
+		log.Fatal("Invalid underwriting configuration: ", err)
+	}
+	rateCard, err := pricing.RateCardFromConfig(config.Pricing)
+	if err != nil {
+		log.Fatal("Invalid pricing configuration: ", err)
+	}
+	ltvLimits, err := underwriting.LTVLimitsFromConfig(config.Underwriting.LTVLimits)
+	if err != nil {
+		log.Fatal("Invalid underwriting configuration: ", err)
+	}
+	approvalThresholds, err := underwriting.ApprovalThresholdsFromConfig(config.Underwriting.ApprovalThresholds)
+	if err != nil {
+		log.Fatal("Invalid underwriting configuration: ", err)
+	}
+	prepaymentCharges, err := repaymentService.ChargesFromConfig(config.Prepayment)
+	if err != nil {
+		log.Fatal("Invalid prepayment configuration: ", err)
+	}
+	phones, err := phone.NewNormalizer(config.Phone.DefaultRegion)
+	if err != nil {
+		log.Fatal("Invalid phone configuration: ", err)
+	}
+	pricingEngine := pricing.NewEngine(rateCard, fxService)
+	exposureChecker := exposure.NewChecker(loanRepository, fxService, config.ExposureLimits)
+
+	// Initialize services
+	customerService := customerService.NewCustomerService(customerRepository, loanRepository, documentRepository, repaymentRepository, notificationRepository, fxService, phones)
+	productService := productService.NewProductService(productRepository, pricingEngine, affordabilityPolicies)
+	documentService := documentService.NewDocumentService(documentRepository, loanRepository, documentStore, documentChecklist, productService)
+	kycService := kycService.NewKYCService(kycRepository, customerRepository, loanRepository, kycProvider.NewStubProvider(), notificationService)
+	otpService := otpService.NewOTPService(otpRepository, customerRepository, loanRepository, notificationService)
+	fraudService := fraudService.NewFraudService(fraudRepository, loanRepository, customerRepository, notificationService, approvalThresholds)
+	creditService := creditService.NewCreditService(creditRepository, bureau)
+	quoteService := quoteService.NewQuoteService(quoteRepository, customerRepository, creditService, exposureChecker, pricingEngine, affordabilityPolicies, productService, phones)
+	repaymentService := repaymentService.NewRepaymentService(repaymentRepository, loanRepository, customerRepository, notificationService, prepaymentCharges)
+	collateralService := collateralService.NewCollateralService(collateralRepository, loanRepository, repaymentService, ltvLimits)
+	loanService := loanService.NewLoanService(loanRepository, agentRepository, customerRepository, notificationService, kycService, otpService, documentService, creditService, affordabilityPolicies, exposureChecker, fraudService, pricingEngine, quoteService, productService, collateralService, repaymentService, approvalThresholds, fxService, phones)
+	reportService := reportService.NewReportService(reportRepository, agentRepository)
+	agentService := agentService.NewAgentService(agentRepository, loanRepository, customerRepository, notificationService, documentService, exposureChecker, creditService, pricingEngine, collateralService, repaymentService, approvalThresholds)
 
 	// Initialize handlers
 	customerHandler := customerHandler.NewCustomerHandler(customerService)
 	loanHandler := loanHandler.NewLoanHandler(loanService)
 	agentHandler := agentHandler.NewAgentHandler(agentService)
-
-	// Initialize sample data
-	initSampleData(agentRepository)
+	kycHandler := kycHandler.NewKYCHandler(kycService)
+	otpHandler := otpHandler.NewOTPHandler(otpService)
+	documentHandler := documentHandler.NewDocumentHandler(documentService)
+	creditHandler := creditHandler.NewCreditHandler(creditService)
+	fraudHandler := fraudHandler.NewFraudHandler(fraudService)
+	quoteHandler := quoteHandler.NewQuoteHandler(quoteService)
+	productHandler := productHandler.NewProductHandler(productService)
+	collateralHandler := collateralHandler.NewCollateralHandler(collateralService)
+	repaymentHandler := repaymentHandler.NewRepaymentHandler(repaymentService)
+	fxHandler := fxHandler.NewFXHandler(fxService)
+	reportHandler := reportHandler.NewReportHandler(reportService)
 
 	// Start loan processor with context
 	go loanService.StartLoanProcessor(rootCtx)
 
 	// Setup router
-	router := gin.Default()
+	router := gin.New()
+	router.Use(gin.Logger(), apperror.Recovery(), apperror.Middleware())
+	router.NoRoute(apperror.NoRoute)
 	v1 := router.Group("/api/v1")
 	{
 		// Customer endpoints
 		v1.POST("/customers", customerHandler.CreateCustomer)
 		v1.GET("/customers/:id", customerHandler.GetCustomerByID)
-		v1.GET("/customers", customerHandler.GetAllCustomers)
+		v1.GET("/customers/:id/overview", customerHandler.GetOverview)
+		v1.GET("/customers", customerHandler.SearchCustomers)
 		v1.GET("/customers/top", customerHandler.GetTopCustomers)
+		v1.PUT("/customers/:id", customerHandler.UpdateCustomer)
+		v1.PATCH("/customers/:id", customerHandler.PatchCustomer)
+		v1.DELETE("/customers/:id", customerHandler.DeleteCustomer)
+		v1.POST("/customers/:id/merge", customerHandler.MergeCustomers)
+		v1.POST("/customers/:id/kyc", kycHandler.VerifyKYC)
+		v1.GET("/customers/:id/kyc", kycHandler.GetKYCStatus)
+		v1.POST("/customers/:id/otp", otpHandler.SendOTP)
+		v1.POST("/customers/:id/otp/verify", otpHandler.VerifyOTP)
 
 		// Loan endpoints
 		v1.POST("/loans", loanHandler.SubmitLoan)
+		v1.POST("/loans/quote", quoteHandler.CreateQuote)
 		v1.GET("/loans/status-count", loanHandler.GetStatusCount)
+		v1.GET("/loans/portfolio", loanHandler.GetPortfolio)
 		v1.GET("/loans", loanHandler.GetLoansByStatus)
 		v1.GET("/loans/:id", loanHandler.GetLoanByID)
+		v1.GET("/loans/:id/credit-report", creditHandler.GetCreditReport)
+		v1.GET("/loans/:id/parties", loanHandler.GetParties)
+		v1.POST("/loans/:id/parties", loanHandler.AddParty)
+		v1.DELETE("/loans/:id/parties/:party_id", loanHandler.RemoveParty)
+		v1.POST("/loans/:id/documents", documentHandler.UploadDocument)
+		v1.GET("/loans/:id/documents", documentHandler.ListDocuments)
+		v1.GET("/loans/:id/documents/checklist", documentHandler.GetChecklist)
+		v1.GET("/loans/:id/documents/:document_id", documentHandler.DownloadDocument)
+		v1.POST("/loans/:id/collateral", collateralHandler.AddCollateral)
+		v1.GET("/loans/:id/collateral", collateralHandler.GetCollateral)
+		v1.POST("/loans/:id/collateral/:collateral_id/valuations", collateralHandler.AddValuation)
+		v1.PUT("/loans/:id/collateral/:collateral_id/lien", collateralHandler.UpdateLien)
+		v1.GET("/loans/:id/schedule", repaymentHandler.GetSchedule)
+		v1.GET("/loans/:id/schedules", repaymentHandler.GetSchedules)
+		v1.GET("/loans/:id/ledger", repaymentHandler.GetLedger)
+		v1.POST("/loans/:id/repayments", repaymentHandler.PayInstalment)
+		v1.POST("/loans/:id/prepayments", repaymentHandler.PartPay)
+		v1.GET("/loans/:id/foreclosure-quote", repaymentHandler.GetForeclosureQuote)
+		v1.POST("/loans/:id/foreclosure", repaymentHandler.Foreclose)
+		v1.POST("/loans/:id/restructure", repaymentHandler.Restructure)
+		v1.POST("/loans/:id/top-up", loanHandler.TopUp)
+
+		// Loan product endpoints
+		v1.POST("/products", productHandler.CreateProduct)
+		v1.GET("/products", productHandler.GetProducts)
+		v1.GET("/products/:id", productHandler.GetProduct)
+		v1.PUT("/products/:id", productHandler.UpdateProduct)
+		v1.DELETE("/products/:id", productHandler.RetireProduct)
+
+		// Report endpoints
+		v1.GET("/reports/approval-rates", reportHandler.GetApprovalRates)
+		v1.GET("/reports/decision-sources", reportHandler.GetDecisionSources)
+		v1.GET("/reports/decision-times", reportHandler.GetDecisionTimes)
+		v1.GET("/reports/amounts", reportHandler.GetAmountDistributions)
+		v1.GET("/reports/daily-volume", reportHandler.GetDailyVolumes)
+
+		// Exchange rate endpoints
+		v1.GET("/fx-rates", fxHandler.GetRates)
+		v1.POST("/fx-rates", fxHandler.SetRates)
+
+		// Fraud review endpoints
+		v1.GET("/fraud-reviews", fraudHandler.GetQueue)
+		v1.PUT("/fraud-reviews/:loan_id/decision", fraudHandler.Review)
 
 		// Agent endpoints
 		v1.POST("/agents", agentHandler.CreateAgent)
+		v1.GET("/agents", agentHandler.ListAgents)
+		v1.GET("/agents/:agent_id", agentHandler.GetAgent)
+		v1.PUT("/agents/:agent_id", agentHandler.UpdateAgent)
+		v1.DELETE("/agents/:agent_id", agentHandler.DeactivateAgent)
+		v1.GET("/agents/:agent_id/tree", agentHandler.GetTree)
+		v1.GET("/agents/:agent_id/stats", reportHandler.GetAgentStats)
+		v1.GET("/managers/:id/team-stats", reportHandler.GetTeamStats)
+		v1.GET("/agents/:agent_id/loans", agentHandler.GetWorkQueue)
+		v1.GET("/agents/:agent_id/loans/:loan_id", agentHandler.GetLoanReview)
+		v1.POST("/agents/:agent_id/loans/:loan_id/claim", agentHandler.ClaimLoan)
+		v1.POST("/agents/:agent_id/loans/:loan_id/unclaim", agentHandler.UnclaimLoan)
 		v1.PUT("/agents/:agent_id/loans/:loan_id/decision", agentHandler.MakeDecision)
 	}
 
 	fmt.Println("Server starting on port 8080...")
 	log.Fatal(http.ListenAndServe(":8080", router))
 }
-
-func initSampleData(agentRepo *agentRepo.AgentRepository) {
-	// Add sample agents
-	agentRepo.AddAgent(&agentModels.Agent{ID: 1, Name: "John Manager", ManagerID: nil})
-	agentRepo.AddAgent(&agentModels.Agent{ID: 2, Name: "Alice Agent", ManagerID: &[]int{1}[0]})
-	agentRepo.AddAgent(&agentModels.Agent{ID: 3, Name: "Bob Agent", ManagerID: &[]int{1}[0]})
-
-	// Update the sequence to prevent primary key conflicts
-	agentRepo.DB.DB.Exec("SELECT setval('agents_id_seq', (SELECT MAX(id) FROM agents))")
-}
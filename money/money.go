@@ -0,0 +1,354 @@
+// Package money provides a fixed-point amount type for loan amounts, fees,
+// income and ledger math, replacing float64.
+//
+// A Money holds an integer number of minor units (hundredths, matching the
+// DECIMAL(15,2) columns) and an ISO 4217 currency code. Amounts read from the
+// database or JSON carry no currency until their owner assigns one with In;
+// an amount without a currency takes on the currency of the amount it is
+// combined or compared with. Combining two different currencies is a
+// programming error and panics.
+//
+// Any operation that can produce fractions of a minor unit takes an explicit
+// RoundingMode.
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+const (
+	INR Currency = "INR"
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+)
+
+// IsValid reports whether the currency is one the module lends in.
+func (c Currency) IsValid() bool {
+	switch c {
+	case INR, USD, EUR, GBP:
+		return true
+	}
+	return false
+}
+
+// DefaultCurrency is the currency of amounts that are not otherwise
+// specified.
+const DefaultCurrency = INR
+
+// Scale is the number of minor units in a major unit.
+const Scale = 100
+
+type RoundingMode int
+
+const (
+	// HalfUp rounds to the nearest minor unit, ties away from zero.
+	HalfUp RoundingMode = iota
+	// HalfEven rounds to the nearest minor unit, ties to the even neighbour.
+	HalfEven
+	// Down rounds towards zero (truncates).
+	Down
+	// Up rounds away from zero.
+	Up
+)
+
+func (m RoundingMode) String() string {
+	switch m {
+	case HalfUp:
+		return "HALF_UP"
+	case HalfEven:
+		return "HALF_EVEN"
+	case Down:
+		return "DOWN"
+	case Up:
+		return "UP"
+	}
+	return fmt.Sprintf("RoundingMode(%d)", int(m))
+}
+
+var ErrInvalidAmount = errors.New("invalid money amount")
+
+// Money is a fixed-point amount of a currency. The zero value is zero with no
+// currency.
+type Money struct {
+	minor    int64
+	currency Currency
+}
+
+// New returns an amount of minor units.
+func New(minor int64, currency Currency) Money {
+	return Money{minor: minor, currency: currency}
+}
+
+// FromUnits returns an amount of whole major units.
+func FromUnits(units int64, currency Currency) Money {
+	return Money{minor: units * Scale, currency: currency}
+}
+
+// Parse reads a decimal amount such as "1234.5" or "-0.75". More than two
+// decimal places is an error: round explicitly with ParseRounded instead.
+func Parse(s string, currency Currency) (Money, error) {
+	r, ok := parseRat(s)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	scaled := new(big.Rat).Mul(r, big.NewRat(Scale, 1))
+	if !scaled.IsInt() {
+		return Money{}, fmt.Errorf("%w: %q has more than two decimal places", ErrInvalidAmount, s)
+	}
+	if !scaled.Num().IsInt64() {
+		return Money{}, fmt.Errorf("%w: %q is out of range", ErrInvalidAmount, s)
+	}
+	return Money{minor: scaled.Num().Int64(), currency: currency}, nil
+}
+
+// ParseRounded reads a decimal amount, rounding it to minor units.
+func ParseRounded(s string, currency Currency, mode RoundingMode) (Money, error) {
+	r, ok := parseRat(s)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	return Money{minor: round(new(big.Rat).Mul(r, big.NewRat(Scale, 1)), mode), currency: currency}, nil
+}
+
+// MustParse is Parse for constants; it panics on an invalid amount.
+func MustParse(s string, currency Currency) Money {
+	m, err := Parse(s, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// FromFloat converts a float, such as a value from a configuration file, to
+// an amount. The float is taken at its shortest decimal representation, so
+// 1.005 is treated as exactly 1.005 before rounding.
+func FromFloat(f float64, currency Currency, mode RoundingMode) Money {
+	m, err := ParseRounded(strconv.FormatFloat(f, 'f', -1, 64), currency, mode)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func parseRat(s string) (*big.Rat, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s, "/eE") {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// round rounds a rational number of minor units to an integer.
+func round(r *big.Rat, mode RoundingMode) int64 {
+	q, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Sign() == 0 {
+		return q.Int64()
+	}
+	sign := int64(r.Sign())
+	// Compare twice the remainder with the denominator to find which side of
+	// the half-way point the value lies.
+	half := new(big.Int).Abs(rem)
+	half.Lsh(half, 1)
+	cmp := half.Cmp(r.Denom())
+
+	awayFromZero := false
+	switch mode {
+	case HalfUp:
+		awayFromZero = cmp >= 0
+	case HalfEven:
+		awayFromZero = cmp > 0 || (cmp == 0 && q.Bit(0) == 1)
+	case Down:
+		awayFromZero = false
+	case Up:
+		awayFromZero = true
+	default:
+		panic(fmt.Sprintf("money: unknown rounding mode %d", int(mode)))
+	}
+	if awayFromZero {
+		return q.Int64() + sign
+	}
+	return q.Int64()
+}
+
+// Minor returns the amount in minor units.
+func (m Money) Minor() int64 { return m.minor }
+
+func (m Money) Currency() Currency { return m.currency }
+
+// In returns the amount in the given currency. It does not convert: use it to
+// label an amount whose currency is known from its owner.
+func (m Money) In(currency Currency) Money {
+	m.currency = currency
+	return m
+}
+
+// Float64 returns the amount as a float, for ratios and display only.
+func (m Money) Float64() float64 {
+	return float64(m.minor) / Scale
+}
+
+// String formats the amount with two decimal places and no currency.
+func (m Money) String() string {
+	sign := ""
+	minor := m.minor
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/Scale, minor%Scale)
+}
+
+func (m Money) IsZero() bool     { return m.minor == 0 }
+func (m Money) IsPositive() bool { return m.minor > 0 }
+func (m Money) IsNegative() bool { return m.minor < 0 }
+
+func (m Money) Add(o Money) Money {
+	return Money{minor: m.minor + o.minor, currency: m.common(o)}
+}
+
+func (m Money) Sub(o Money) Money {
+	return Money{minor: m.minor - o.minor, currency: m.common(o)}
+}
+
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor, currency: m.currency}
+}
+
+// Mul multiplies the amount by a factor such as an interest rate, rounding
+// the product to minor units.
+func (m Money) Mul(factor float64, mode RoundingMode) Money {
+	f, ok := parseRat(strconv.FormatFloat(factor, 'f', -1, 64))
+	if !ok {
+		panic(fmt.Sprintf("money: invalid factor %v", factor))
+	}
+	return Money{minor: round(f.Mul(f, new(big.Rat).SetInt64(m.minor)), mode), currency: m.currency}
+}
+
+// Convert converts the amount to another currency at rate units of to per
+// unit of the amount's currency.
+func (m Money) Convert(to Currency, rate float64, mode RoundingMode) Money {
+	converted := m.Mul(rate, mode)
+	converted.currency = to
+	return converted
+}
+
+// Div divides the amount into n parts, rounding each to minor units.
+func (m Money) Div(n int64, mode RoundingMode) Money {
+	if n == 0 {
+		panic("money: division by zero")
+	}
+	return Money{minor: round(big.NewRat(m.minor, n), mode), currency: m.currency}
+}
+
+// Ratio returns m / o as a float, for ratios such as LTV and DTI. It returns
+// 0 when o is zero.
+func (m Money) Ratio(o Money) float64 {
+	m.common(o)
+	if o.minor == 0 {
+		return 0
+	}
+	return float64(m.minor) / float64(o.minor)
+}
+
+// Cmp compares two amounts, returning -1, 0 or +1.
+func (m Money) Cmp(o Money) int {
+	m.common(o)
+	switch {
+	case m.minor < o.minor:
+		return -1
+	case m.minor > o.minor:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) Equal(o Money) bool       { return m.Cmp(o) == 0 }
+func (m Money) LessThan(o Money) bool    { return m.Cmp(o) < 0 }
+func (m Money) GreaterThan(o Money) bool { return m.Cmp(o) > 0 }
+
+// Min returns the smaller of two amounts.
+func Min(a, b Money) Money {
+	if b.LessThan(a) {
+		return b
+	}
+	return a
+}
+
+// Max returns the larger of two amounts.
+func Max(a, b Money) Money {
+	if b.GreaterThan(a) {
+		return b
+	}
+	return a
+}
+
+// common returns the currency of an operation on m and o.
+func (m Money) common(o Money) Currency {
+	switch {
+	case m.currency == "":
+		return o.currency
+	case o.currency == "" || o.currency == m.currency:
+		return m.currency
+	}
+	panic(fmt.Sprintf("money: currency mismatch %s and %s", m.currency, o.currency))
+}
+
+// MarshalJSON encodes the amount as a decimal string, such as "1234.50".
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON accepts a decimal string or a JSON number with at most two
+// decimal places.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	parsed, err := Parse(s, m.currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value stores the amount as a decimal string.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan reads a DECIMAL column. The currency is left for the owner to set.
+func (m *Money) Scan(src interface{}) error {
+	var err error
+	switch v := src.(type) {
+	case nil:
+		*m = Money{}
+	case []byte:
+		*m, err = Parse(string(v), "")
+	case string:
+		*m, err = Parse(v, "")
+	case int64:
+		*m = FromUnits(v, "")
+	case float64:
+		*m = FromFloat(v, "", HalfEven)
+	default:
+		err = fmt.Errorf("money: cannot scan %T", src)
+	}
+	return err
+}
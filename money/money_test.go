@@ -0,0 +1,147 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseRoundedModes(t *testing.T) {
+	tests := []struct {
+		in   string
+		mode RoundingMode
+		want string
+	}{
+		{"1.005", HalfUp, "1.01"},
+		{"1.005", HalfEven, "1.00"},
+		{"1.015", HalfEven, "1.02"},
+		{"1.004", HalfUp, "1.00"},
+		{"1.001", Up, "1.01"},
+		{"1.009", Down, "1.00"},
+		{"-1.005", HalfUp, "-1.01"},
+		{"-1.005", HalfEven, "-1.00"},
+		{"-1.001", Up, "-1.01"},
+		{"-1.009", Down, "-1.00"},
+		{"2.50", Down, "2.50"},
+	}
+	for _, tt := range tests {
+		got, err := ParseRounded(tt.in, INR, tt.mode)
+		if err != nil {
+			t.Fatalf("ParseRounded(%q, %s): %v", tt.in, tt.mode, err)
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseRounded(%q, %s) = %s, want %s", tt.in, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejectsExtraPrecision(t *testing.T) {
+	if _, err := Parse("10.001", INR); err == nil {
+		t.Error("Parse(10.001) should fail without an explicit rounding mode")
+	}
+	for _, in := range []string{"", "abc", "1e5", "1/2"} {
+		if _, err := Parse(in, INR); err == nil {
+			t.Errorf("Parse(%q) should fail", in)
+		}
+	}
+}
+
+func TestFromFloatUsesShortestDecimal(t *testing.T) {
+	// 1.005 is 1.00499999999999989... in binary; it must still round up.
+	if got := FromFloat(1.005, INR, HalfUp); got.String() != "1.01" {
+		t.Errorf("FromFloat(1.005, HALF_UP) = %s, want 1.01", got)
+	}
+	if got := FromFloat(0.1+0.2, INR, HalfEven); got.String() != "0.30" {
+		t.Errorf("FromFloat(0.1+0.2, HALF_EVEN) = %s, want 0.30", got)
+	}
+}
+
+func TestMulAndDivRounding(t *testing.T) {
+	principal := MustParse("100000.00", INR)
+	// Monthly interest at 12.5% a year: 1041.666...
+	if got := principal.Mul(0.125/12, HalfUp); got.String() != "1041.67" {
+		t.Errorf("Mul HALF_UP = %s, want 1041.67", got)
+	}
+	if got := principal.Mul(0.125/12, Down); got.String() != "1041.66" {
+		t.Errorf("Mul DOWN = %s, want 1041.66", got)
+	}
+	if got := MustParse("10.00", INR).Div(3, Up); got.String() != "3.34" {
+		t.Errorf("Div UP = %s, want 3.34", got)
+	}
+	if got := MustParse("0.25", INR).Div(10, HalfEven); got.String() != "0.02" {
+		t.Errorf("Div HALF_EVEN = %s, want 0.02", got)
+	}
+	if got := MustParse("0.35", INR).Div(10, HalfEven); got.String() != "0.04" {
+		t.Errorf("Div HALF_EVEN = %s, want 0.04", got)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	got := MustParse("100.00", USD).Convert(INR, 83.3333, HalfUp)
+	if got.String() != "8333.33" || got.Currency() != INR {
+		t.Errorf("Convert = %s %s, want 8333.33 INR", got, got.Currency())
+	}
+	got = MustParse("1000.00", INR).Convert(USD, 1/83.25, HalfEven)
+	if got.String() != "12.01" || got.Currency() != USD {
+		t.Errorf("Convert = %s %s, want 12.01 USD", got, got.Currency())
+	}
+}
+
+func TestArithmeticAndComparison(t *testing.T) {
+	a := MustParse("10.10", INR)
+	b := MustParse("0.20", INR)
+	if got := a.Add(b); got.String() != "10.30" || got.Currency() != INR {
+		t.Errorf("Add = %s %s, want 10.30 INR", got, got.Currency())
+	}
+	if got := b.Sub(a); got.String() != "-9.90" {
+		t.Errorf("Sub = %s, want -9.90", got)
+	}
+	if !b.LessThan(a) || a.LessThan(b) || !a.Equal(MustParse("10.1", INR)) {
+		t.Error("comparison of 10.10 and 0.20 is wrong")
+	}
+
+	// An amount without a currency takes on the other's currency.
+	unlabelled := MustParse("1.00", "")
+	if got := unlabelled.Add(a); got.Currency() != INR {
+		t.Errorf("Add currency = %q, want INR", got.Currency())
+	}
+}
+
+func TestCurrencyMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("adding INR and USD should panic")
+		}
+	}()
+	MustParse("1.00", INR).Add(MustParse("1.00", USD))
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	var v struct {
+		Amount Money `json:"amount"`
+	}
+	for _, in := range []string{`{"amount":"1234.5"}`, `{"amount":1234.5}`} {
+		if err := json.Unmarshal([]byte(in), &v); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", in, err)
+		}
+		out, _ := json.Marshal(v)
+		if string(out) != `{"amount":"1234.50"}` {
+			t.Errorf("Marshal = %s, want {\"amount\":\"1234.50\"}", out)
+		}
+	}
+	if err := json.Unmarshal([]byte(`{"amount":"1.234"}`), &v); err == nil {
+		t.Error("Unmarshal should reject more than two decimal places")
+	}
+}
+
+func TestScan(t *testing.T) {
+	var m Money
+	if err := m.Scan([]byte("98765.43")); err != nil || m.Minor() != 9876543 {
+		t.Errorf("Scan([]byte) = %d, %v", m.Minor(), err)
+	}
+	if err := m.Scan(int64(12)); err != nil || m.String() != "12.00" {
+		t.Errorf("Scan(int64) = %s, %v", m, err)
+	}
+	if err := m.Scan(nil); err != nil || !m.IsZero() {
+		t.Errorf("Scan(nil) = %s, %v", m, err)
+	}
+}
@@ -0,0 +1,47 @@
+package notification
+
+import (
+	"time"
+
+	"loan-module/repository"
+)
+
+type Channel string
+
+const (
+	ChannelSMS  Channel = "SMS"
+	ChannelPush Channel = "PUSH"
+)
+
+// Notification is a message that was sent. The recipient is a phone number
+// for SMS and an agent ID for push notifications.
+type Notification struct {
+	ID        int       `gorm:"primaryKey" json:"id"`
+	Channel   Channel   `gorm:"type:varchar(10);not null" json:"channel"`
+	Recipient string    `gorm:"type:varchar(50);not null" json:"recipient"`
+	Message   string    `gorm:"not null" json:"message"`
+	SentAt    time.Time `gorm:"autoCreateTime" json:"sent_at"`
+}
+
+type Repository struct {
+	db *database.Database
+}
+
+func NewRepository(db *database.Database) *Repository {
+	return &Repository{db: db}
+}
+
+func (r *Repository) AddNotification(notification *Notification) error {
+	return r.db.DB.Create(notification).Error
+}
+
+// GetSent returns up to limit messages sent to the recipient on the channel
+// since the given time, newest first.
+func (r *Repository) GetSent(channel Channel, recipient string, since time.Time, limit int) []*Notification {
+	notifications := []*Notification{}
+	r.db.DB.Where("channel = ? AND recipient = ? AND sent_at >= ?", channel, recipient, since).
+		Order("sent_at DESC, id DESC").
+		Limit(limit).
+		Find(&notifications)
+	return notifications
+}
@@ -1,17 +1,53 @@
 package notification
 
-import "log"
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
 
-type NotificationService struct{}
+// NotificationService sends messages and keeps a record of each one. A
+// message that cannot be recorded is still sent.
+type NotificationService struct {
+	repo *Repository
+}
 
-func NewNotificationService() *NotificationService {
-	return &NotificationService{}
+func NewNotificationService(repo *Repository) *NotificationService {
+	return &NotificationService{repo: repo}
 }
 
 func (s *NotificationService) SendPushNotification(agentID int, message string) {
 	log.Printf("[PUSH NOTIFICATION] Agent %d: %s", agentID, message)
+	s.record(ChannelPush, strconv.Itoa(agentID), message)
 }
 
 func (s *NotificationService) SendSMS(phone, message string) {
 	log.Printf("[SMS] %s: %s", phone, message)
+	s.record(ChannelSMS, phone, message)
+}
+
+// SendSMSToAll sends the same SMS to every phone, such as all parties to a
+// loan.
+func (s *NotificationService) SendSMSToAll(phones []string, message string) {
+	for _, phone := range phones {
+		s.SendSMS(phone, message)
+	}
+}
+
+// SendOTP texts a one-time code to the phone. The record keeps the message
+// with the code masked, so stored notifications cannot be used to verify.
+func (s *NotificationService) SendOTP(phone, code string, validity time.Duration) {
+	message := "Your verification code is %s. It expires in %d minutes. Do not share it with anyone."
+	minutes := int(validity.Minutes())
+	log.Printf("[SMS] %s: %s", phone, fmt.Sprintf(message, code, minutes))
+	s.record(ChannelSMS, phone, fmt.Sprintf(message, strings.Repeat("*", len(code)), minutes))
+}
+
+func (s *NotificationService) record(channel Channel, recipient, message string) {
+	notification := &Notification{Channel: channel, Recipient: recipient, Message: message}
+	if err := s.repo.AddNotification(notification); err != nil {
+		log.Printf("Error recording %s notification to %s: %v", channel, recipient, err)
+	}
 }
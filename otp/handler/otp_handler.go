@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"loan-module/apperror"
+	"loan-module/otp/models"
+	"loan-module/otp/service"
+)
+
+type OTPHandler struct {
+	otpService *service.OTPService
+}
+
+func NewOTPHandler(otpService *service.OTPService) *OTPHandler {
+	return &OTPHandler{otpService: otpService}
+}
+
+func (h *OTPHandler) SendOTP(c *gin.Context) {
+	customerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("customer ID"))
+		return
+	}
+	otp, err := h.otpService.Send(customerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, otp)
+}
+
+func (h *OTPHandler) VerifyOTP(c *gin.Context) {
+	customerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("customer ID"))
+		return
+	}
+	var req models.VerifyOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	verification, err := h.otpService.Verify(customerID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, verification)
+}
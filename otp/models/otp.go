@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// OTP is a one-time code sent to a customer's phone. Only a hash of the code
+// is stored. An OTP is used up once verified, and locked once
+// constants.OTPMaxAttempts wrong codes have been tried.
+type OTP struct {
+	ID         int        `gorm:"primaryKey" json:"id"`
+	CustomerID int        `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"customer_id"`
+	Phone      string     `gorm:"not null" json:"phone"`
+	CodeHash   string     `gorm:"type:varchar(64);not null" json:"-"`
+	Attempts   int        `gorm:"not null;default:0" json:"attempts"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type VerifyOTPRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// OTPResponse describes the OTP sent to a customer, without the code.
+type OTPResponse struct {
+	CustomerID   int       `json:"customer_id"`
+	Phone        string    `json:"phone"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	AttemptsLeft int       `json:"attempts_left"`
+}
+
+// VerificationResponse is the outcome of a successful verification and the
+// loans it released for processing.
+type VerificationResponse struct {
+	CustomerID    int       `json:"customer_id"`
+	Phone         string    `json:"phone"`
+	VerifiedAt    time.Time `json:"verified_at"`
+	ReleasedLoans int64     `json:"released_loans"`
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"loan-module/otp/models"
+	"loan-module/repository"
+)
+
+type OTPRepository struct {
+	db *database.Database
+}
+
+func NewOTPRepository(db *database.Database) *OTPRepository {
+	return &OTPRepository{db: db}
+}
+
+func (r *OTPRepository) AddOTP(otp *models.OTP) error {
+	return r.db.DB.Create(otp).Error
+}
+
+// GetLatestOTP returns the last OTP sent to the customer.
+func (r *OTPRepository) GetLatestOTP(customerID int) (*models.OTP, bool) {
+	var otp models.OTP
+	if err := r.db.DB.Where("customer_id = ?", customerID).Order("created_at DESC, id DESC").First(&otp).Error; err != nil {
+		return nil, false
+	}
+	return &otp, true
+}
+
+// RecordFailedAttempt counts a wrong code against the OTP while it can still
+// be tried. It reports whether the attempt was counted.
+func (r *OTPRepository) RecordFailedAttempt(id, maxAttempts int) (bool, error) {
+	result := r.db.DB.Model(&models.OTP{}).
+		Where("id = ? AND verified_at IS NULL AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkVerified uses up the OTP unless it was already used, locked or has
+// expired in the meantime. It reports whether the OTP was marked.
+func (r *OTPRepository) MarkVerified(id, maxAttempts int, at time.Time) (bool, error) {
+	result := r.db.DB.Model(&models.OTP{}).
+		Where("id = ? AND verified_at IS NULL AND attempts < ? AND expires_at > ?", id, maxAttempts, at).
+		Update("verified_at", at)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"loan-module/apperror"
+	"loan-module/constants"
+	customerModels "loan-module/customer/models"
+	customerRepo "loan-module/customer/repository"
+	loanModels "loan-module/loan/models"
+	loanRepo "loan-module/loan/repository"
+	"loan-module/notification"
+	"loan-module/otp/models"
+	"loan-module/otp/repository"
+)
+
+var (
+	ErrCustomerNotFound     = apperror.NotFound("CUSTOMER_NOT_FOUND", "customer not found")
+	ErrPhoneAlreadyVerified = apperror.Conflict("PHONE_ALREADY_VERIFIED", "phone is already verified")
+	ErrOTPTooSoon           = apperror.RateLimited("OTP_TOO_SOON", "an OTP was sent too recently")
+	ErrOTPNotFound          = apperror.NotFound("OTP_NOT_FOUND", "no pending OTP for the customer's phone")
+	ErrOTPExpired           = apperror.Unprocessable("OTP_EXPIRED", "OTP has expired, request a new one")
+	ErrOTPLocked            = apperror.RateLimited("OTP_LOCKED", "too many wrong codes, request a new OTP")
+	ErrInvalidOTP           = apperror.Validation("INVALID_OTP", "invalid OTP")
+)
+
+type OTPService struct {
+	repo                *repository.OTPRepository
+	customerRepo        *customerRepo.CustomerRepository
+	loanRepo            *loanRepo.LoanRepository
+	notificationService *notification.NotificationService
+}
+
+func NewOTPService(
+	repo *repository.OTPRepository,
+	customerRepo *customerRepo.CustomerRepository,
+	loanRepo *loanRepo.LoanRepository,
+	notificationService *notification.NotificationService,
+) *OTPService {
+	return &OTPService{
+		repo:                repo,
+		customerRepo:        customerRepo,
+		loanRepo:            loanRepo,
+		notificationService: notificationService,
+	}
+}
+
+// Send texts a new OTP to the customer's phone, replacing any earlier one. A
+// new OTP can only be sent OTPResendInterval after the last.
+func (s *OTPService) Send(customerID int) (*models.OTPResponse, error) {
+	customer, exists := s.customerRepo.GetCustomerByID(customerID)
+	if !exists {
+		return nil, ErrCustomerNotFound
+	}
+	if s.IsVerified(customer) {
+		return nil, ErrPhoneAlreadyVerified
+	}
+	now := time.Now()
+	if latest, ok := s.repo.GetLatestOTP(customer.ID); ok && latest.Phone == customer.Phone {
+		if wait := latest.CreatedAt.Add(constants.OTPResendInterval).Sub(now); wait > 0 {
+			return nil, fmt.Errorf("%w: try again in %d seconds", ErrOTPTooSoon, int(wait.Seconds())+1)
+		}
+	}
+	otp, err := s.issue(customer, now)
+	if err != nil {
+		return nil, err
+	}
+	return response(otp), nil
+}
+
+// RequestVerification sends the customer an OTP for a loan waiting on phone
+// verification, unless one they can still use is already pending.
+func (s *OTPService) RequestVerification(customer *customerModels.Customer) error {
+	now := time.Now()
+	if latest, ok := s.repo.GetLatestOTP(customer.ID); ok && usable(latest, customer.Phone, now) {
+		return nil
+	}
+	_, err := s.issue(customer, now)
+	return err
+}
+
+// Verify checks the code against the customer's pending OTP. On success the
+// phone is marked verified and the customer's loans waiting on it are
+// released for processing. Every wrong code counts as an attempt.
+func (s *OTPService) Verify(customerID int, req *models.VerifyOTPRequest) (*models.VerificationResponse, error) {
+	customer, exists := s.customerRepo.GetCustomerByID(customerID)
+	if !exists {
+		return nil, ErrCustomerNotFound
+	}
+	if s.IsVerified(customer) {
+		return nil, ErrPhoneAlreadyVerified
+	}
+	otp, ok := s.repo.GetLatestOTP(customer.ID)
+	if !ok || otp.Phone != customer.Phone || otp.VerifiedAt != nil {
+		return nil, ErrOTPNotFound
+	}
+	now := time.Now()
+	if !now.Before(otp.ExpiresAt) {
+		return nil, ErrOTPExpired
+	}
+	if otp.Attempts >= constants.OTPMaxAttempts {
+		return nil, ErrOTPLocked
+	}
+
+	code := strings.TrimSpace(req.Code)
+	if subtle.ConstantTimeCompare([]byte(hashCode(otp.Phone, code)), []byte(otp.CodeHash)) != 1 {
+		counted, err := s.repo.RecordFailedAttempt(otp.ID, constants.OTPMaxAttempts)
+		if err != nil {
+			return nil, err
+		}
+		left := constants.OTPMaxAttempts - otp.Attempts - 1
+		if !counted || left <= 0 {
+			return nil, ErrOTPLocked
+		}
+		return nil, fmt.Errorf("%w: %d attempts left", ErrInvalidOTP, left)
+	}
+
+	marked, err := s.repo.MarkVerified(otp.ID, constants.OTPMaxAttempts, now)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		return nil, ErrOTPNotFound
+	}
+	// The phone may have changed since the customer was read
+	updated, err := s.customerRepo.MarkPhoneVerified(customer.ID, otp.Phone, now)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrOTPNotFound
+	}
+
+	released, err := s.loanRepo.UpdateCustomerLoansStatus(customer.ID, loanModels.OTPPending, loanModels.Applied)
+	if err != nil {
+		log.Printf("Error releasing OTP pending loans for customer %d: %v", customer.ID, err)
+	} else if released > 0 {
+		log.Printf("Released %d OTP pending loans for customer %d", released, customer.ID)
+	}
+	return &models.VerificationResponse{
+		CustomerID:    customer.ID,
+		Phone:         otp.Phone,
+		VerifiedAt:    now,
+		ReleasedLoans: released,
+	}, nil
+}
+
+// IsVerified reports whether the customer has verified their current phone.
+func (s *OTPService) IsVerified(customer *customerModels.Customer) bool {
+	return customer.PhoneVerifiedAt != nil
+}
+
+// issue stores a new OTP for the customer's phone and texts them the code.
+func (s *OTPService) issue(customer *customerModels.Customer, now time.Time) (*models.OTP, error) {
+	code, err := generateCode(constants.OTPLength)
+	if err != nil {
+		return nil, err
+	}
+	otp := &models.OTP{
+		CustomerID: customer.ID,
+		Phone:      customer.Phone,
+		CodeHash:   hashCode(customer.Phone, code),
+		ExpiresAt:  now.Add(constants.OTPValidity),
+	}
+	if err := s.repo.AddOTP(otp); err != nil {
+		return nil, err
+	}
+	s.notificationService.SendOTP(customer.Phone, code, constants.OTPValidity)
+	return otp, nil
+}
+
+// usable reports whether the OTP can still verify the phone.
+func usable(otp *models.OTP, phone string, now time.Time) bool {
+	return otp.Phone == phone && otp.VerifiedAt == nil &&
+		otp.Attempts < constants.OTPMaxAttempts && now.Before(otp.ExpiresAt)
+}
+
+func response(otp *models.OTP) *models.OTPResponse {
+	return &models.OTPResponse{
+		CustomerID:   otp.CustomerID,
+		Phone:        otp.Phone,
+		ExpiresAt:    otp.ExpiresAt,
+		AttemptsLeft: constants.OTPMaxAttempts - otp.Attempts,
+	}
+}
+
+// generateCode returns a random code of the given number of digits.
+func generateCode(digits int) (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", fmt.Errorf("generating OTP: %w", err)
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
+
+// hashCode binds the code to the phone it was sent to.
+func hashCode(phone, code string) string {
+	sum := sha256.Sum256([]byte(phone + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
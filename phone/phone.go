@@ -0,0 +1,122 @@
+// Package phone normalises phone numbers to E.164 so that one number written
+// in different ways identifies one customer.
+package phone
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultRegion is used for national numbers when no region is configured.
+const DefaultRegion = "IN"
+
+var (
+	ErrInvalidPhone  = errors.New("invalid phone number")
+	ErrUnknownRegion = errors.New("unsupported phone region")
+)
+
+// region describes how national numbers are written in a country: its
+// calling code, the lengths of its national significant numbers and the
+// trunk prefix dialled before them within the country.
+type region struct {
+	callingCode string
+	lengths     []int
+	trunk       string
+}
+
+var regions = map[string]region{
+	"IN": {callingCode: "91", lengths: []int{10}, trunk: "0"},
+	"US": {callingCode: "1", lengths: []int{10}, trunk: "1"},
+	"CA": {callingCode: "1", lengths: []int{10}, trunk: "1"},
+	"GB": {callingCode: "44", lengths: []int{10}, trunk: "0"},
+	"AE": {callingCode: "971", lengths: []int{8, 9}, trunk: "0"},
+	"SG": {callingCode: "65", lengths: []int{8}},
+	"AU": {callingCode: "61", lengths: []int{9}, trunk: "0"},
+}
+
+// E.164 numbers have at most 15 digits; shorter than 8 is not a real
+// subscriber number anywhere we lend.
+const (
+	minDigits = 8
+	maxDigits = 15
+)
+
+// Normalizer turns phone numbers into E.164, reading numbers without a
+// country code as numbers of its region.
+type Normalizer struct {
+	region region
+}
+
+// NewNormalizer returns a normalizer for national numbers of the region, an
+// ISO 3166 alpha-2 code. An empty region means DefaultRegion.
+func NewNormalizer(defaultRegion string) (*Normalizer, error) {
+	if defaultRegion == "" {
+		defaultRegion = DefaultRegion
+	}
+	r, ok := regions[strings.ToUpper(defaultRegion)]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownRegion, defaultRegion)
+	}
+	return &Normalizer{region: r}, nil
+}
+
+// Normalize returns the number in E.164 form, such as +919876543210.
+// Spaces, dashes, dots and brackets are ignored, and a leading 00 is read as
+// the international prefix. A number without one is national: a trunk
+// prefix is dropped and the region's calling code added. A national number
+// already starting with the region's calling code is taken as international.
+func (n *Normalizer) Normalize(raw string) (string, error) {
+	number := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\u00a0', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(raw))
+	if strings.HasPrefix(number, "00") {
+		number = "+" + number[2:]
+	}
+
+	digits := strings.TrimPrefix(number, "+")
+	if digits == "" || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return "", fmt.Errorf("%w %q", ErrInvalidPhone, raw)
+	}
+	if strings.HasPrefix(number, "+") {
+		return international(raw, digits)
+	}
+
+	r := n.region
+	switch {
+	case r.valid(digits):
+		return "+" + r.callingCode + digits, nil
+	case r.trunk != "" && strings.HasPrefix(digits, r.trunk) && r.valid(digits[len(r.trunk):]):
+		return "+" + r.callingCode + digits[len(r.trunk):], nil
+	case strings.HasPrefix(digits, r.callingCode) && r.valid(digits[len(r.callingCode):]):
+		return "+" + digits, nil
+	}
+	return "", fmt.Errorf("%w %q: not a valid number for calling code +%s", ErrInvalidPhone, raw, r.callingCode)
+}
+
+// international checks a number given with its country code. Numbers of the
+// regions we know must have one of their national lengths.
+func international(raw, digits string) (string, error) {
+	if len(digits) < minDigits || len(digits) > maxDigits || digits[0] == '0' {
+		return "", fmt.Errorf("%w %q", ErrInvalidPhone, raw)
+	}
+	for _, r := range regions {
+		if strings.HasPrefix(digits, r.callingCode) && !r.valid(digits[len(r.callingCode):]) {
+			return "", fmt.Errorf("%w %q: not a valid number for calling code +%s", ErrInvalidPhone, raw, r.callingCode)
+		}
+	}
+	return "+" + digits, nil
+}
+
+func (r region) valid(national string) bool {
+	for _, length := range r.lengths {
+		if len(national) == length {
+			return national[0] != '0'
+		}
+	}
+	return false
+}
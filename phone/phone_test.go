@@ -0,0 +1,64 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		region string
+		in     string
+		want   string
+	}{
+		{"IN", "9876543210", "+919876543210"},
+		{"IN", "+91 98765 43210", "+919876543210"},
+		{"IN", "098765-43210", "+919876543210"},
+		{"IN", "919876543210", "+919876543210"},
+		{"IN", "0091 (98765) 43210", "+919876543210"},
+		{"IN", "+44 20 7946 0958", "+442079460958"},
+		{"GB", "020 7946 0958", "+442079460958"},
+		{"US", "(415) 555-0132", "+14155550132"},
+		{"US", "1-415-555-0132", "+14155550132"},
+		{"SG", "6123 4567", "+6561234567"},
+	}
+	for _, tt := range tests {
+		n, err := NewNormalizer(tt.region)
+		if err != nil {
+			t.Fatalf("NewNormalizer(%q): %v", tt.region, err)
+		}
+		got, err := n.Normalize(tt.in)
+		if err != nil {
+			t.Errorf("Normalize(%q) in %s: %v", tt.in, tt.region, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Normalize(%q) in %s = %s, want %s", tt.in, tt.region, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeRejectsInvalidNumbers(t *testing.T) {
+	n, err := NewNormalizer("IN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, in := range []string{"", "98765", "98765432100", "0000000000", "98765abc10", "+91 98765 4321", "+0123456789", "+1234567890123456"} {
+		if got, err := n.Normalize(in); !errors.Is(err, ErrInvalidPhone) {
+			t.Errorf("Normalize(%q) = %q, %v; want ErrInvalidPhone", in, got, err)
+		}
+	}
+}
+
+func TestNewNormalizerRegion(t *testing.T) {
+	if _, err := NewNormalizer("XX"); !errors.Is(err, ErrUnknownRegion) {
+		t.Errorf("NewNormalizer(XX) = %v, want ErrUnknownRegion", err)
+	}
+	n, err := NewNormalizer("")
+	if err != nil {
+		t.Fatalf("NewNormalizer(\"\"): %v", err)
+	}
+	if got, _ := n.Normalize("9876543210"); got != "+919876543210" {
+		t.Errorf("default region normalised 9876543210 to %q", got)
+	}
+}
@@ -0,0 +1,148 @@
+package pricing
+
+import (
+	"math"
+
+	fx "loan-module/fx/service"
+	loanModels "loan-module/loan/models"
+	"loan-module/money"
+	"loan-module/underwriting"
+)
+
+// Quote is the price of a loan under one rate card.
+type Quote struct {
+	RateCardVersion string      `json:"rate_card_version"`
+	RiskGrade       string      `json:"risk_grade"`
+	BaseRate        float64     `json:"base_rate"`
+	RiskSpread      float64     `json:"risk_spread"`
+	AnnualRate      float64     `json:"annual_rate"`
+	APR             float64     `json:"apr"`
+	ProcessingFee   money.Money `json:"processing_fee"`
+	TenureMonths    int         `json:"tenure_months"`
+	EMI             money.Money `json:"emi"`
+}
+
+// CreditScore is the optional score used to pick a risk grade. Scored is
+// false for customers with no bureau history.
+type CreditScore struct {
+	Score  int
+	Scored bool
+}
+
+type Engine struct {
+	card      *RateCard
+	fxService *fx.FXService
+}
+
+func NewEngine(card *RateCard, fxService *fx.FXService) *Engine {
+	return &Engine{card: card, fxService: fxService}
+}
+
+func (e *Engine) RateCardVersion() string {
+	return e.card.Version
+}
+
+// Prices reports whether the rate card has rates for the loan type.
+func (e *Engine) Prices(loanType loanModels.LoanType) bool {
+	_, ok := e.card.Products[loanType]
+	return ok
+}
+
+// Price quotes a loan of the given type, amount and tenure for a credit score.
+// Loans in another currency than the rate card's are banded and have their
+// fee capped at the latest exchange rate.
+func (e *Engine) Price(loanType loanModels.LoanType, amount money.Money, tenureMonths int, score CreditScore) (*Quote, error) {
+	currency := amount.Currency()
+	if currency == "" {
+		currency = e.card.Currency
+		amount = amount.In(currency)
+	}
+	cardAmount, err := e.fxService.Convert(amount, e.card.Currency)
+	if err != nil {
+		return nil, err
+	}
+	baseRate, product, err := e.card.baseRate(loanType, cardAmount)
+	if err != nil {
+		return nil, err
+	}
+	grade, spread := e.card.grade(score.Score, score.Scored)
+
+	minFee, err := e.fxService.Convert(product.MinProcessingFee, currency)
+	if err != nil {
+		return nil, err
+	}
+	maxFee, err := e.fxService.Convert(product.MaxProcessingFee, currency)
+	if err != nil {
+		return nil, err
+	}
+	fee := money.Max(amount.Mul(product.ProcessingFeePercent, money.HalfUp), minFee)
+	if maxFee.IsPositive() {
+		fee = money.Min(fee, maxFee)
+	}
+
+	rate := baseRate + spread
+	emi := underwriting.EMI(amount, rate, tenureMonths)
+	return &Quote{
+		RateCardVersion: e.card.Version,
+		RiskGrade:       grade,
+		BaseRate:        baseRate,
+		RiskSpread:      spread,
+		AnnualRate:      round6(rate),
+		APR:             round6(apr(amount, fee, emi, tenureMonths, rate)),
+		ProcessingFee:   fee,
+		TenureMonths:    tenureMonths,
+		EMI:             emi,
+	}, nil
+}
+
+// apr finds the annual rate at which the instalments repay the amount
+// actually disbursed, i.e. the principal less the processing fee.
+func apr(principal, fee, emi money.Money, months int, nominal float64) float64 {
+	net := principal.Sub(fee)
+	if months <= 0 || !emi.IsPositive() || !net.IsPositive() {
+		return nominal
+	}
+	lo, hi := nominal, nominal+1
+	for i := 0; i < 100; i++ {
+		mid := (lo + hi) / 2
+		if underwriting.PrincipalForEMI(emi, mid, months).GreaterThan(net) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+func round6(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+// QuoteLoan prices a new application and records the quoted rate and APR.
+func (e *Engine) QuoteLoan(loan *loanModels.Loan, score CreditScore) (*Quote, error) {
+	quote, err := e.Price(loan.LoanType, loan.LoanAmount, loan.TenureMonths, score)
+	if err != nil {
+		return nil, err
+	}
+	loan.QuotedRate = &quote.AnnualRate
+	loan.QuotedAPR = &quote.APR
+	loan.ProcessingFee = &quote.ProcessingFee
+	loan.RiskGrade = quote.RiskGrade
+	loan.RateCardVersion = quote.RateCardVersion
+	return quote, nil
+}
+
+// PriceApproval fixes the final rate of a loan being approved, using the
+// current rate card and the score from the loan's credit report.
+func (e *Engine) PriceApproval(loan *loanModels.Loan, score CreditScore) (*Quote, error) {
+	quote, err := e.Price(loan.LoanType, loan.LoanAmount, loan.TenureMonths, score)
+	if err != nil {
+		return nil, err
+	}
+	loan.FinalRate = &quote.AnnualRate
+	loan.FinalAPR = &quote.APR
+	loan.ProcessingFee = &quote.ProcessingFee
+	loan.RiskGrade = quote.RiskGrade
+	loan.RateCardVersion = quote.RateCardVersion
+	return quote, nil
+}
@@ -0,0 +1,178 @@
+package pricing
+
+import (
+	"fmt"
+	"sort"
+
+	"loan-module/apperror"
+	loanModels "loan-module/loan/models"
+	"loan-module/money"
+	"loan-module/providers"
+)
+
+// ErrNoRate is returned for loans the rate card has no rate for.
+var ErrNoRate = apperror.Unprocessable("NO_RATE", "the rate card has no rate for the loan")
+
+// AmountBand sets the base rate for loans whose amount is in
+// [MinAmount, MaxAmount]. A zero MaxAmount is unbounded.
+type AmountBand struct {
+	MinAmount money.Money
+	MaxAmount money.Money
+	BaseRate  float64
+}
+
+// ProductRates is the rate table for one loan type.
+type ProductRates struct {
+	LoanType             loanModels.LoanType
+	Bands                []AmountBand
+	ProcessingFeePercent float64
+	MinProcessingFee     money.Money
+	MaxProcessingFee     money.Money
+}
+
+// RiskGrade adds Spread to the base rate for credit scores of at least
+// MinScore. Grades are matched from the highest MinScore down.
+type RiskGrade struct {
+	Grade    string
+	MinScore int
+	Spread   float64
+}
+
+// RateCard is a versioned set of rate tables and risk spreads. Rates are
+// annual fractions, so 0.12 means 12% a year. Amount bands and fee caps are
+// in Currency.
+type RateCard struct {
+	Version        string
+	Currency       money.Currency
+	Products       map[loanModels.LoanType]ProductRates
+	Grades         []RiskGrade
+	UnscoredGrade  string
+	UnscoredSpread float64
+}
+
+func inr(units int64) money.Money {
+	return money.FromUnits(units, money.DefaultCurrency)
+}
+
+// DefaultRateCard is used when no rate card is configured.
+var DefaultRateCard = &RateCard{
+	Version:  "default-v1",
+	Currency: money.DefaultCurrency,
+	Products: map[loanModels.LoanType]ProductRates{
+		loanModels.Personal: {
+			LoanType:             loanModels.Personal,
+			Bands:                []AmountBand{{MaxAmount: inr(100000), BaseRate: 0.13}, {MinAmount: inr(100000), BaseRate: 0.12}},
+			ProcessingFeePercent: 0.02, MinProcessingFee: inr(500), MaxProcessingFee: inr(10000),
+		},
+		loanModels.Home: {
+			LoanType:             loanModels.Home,
+			Bands:                []AmountBand{{MaxAmount: inr(3000000), BaseRate: 0.088}, {MinAmount: inr(3000000), BaseRate: 0.085}},
+			ProcessingFeePercent: 0.005, MinProcessingFee: inr(2500), MaxProcessingFee: inr(25000),
+		},
+		loanModels.Auto: {
+			LoanType:             loanModels.Auto,
+			Bands:                []AmountBand{{BaseRate: 0.095}},
+			ProcessingFeePercent: 0.01, MinProcessingFee: inr(1000), MaxProcessingFee: inr(10000),
+		},
+		loanModels.Business: {
+			LoanType:             loanModels.Business,
+			Bands:                []AmountBand{{MaxAmount: inr(500000), BaseRate: 0.15}, {MinAmount: inr(500000), BaseRate: 0.14}},
+			ProcessingFeePercent: 0.02, MinProcessingFee: inr(2000), MaxProcessingFee: inr(50000),
+		},
+	},
+	Grades: []RiskGrade{
+		{Grade: "A", MinScore: 750, Spread: 0},
+		{Grade: "B", MinScore: 700, Spread: 0.01},
+		{Grade: "C", MinScore: 650, Spread: 0.025},
+		{Grade: "D", MinScore: 550, Spread: 0.045},
+		{Grade: "E", MinScore: 0, Spread: 0.07},
+	},
+	UnscoredGrade:  "U",
+	UnscoredSpread: 0.03,
+}
+
+// RateCardFromConfig builds the rate card from configuration, falling back
+// to DefaultRateCard when none is configured.
+func RateCardFromConfig(cfg providers.PricingConfig) (*RateCard, error) {
+	if len(cfg.Products) == 0 {
+		return DefaultRateCard, nil
+	}
+	if cfg.Version == "" {
+		return nil, fmt.Errorf("pricing: version is required")
+	}
+
+	currency := money.Currency(cfg.Currency)
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	if !currency.IsValid() {
+		return nil, fmt.Errorf("pricing: unsupported currency %q", cfg.Currency)
+	}
+
+	card := &RateCard{
+		Version:        cfg.Version,
+		Currency:       currency,
+		Products:       make(map[loanModels.LoanType]ProductRates, len(cfg.Products)),
+		UnscoredGrade:  cfg.UnscoredGrade,
+		UnscoredSpread: cfg.UnscoredSpread,
+	}
+	if card.UnscoredGrade == "" {
+		card.UnscoredGrade = DefaultRateCard.UnscoredGrade
+	}
+
+	for _, product := range cfg.Products {
+		if len(product.Bands) == 0 {
+			return nil, fmt.Errorf("pricing: loan type %s has no amount bands", product.LoanType)
+		}
+		rates := ProductRates{
+			LoanType:             loanModels.LoanType(product.LoanType),
+			ProcessingFeePercent: product.ProcessingFeePercent,
+			MinProcessingFee:     money.FromFloat(product.MinProcessingFee, currency, money.HalfUp),
+			MaxProcessingFee:     money.FromFloat(product.MaxProcessingFee, currency, money.HalfUp),
+		}
+		for _, band := range product.Bands {
+			rates.Bands = append(rates.Bands, AmountBand{
+				MinAmount: money.FromFloat(band.MinAmount, currency, money.HalfUp),
+				MaxAmount: money.FromFloat(band.MaxAmount, currency, money.HalfUp),
+				BaseRate:  band.BaseRate,
+			})
+		}
+		card.Products[rates.LoanType] = rates
+	}
+
+	for _, grade := range cfg.RiskGrades {
+		card.Grades = append(card.Grades, RiskGrade{Grade: grade.Grade, MinScore: grade.MinScore, Spread: grade.Spread})
+	}
+	if len(card.Grades) == 0 {
+		card.Grades = DefaultRateCard.Grades
+	}
+	sort.Slice(card.Grades, func(i, j int) bool { return card.Grades[i].MinScore > card.Grades[j].MinScore })
+
+	return card, nil
+}
+
+func (c *RateCard) baseRate(loanType loanModels.LoanType, amount money.Money) (float64, *ProductRates, error) {
+	product, ok := c.Products[loanType]
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: no rates for loan type %s in rate card %s", ErrNoRate, loanType, c.Version)
+	}
+	for _, band := range product.Bands {
+		if !amount.LessThan(band.MinAmount) && (band.MaxAmount.IsZero() || amount.LessThan(band.MaxAmount)) {
+			return band.BaseRate, &product, nil
+		}
+	}
+	return 0, nil, fmt.Errorf("%w: amount %s is outside the %s rate bands", ErrNoRate, amount, loanType)
+}
+
+func (c *RateCard) grade(score int, scored bool) (string, float64) {
+	if !scored {
+		return c.UnscoredGrade, c.UnscoredSpread
+	}
+	for _, grade := range c.Grades {
+		if score >= grade.MinScore {
+			return grade.Grade, grade.Spread
+		}
+	}
+	last := c.Grades[len(c.Grades)-1]
+	return last.Grade, last.Spread
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"loan-module/apperror"
+	loanModels "loan-module/loan/models"
+	"loan-module/product/models"
+	"loan-module/product/service"
+)
+
+type ProductHandler struct {
+	productService *service.ProductService
+}
+
+func NewProductHandler(productService *service.ProductService) *ProductHandler {
+	return &ProductHandler{productService: productService}
+}
+
+func (h *ProductHandler) CreateProduct(c *gin.Context) {
+	var req models.ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	product, err := h.productService.CreateProduct(&req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, product)
+}
+
+func (h *ProductHandler) GetProducts(c *gin.Context) {
+	code := loanModels.LoanType(c.Query("code"))
+	activeOnly := c.Query("active") == "true"
+	products := h.productService.GetProducts(code, activeOnly)
+	c.JSON(http.StatusOK, gin.H{"products": products})
+}
+
+func (h *ProductHandler) GetProduct(c *gin.Context) {
+	id, ok := productID(c)
+	if !ok {
+		return
+	}
+	product, exists := h.productService.GetProduct(id)
+	if !exists {
+		c.Error(service.ErrProductNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, product)
+}
+
+func (h *ProductHandler) UpdateProduct(c *gin.Context) {
+	id, ok := productID(c)
+	if !ok {
+		return
+	}
+	var req models.ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	product, err := h.productService.UpdateProduct(id, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, product)
+}
+
+func (h *ProductHandler) RetireProduct(c *gin.Context) {
+	id, ok := productID(c)
+	if !ok {
+		return
+	}
+	product, err := h.productService.RetireProduct(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Product retired successfully", "product": product})
+}
+
+func productID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("product ID"))
+		return 0, false
+	}
+	return id, true
+}
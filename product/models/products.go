@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	loanModels "loan-module/loan/models"
+	"loan-module/money"
+)
+
+// LoanProduct is a catalogue entry that loan applications are validated
+// against. Code is what loans store as their loan type; a code can have
+// several products over time as long as their active windows do not overlap.
+// MinAmount and MaxAmount are in Currency; Limits offer the product in other
+// currencies.
+type LoanProduct struct {
+	ID                int                 `gorm:"primaryKey" json:"id"`
+	Code              loanModels.LoanType `gorm:"type:varchar(30);not null;index" json:"code"`
+	Name              string              `gorm:"not null" json:"name"`
+	Description       string              `json:"description,omitempty"`
+	Currency          money.Currency      `gorm:"type:varchar(3);not null;default:INR" json:"currency"`
+	MinAmount         money.Money         `gorm:"not null" json:"min_amount"`
+	MaxAmount         money.Money         `gorm:"not null" json:"max_amount"`
+	Limits            []*ProductLimit     `gorm:"foreignKey:ProductID" json:"limits"`
+	MinTenureMonths   int                 `gorm:"not null" json:"min_tenure_months"`
+	MaxTenureMonths   int                 `gorm:"not null" json:"max_tenure_months"`
+	EligibleSegments  []string            `gorm:"type:jsonb;serializer:json;not null" json:"eligible_segments"`
+	RequiredDocuments []string            `gorm:"type:jsonb;serializer:json;not null" json:"required_documents"`
+	RateCardVersion   string              `gorm:"type:varchar(50)" json:"rate_card_version,omitempty"`
+	ActiveFrom        time.Time           `gorm:"not null" json:"active_from"`
+	ActiveTo          *time.Time          `json:"active_to,omitempty"`
+	CreatedAt         time.Time           `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time           `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// IsActiveAt reports whether the product can take applications at t.
+func (p *LoanProduct) IsActiveAt(t time.Time) bool {
+	if t.Before(p.ActiveFrom) {
+		return false
+	}
+	return p.ActiveTo == nil || t.Before(*p.ActiveTo)
+}
+
+// AmountLimits returns the amount range of the product in a currency and
+// whether the product is offered in that currency at all.
+func (p *LoanProduct) AmountLimits(currency money.Currency) (money.Money, money.Money, bool) {
+	if currency == p.Currency {
+		return p.MinAmount.In(currency), p.MaxAmount.In(currency), true
+	}
+	for _, limit := range p.Limits {
+		if limit.Currency == currency {
+			return limit.MinAmount.In(currency), limit.MaxAmount.In(currency), true
+		}
+	}
+	return money.Money{}, money.Money{}, false
+}
+
+// ProductLimit is the amount range of a product in a currency other than
+// its own.
+type ProductLimit struct {
+	ID        int            `gorm:"primaryKey" json:"-"`
+	ProductID int            `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"-"`
+	Currency  money.Currency `gorm:"type:varchar(3);not null" json:"currency"`
+	MinAmount money.Money    `gorm:"not null" json:"min_amount"`
+	MaxAmount money.Money    `gorm:"not null" json:"max_amount"`
+}
+
+type ProductLimitRequest struct {
+	Currency  money.Currency `json:"currency" binding:"required"`
+	MinAmount money.Money    `json:"min_amount"`
+	MaxAmount money.Money    `json:"max_amount"`
+}
+
+// ProductRequest is the admin payload for creating or replacing a product.
+// Dates are RFC 3339 timestamps; an empty active_to means open-ended.
+type ProductRequest struct {
+	Code              loanModels.LoanType   `json:"code" binding:"required"`
+	Name              string                `json:"name" binding:"required"`
+	Description       string                `json:"description"`
+	Currency          money.Currency        `json:"currency"`
+	MinAmount         money.Money           `json:"min_amount"`
+	MaxAmount         money.Money           `json:"max_amount"`
+	Limits            []ProductLimitRequest `json:"limits" binding:"omitempty,dive"`
+	MinTenureMonths   int                   `json:"min_tenure_months" binding:"gte=1"`
+	MaxTenureMonths   int                   `json:"max_tenure_months" binding:"gtefield=MinTenureMonths,lte=360"`
+	EligibleSegments  []string              `json:"eligible_segments"`
+	RequiredDocuments []string              `json:"required_documents"`
+	RateCardVersion   string                `json:"rate_card_version"`
+	ActiveFrom        *time.Time            `json:"active_from"`
+	ActiveTo          *time.Time            `json:"active_to"`
+}
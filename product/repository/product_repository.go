@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	loanModels "loan-module/loan/models"
+	"loan-module/product/models"
+	"loan-module/repository"
+)
+
+type ProductRepository struct {
+	db *database.Database
+}
+
+func NewProductRepository(db *database.Database) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+func (r *ProductRepository) AddProduct(product *models.LoanProduct) error {
+	return r.db.DB.Create(product).Error
+}
+
+// UpdateProduct saves a product and replaces its currency limits.
+func (r *ProductRepository) UpdateProduct(product *models.LoanProduct) error {
+	return r.db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models.ProductLimit{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(product).Error
+	})
+}
+
+func (r *ProductRepository) GetProductByID(id int) (*models.LoanProduct, bool) {
+	var product models.LoanProduct
+	result := r.db.DB.Preload("Limits").First(&product, id)
+	return &product, result.Error == nil
+}
+
+func (r *ProductRepository) GetProducts(code loanModels.LoanType) []*models.LoanProduct {
+	var products []*models.LoanProduct
+	query := r.db.DB.Preload("Limits").Order("code ASC, active_from DESC")
+	if code != "" {
+		query = query.Where("code = ?", code)
+	}
+	query.Find(&products)
+	return products
+}
+
+// GetActiveProduct returns the product with the given code that is active
+// at time t.
+func (r *ProductRepository) GetActiveProduct(code loanModels.LoanType, t time.Time) (*models.LoanProduct, bool) {
+	var product models.LoanProduct
+	result := r.db.DB.Preload("Limits").
+		Where("code = ? AND active_from <= ? AND (active_to IS NULL OR active_to > ?)", code, t, t).
+		Order("active_from DESC").
+		First(&product)
+	return &product, result.Error == nil
+}
+
+// HasOverlap reports whether another product with the same code is active
+// at any point in [from, to). A nil to is open-ended.
+func (r *ProductRepository) HasOverlap(code loanModels.LoanType, from time.Time, to *time.Time, excludeID int) (bool, error) {
+	query := r.db.DB.Model(&models.LoanProduct{}).
+		Where("code = ? AND id <> ?", code, excludeID).
+		Where("active_to IS NULL OR active_to > ?", from)
+	if to != nil {
+		query = query.Where("active_from < ?", *to)
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"loan-module/apperror"
+	customerModels "loan-module/customer/models"
+	documentModels "loan-module/document/models"
+	loanModels "loan-module/loan/models"
+	"loan-module/money"
+	"loan-module/pricing"
+	"loan-module/product/models"
+	"loan-module/product/repository"
+	"loan-module/underwriting"
+)
+
+var (
+	ErrProductNotFound = apperror.NotFound("PRODUCT_NOT_FOUND", "product not found")
+	ErrInvalidProduct  = apperror.Validation("INVALID_PRODUCT", "invalid product")
+	ErrNoActiveProduct = apperror.Unprocessable("NO_ACTIVE_PRODUCT", "no active product for loan type")
+	ErrProductOverlap  = apperror.Conflict("PRODUCT_OVERLAP", "another product with this code is active in the same period")
+)
+
+type ProductService struct {
+	repo          *repository.ProductRepository
+	pricingEngine *pricing.Engine
+	policies      underwriting.Policies
+}
+
+func NewProductService(repo *repository.ProductRepository, pricingEngine *pricing.Engine, policies underwriting.Policies) *ProductService {
+	return &ProductService{repo: repo, pricingEngine: pricingEngine, policies: policies}
+}
+
+func (s *ProductService) CreateProduct(req *models.ProductRequest) (*models.LoanProduct, error) {
+	product := &models.LoanProduct{}
+	if err := s.apply(product, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.AddProduct(product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (s *ProductService) UpdateProduct(id int, req *models.ProductRequest) (*models.LoanProduct, error) {
+	product, exists := s.repo.GetProductByID(id)
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+	if err := s.apply(product, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateProduct(product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// RetireProduct ends a product's active window now. Existing loans keep
+// their product code; new applications are refused.
+func (s *ProductService) RetireProduct(id int) (*models.LoanProduct, error) {
+	product, exists := s.repo.GetProductByID(id)
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+	now := time.Now()
+	if product.ActiveTo == nil || product.ActiveTo.After(now) {
+		product.ActiveTo = &now
+		if err := s.repo.UpdateProduct(product); err != nil {
+			return nil, err
+		}
+	}
+	return product, nil
+}
+
+func (s *ProductService) GetProduct(id int) (*models.LoanProduct, bool) {
+	return s.repo.GetProductByID(id)
+}
+
+// GetProducts lists products, optionally only those of one code or those
+// active now.
+func (s *ProductService) GetProducts(code loanModels.LoanType, activeOnly bool) []*models.LoanProduct {
+	products := s.repo.GetProducts(code)
+	if !activeOnly {
+		return products
+	}
+	now := time.Now()
+	active := make([]*models.LoanProduct, 0, len(products))
+	for _, product := range products {
+		if product.IsActiveAt(now) {
+			active = append(active, product)
+		}
+	}
+	return active
+}
+
+// ActiveProduct returns the product that applications of the given loan type
+// are validated against today.
+func (s *ProductService) ActiveProduct(code loanModels.LoanType) (*models.LoanProduct, error) {
+	product, exists := s.repo.GetActiveProduct(code, time.Now())
+	if !exists {
+		return nil, fmt.Errorf("%w %s", ErrNoActiveProduct, code)
+	}
+	return product, nil
+}
+
+// ProductAt returns the product a loan was applied under, if any.
+func (s *ProductService) ProductAt(code loanModels.LoanType, t time.Time) (*models.LoanProduct, bool) {
+	return s.repo.GetActiveProduct(code, t)
+}
+
+// ValidateApplication checks an application against the active product and
+// returns the product together with the tenure to use. A zero tenure means
+// the caller has no preference, so defaultTenure is fitted into the
+// product's range instead. An empty segment skips the segment check.
+func (s *ProductService) ValidateApplication(
+	code loanModels.LoanType,
+	amount money.Money,
+	tenure, defaultTenure int,
+	segment customerModels.EmploymentType,
+) (*models.LoanProduct, int, error) {
+	product, err := s.ActiveProduct(code)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	minAmount, maxAmount, offered := product.AmountLimits(amount.Currency())
+	if !offered {
+		return nil, 0, fmt.Errorf("%s loans are not offered in %s", product.Name, amount.Currency())
+	}
+	if amount.LessThan(minAmount) || amount.GreaterThan(maxAmount) {
+		return nil, 0, fmt.Errorf("%s loans must be between %s and %s %s", product.Name, minAmount, maxAmount, amount.Currency())
+	}
+
+	if tenure == 0 {
+		tenure = min(max(defaultTenure, product.MinTenureMonths), product.MaxTenureMonths)
+	}
+	if tenure < product.MinTenureMonths || tenure > product.MaxTenureMonths {
+		return nil, 0, fmt.Errorf("%s tenure must be between %d and %d months",
+			product.Name, product.MinTenureMonths, product.MaxTenureMonths)
+	}
+
+	if segment != "" && len(product.EligibleSegments) > 0 {
+		eligible := false
+		for _, allowed := range product.EligibleSegments {
+			if allowed == string(segment) {
+				eligible = true
+				break
+			}
+		}
+		if !eligible {
+			return nil, 0, fmt.Errorf("%s is not available to %s customers", product.Name, segment)
+		}
+	}
+
+	return product, tenure, nil
+}
+
+func (s *ProductService) apply(product *models.LoanProduct, req *models.ProductRequest) error {
+	currency := req.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	if err := validateLimits(currency, req.MinAmount, req.MaxAmount); err != nil {
+		return err
+	}
+	limits := make([]*models.ProductLimit, 0, len(req.Limits))
+	seen := map[money.Currency]bool{currency: true}
+	for _, limit := range req.Limits {
+		if seen[limit.Currency] {
+			return fmt.Errorf("%w: duplicate limits for currency %s", ErrInvalidProduct, limit.Currency)
+		}
+		seen[limit.Currency] = true
+		if err := validateLimits(limit.Currency, limit.MinAmount, limit.MaxAmount); err != nil {
+			return err
+		}
+		limits = append(limits, &models.ProductLimit{
+			Currency:  limit.Currency,
+			MinAmount: limit.MinAmount,
+			MaxAmount: limit.MaxAmount,
+		})
+	}
+	for _, segment := range req.EligibleSegments {
+		if !customerModels.EmploymentType(segment).IsValid() {
+			return fmt.Errorf("%w: invalid eligible segment %q", ErrInvalidProduct, segment)
+		}
+	}
+	for _, doc := range req.RequiredDocuments {
+		if !documentModels.DocumentType(doc).IsValid() {
+			return fmt.Errorf("%w: invalid required document %q", ErrInvalidProduct, doc)
+		}
+	}
+	// Applications are priced and underwritten by product code, so a code
+	// needs rates and an affordability policy before it can be offered
+	if !s.pricingEngine.Prices(req.Code) {
+		return fmt.Errorf("%w: rate card %q has no rates for %s",
+			ErrInvalidProduct, s.pricingEngine.RateCardVersion(), req.Code)
+	}
+	if !s.policies.Has(req.Code) {
+		return fmt.Errorf("%w: no affordability policy is configured for %s", ErrInvalidProduct, req.Code)
+	}
+	if req.RateCardVersion != "" && req.RateCardVersion != s.pricingEngine.RateCardVersion() {
+		return fmt.Errorf("%w: rate card %q is not loaded; current rate card is %q",
+			ErrInvalidProduct, req.RateCardVersion, s.pricingEngine.RateCardVersion())
+	}
+
+	activeFrom := time.Now()
+	if req.ActiveFrom != nil {
+		activeFrom = *req.ActiveFrom
+	}
+	if req.ActiveTo != nil && !req.ActiveTo.After(activeFrom) {
+		return fmt.Errorf("%w: active_to must be after active_from", ErrInvalidProduct)
+	}
+	overlap, err := s.repo.HasOverlap(req.Code, activeFrom, req.ActiveTo, product.ID)
+	if err != nil {
+		return err
+	}
+	if overlap {
+		return ErrProductOverlap
+	}
+
+	product.Code = req.Code
+	product.Name = req.Name
+	product.Description = req.Description
+	product.Currency = currency
+	product.MinAmount = req.MinAmount
+	product.MaxAmount = req.MaxAmount
+	product.Limits = limits
+	product.MinTenureMonths = req.MinTenureMonths
+	product.MaxTenureMonths = req.MaxTenureMonths
+	product.EligibleSegments = nonNil(req.EligibleSegments)
+	product.RequiredDocuments = nonNil(req.RequiredDocuments)
+	product.RateCardVersion = req.RateCardVersion
+	product.ActiveFrom = activeFrom
+	product.ActiveTo = req.ActiveTo
+	return nil
+}
+
+func validateLimits(currency money.Currency, minAmount, maxAmount money.Money) error {
+	if !currency.IsValid() {
+		return fmt.Errorf("%w: unsupported currency %q", ErrInvalidProduct, currency)
+	}
+	if !minAmount.IsPositive() {
+		return fmt.Errorf("%w: %s min_amount must be greater than 0", ErrInvalidProduct, currency)
+	}
+	if !maxAmount.GreaterThan(minAmount) {
+		return fmt.Errorf("%w: %s max_amount must be greater than min_amount", ErrInvalidProduct, currency)
+	}
+	return nil
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
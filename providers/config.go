@@ -9,7 +9,16 @@ import (
 )
 
 type Config struct {
-	DB DBConfig `yaml:"db"`
+	DB                DBConfig                `yaml:"db"`
+	Storage           StorageConfig           `yaml:"storage"`
+	DocumentChecklist []DocumentChecklistRule `yaml:"documentChecklist"`
+	CreditBureau      CreditBureauConfig      `yaml:"creditBureau"`
+	Underwriting      UnderwritingConfig      `yaml:"underwriting"`
+	ExposureLimits    ExposureLimitsConfig    `yaml:"exposureLimits"`
+	Pricing           PricingConfig           `yaml:"pricing"`
+	Prepayment        PrepaymentConfig        `yaml:"prepayment"`
+	FX                FXConfig                `yaml:"fx"`
+	Phone             PhoneConfig             `yaml:"phone"`
 }
 
 type DBConfig struct {
@@ -24,6 +33,127 @@ type DBConfig struct {
 	MaxOpenConn int    `yaml:"maxOpenConn"`
 }
 
+type StorageConfig struct {
+	LocalPath string `yaml:"localPath"`
+}
+
+type CreditBureauConfig struct {
+	StubFile string `yaml:"stubFile"`
+}
+
+// FXConfig points at the exchange rates file loaded at start-up and sets the
+// currency reports are converted to by default.
+type FXConfig struct {
+	RatesFile         string `yaml:"ratesFile"`
+	ReportingCurrency string `yaml:"reportingCurrency"`
+}
+
+// PhoneConfig sets the region, as an ISO 3166 code, assumed for phone
+// numbers given without a country code.
+type PhoneConfig struct {
+	DefaultRegion string `yaml:"defaultRegion"`
+}
+
+// ExposureLimitsConfig caps how much a single customer can borrow at once.
+// A zero value disables the corresponding limit. maxActiveExposure is in
+// currency, the default currency when empty; loans in other currencies are
+// converted at the latest exchange rate.
+type ExposureLimitsConfig struct {
+	Currency                   string  `yaml:"currency"`
+	MaxActiveExposure          float64 `yaml:"maxActiveExposure"`
+	MaxOpenApplicationsPerType int     `yaml:"maxOpenApplicationsPerType"`
+	RejectionCooldownDays      int     `yaml:"rejectionCooldownDays"`
+}
+
+// PricingConfig is a versioned rate card. Rates and spreads are annual
+// fractions; processingFeePercent is a fraction of the loan amount. Amount
+// bands and fee caps are in currency, the default currency when empty.
+type PricingConfig struct {
+	Version        string               `yaml:"version"`
+	Currency       string               `yaml:"currency"`
+	Products       []ProductRatesConfig `yaml:"products"`
+	RiskGrades     []RiskGradeConfig    `yaml:"riskGrades"`
+	UnscoredGrade  string               `yaml:"unscoredGrade"`
+	UnscoredSpread float64              `yaml:"unscoredSpread"`
+}
+
+type ProductRatesConfig struct {
+	LoanType             string             `yaml:"loanType"`
+	Bands                []AmountBandConfig `yaml:"bands"`
+	ProcessingFeePercent float64            `yaml:"processingFeePercent"`
+	MinProcessingFee     float64            `yaml:"minProcessingFee"`
+	MaxProcessingFee     float64            `yaml:"maxProcessingFee"`
+}
+
+type AmountBandConfig struct {
+	MinAmount float64 `yaml:"minAmount"`
+	MaxAmount float64 `yaml:"maxAmount"`
+	BaseRate  float64 `yaml:"baseRate"`
+}
+
+type RiskGradeConfig struct {
+	Grade    string  `yaml:"grade"`
+	MinScore int     `yaml:"minScore"`
+	Spread   float64 `yaml:"spread"`
+}
+
+// PrepaymentConfig sets the charges levied on prepaid principal per loan
+// type, as fractions (0.02 = 2%).
+type PrepaymentConfig struct {
+	Charges []PrepaymentChargeConfig `yaml:"charges"`
+}
+
+type PrepaymentChargeConfig struct {
+	LoanType          string  `yaml:"loanType"`
+	PartPaymentCharge float64 `yaml:"partPaymentCharge"`
+	ForeclosureCharge float64 `yaml:"foreclosureCharge"`
+}
+
+type UnderwritingConfig struct {
+	Affordability      []AffordabilityPolicyConfig `yaml:"affordability"`
+	LTVLimits          []LTVLimitConfig            `yaml:"ltvLimits"`
+	ApprovalThresholds []ApprovalThresholdConfig   `yaml:"approvalThresholds"`
+}
+
+// ApprovalThresholdConfig sets the amounts, in one currency, below which the
+// system may approve a loan on its own and above which it rejects it.
+type ApprovalThresholdConfig struct {
+	Currency  string  `yaml:"currency"`
+	MinAmount float64 `yaml:"minAmount"`
+	MaxAmount float64 `yaml:"maxAmount"`
+}
+
+// LTVLimitConfig marks a loan type as secured and caps its loan-to-value
+// ratio.
+type LTVLimitConfig struct {
+	LoanType string  `yaml:"loanType"`
+	MaxLTV   float64 `yaml:"maxLTV"`
+}
+
+// AffordabilityPolicyConfig sets the ratio limits for one loan type. Ratios
+// are fractions of monthly income.
+type AffordabilityPolicyConfig struct {
+	LoanType            string  `yaml:"loanType"`
+	AnnualRate          float64 `yaml:"annualRate"`
+	DefaultTenureMonths int     `yaml:"defaultTenureMonths"`
+	ReferDTI            float64 `yaml:"referDTI"`
+	MaxDTI              float64 `yaml:"maxDTI"`
+	ReferFOIR           float64 `yaml:"referFOIR"`
+	MaxFOIR             float64 `yaml:"maxFOIR"`
+}
+
+// DocumentChecklistRule lists the documents required for a loan type within
+// an amount band. A MaxAmount of 0 means the band has no upper bound. Bands
+// are in currency, the default currency when empty, and only apply to loans
+// in that currency.
+type DocumentChecklistRule struct {
+	LoanType  string   `yaml:"loanType"`
+	Currency  string   `yaml:"currency"`
+	MinAmount float64  `yaml:"minAmount"`
+	MaxAmount float64  `yaml:"maxAmount"`
+	Documents []string `yaml:"documents"`
+}
+
 func GetConfig(configPath string) (*Config, error) {
 	if !filepath.IsAbs(configPath) {
 		wd, err := os.Getwd()
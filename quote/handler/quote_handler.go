@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"loan-module/apperror"
+	"loan-module/quote/models"
+	"loan-module/quote/service"
+)
+
+type QuoteHandler struct {
+	quoteService *service.QuoteService
+}
+
+func NewQuoteHandler(quoteService *service.QuoteService) *QuoteHandler {
+	return &QuoteHandler{quoteService: quoteService}
+}
+
+func (h *QuoteHandler) CreateQuote(c *gin.Context) {
+	var req models.QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	quote, err := h.quoteService.Quote(&req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, quote)
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	loanModels "loan-module/loan/models"
+	"loan-module/money"
+)
+
+type Decision string
+
+const (
+	Eligible Decision = "ELIGIBLE"
+	Refer    Decision = "REFER"
+	Decline  Decision = "DECLINE"
+)
+
+// LoanQuote is a saved indicative quote that can be turned into an
+// application before it expires.
+type LoanQuote struct {
+	ID              int                 `gorm:"primaryKey" json:"quote_id"`
+	CustomerPhone   string              `gorm:"type:varchar(20);not null;index" json:"customer_phone"`
+	LoanType        loanModels.LoanType `gorm:"type:varchar(20);not null" json:"loan_type"`
+	LoanAmount      money.Money         `gorm:"not null" json:"loan_amount"`
+	Currency        money.Currency      `gorm:"type:varchar(3);not null;default:INR" json:"currency"`
+	MaxAmount       money.Money         `gorm:"not null" json:"max_amount"`
+	TenureMonths    int                 `gorm:"not null" json:"tenure_months"`
+	AnnualRate      float64             `gorm:"not null" json:"annual_rate"`
+	APR             float64             `gorm:"column:apr;not null" json:"apr"`
+	ProcessingFee   money.Money         `gorm:"not null" json:"processing_fee"`
+	RiskGrade       string              `gorm:"type:varchar(5);not null" json:"risk_grade"`
+	RateCardVersion string              `gorm:"type:varchar(50);not null" json:"rate_card_version"`
+	Decision        Decision            `gorm:"type:varchar(10);not null" json:"decision"`
+	ExpiresAt       time.Time           `gorm:"not null" json:"expires_at"`
+	ConvertedLoanID *int                `gorm:"constraint:OnDelete:SET NULL" json:"converted_loan_id,omitempty"`
+	CreatedAt       time.Time           `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// AfterFind labels the quote's amounts with its currency.
+func (q *LoanQuote) AfterFind(tx *gorm.DB) error {
+	q.LoanAmount = q.LoanAmount.In(q.Currency)
+	q.MaxAmount = q.MaxAmount.In(q.Currency)
+	q.ProcessingFee = q.ProcessingFee.In(q.Currency)
+	return nil
+}
+
+// QuoteRequest asks for an indicative quote. Currency defaults to the
+// default currency and labels every amount in the request.
+type QuoteRequest struct {
+	CustomerPhone       string              `json:"customer_phone"`
+	LoanType            loanModels.LoanType `json:"loan_type" binding:"required"`
+	LoanAmount          money.Money         `json:"loan_amount"`
+	Currency            money.Currency      `json:"currency"`
+	TenureMonths        int                 `json:"tenure_months" binding:"omitempty,gte=1,lte=360"`
+	MonthlyIncome       money.Money         `json:"monthly_income"`
+	ExistingObligations money.Money         `json:"existing_obligations"`
+	DateOfBirth         string              `json:"date_of_birth"`
+	Save                bool                `json:"save"`
+}
+
+type QuoteResponse struct {
+	QuoteID         *int                                `json:"quote_id,omitempty"`
+	Decision        Decision                            `json:"decision"`
+	Reasons         []string                            `json:"reasons"`
+	LoanType        loanModels.LoanType                 `json:"loan_type"`
+	RequestedAmount money.Money                         `json:"requested_amount"`
+	Currency        money.Currency                      `json:"currency"`
+	MinAmount       money.Money                         `json:"min_amount"`
+	MaxAmount       money.Money                         `json:"max_amount"`
+	TenureMonths    int                                 `json:"tenure_months"`
+	AnnualRate      float64                             `json:"annual_rate"`
+	APR             float64                             `json:"apr"`
+	EMI             money.Money                         `json:"emi"`
+	ProcessingFee   money.Money                         `json:"processing_fee"`
+	RiskGrade       string                              `json:"risk_grade"`
+	RateCardVersion string                              `json:"rate_card_version"`
+	Affordability   *loanModels.AffordabilityAssessment `json:"affordability"`
+	ExpiresAt       *time.Time                          `json:"expires_at,omitempty"`
+}
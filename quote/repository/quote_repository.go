@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"loan-module/quote/models"
+	"loan-module/repository"
+)
+
+type QuoteRepository struct {
+	db *database.Database
+}
+
+func NewQuoteRepository(db *database.Database) *QuoteRepository {
+	return &QuoteRepository{db: db}
+}
+
+func (r *QuoteRepository) AddQuote(quote *models.LoanQuote) error {
+	return r.db.DB.Create(quote).Error
+}
+
+func (r *QuoteRepository) GetQuoteByID(id int) (*models.LoanQuote, bool) {
+	var quote models.LoanQuote
+	result := r.db.DB.First(&quote, id)
+	return &quote, result.Error == nil
+}
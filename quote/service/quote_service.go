@@ -0,0 +1,253 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"loan-module/apperror"
+	"loan-module/constants"
+	creditService "loan-module/credit/service"
+	customerModels "loan-module/customer/models"
+	customerRepo "loan-module/customer/repository"
+	"loan-module/exposure"
+	loanModels "loan-module/loan/models"
+	"loan-module/money"
+	"loan-module/phone"
+	"loan-module/pricing"
+	productService "loan-module/product/service"
+	"loan-module/quote/models"
+	"loan-module/quote/repository"
+	"loan-module/underwriting"
+)
+
+var (
+	ErrInvalidQuoteRequest = apperror.Validation("INVALID_QUOTE_REQUEST", "invalid quote request")
+	ErrQuoteNotFound       = apperror.NotFound("QUOTE_NOT_FOUND", "quote not found")
+	ErrQuoteExpired        = apperror.InvalidTransition("QUOTE_EXPIRED", "quote has expired")
+	ErrQuoteUsed           = apperror.InvalidTransition("QUOTE_USED", "quote has already been used for an application")
+	ErrQuoteMismatch       = apperror.Validation("QUOTE_MISMATCH", "application does not match the quote")
+)
+
+type QuoteService struct {
+	repo            *repository.QuoteRepository
+	customerRepo    *customerRepo.CustomerRepository
+	creditService   *creditService.CreditService
+	exposureChecker *exposure.Checker
+	pricingEngine   *pricing.Engine
+	policies        underwriting.Policies
+	productService  *productService.ProductService
+	phones          *phone.Normalizer
+}
+
+func NewQuoteService(
+	repo *repository.QuoteRepository,
+	customerRepo *customerRepo.CustomerRepository,
+	creditService *creditService.CreditService,
+	exposureChecker *exposure.Checker,
+	pricingEngine *pricing.Engine,
+	policies underwriting.Policies,
+	productService *productService.ProductService,
+	phones *phone.Normalizer,
+) *QuoteService {
+	return &QuoteService{
+		repo:            repo,
+		customerRepo:    customerRepo,
+		creditService:   creditService,
+		exposureChecker: exposureChecker,
+		pricingEngine:   pricingEngine,
+		policies:        policies,
+		productService:  productService,
+		phones:          phones,
+	}
+}
+
+// Quote runs the eligibility, pricing and affordability checks for the
+// customer's inputs without creating a loan. The quote is only stored when
+// the request asks for it.
+func (s *QuoteService) Quote(req *models.QuoteRequest) (*models.QuoteResponse, error) {
+	if !req.LoanAmount.IsPositive() || !req.MonthlyIncome.IsPositive() {
+		return nil, fmt.Errorf("%w: loan_amount and monthly_income must be greater than 0", ErrInvalidQuoteRequest)
+	}
+	if req.ExistingObligations.IsNegative() {
+		return nil, fmt.Errorf("%w: existing_obligations cannot be negative", ErrInvalidQuoteRequest)
+	}
+	if req.Currency == "" {
+		req.Currency = money.DefaultCurrency
+	}
+	if !req.Currency.IsValid() {
+		return nil, fmt.Errorf("%w: unsupported currency %q", ErrInvalidQuoteRequest, req.Currency)
+	}
+	req.LoanAmount = req.LoanAmount.In(req.Currency)
+	req.MonthlyIncome = req.MonthlyIncome.In(req.Currency)
+	req.ExistingObligations = req.ExistingObligations.In(req.Currency)
+	policy, ok := s.policies.For(req.LoanType)
+	if !ok {
+		return nil, fmt.Errorf("%w: loan type %s cannot be underwritten", ErrInvalidQuoteRequest, req.LoanType)
+	}
+
+	var reasons []string
+	decision := models.Eligible
+	decline := func(reason string) {
+		decision = models.Decline
+		reasons = append(reasons, reason)
+	}
+	refer := func(reason string) {
+		if decision == models.Eligible {
+			decision = models.Refer
+		}
+		reasons = append(reasons, reason)
+	}
+
+	product, err := s.productService.ActiveProduct(req.LoanType)
+	if err != nil {
+		return nil, err
+	}
+	tenure := req.TenureMonths
+	if tenure == 0 {
+		tenure = min(max(policy.DefaultTenureMonths, product.MinTenureMonths), product.MaxTenureMonths)
+	}
+	if _, _, err := s.productService.ValidateApplication(req.LoanType, req.LoanAmount, tenure, tenure, ""); err != nil {
+		decline(err.Error())
+	}
+	if req.DateOfBirth != "" {
+		dob, err := time.Parse("2006-01-02", req.DateOfBirth)
+		if err != nil {
+			return nil, fmt.Errorf("%w: date_of_birth must be in YYYY-MM-DD format", ErrInvalidQuoteRequest)
+		}
+		if age := customerModels.AgeOn(dob, time.Now()); age < customerModels.MinAge || age > customerModels.MaxAge {
+			decline(fmt.Sprintf("applicant age must be between %d and %d", customerModels.MinAge, customerModels.MaxAge))
+		}
+	}
+
+	// Known customers are checked against their limits and cached credit score
+	var score pricing.CreditScore
+	if req.CustomerPhone != "" {
+		number, err := s.phones.Normalize(req.CustomerPhone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidQuoteRequest, err)
+		}
+		req.CustomerPhone = number
+		if customer, exists := s.customerRepo.GetCustomerByPhone(req.CustomerPhone); exists {
+			if err := s.exposureChecker.CheckSubmission(customer.ID, req.LoanType, req.LoanAmount); err != nil {
+				var limitErr *exposure.LimitError
+				if !errors.As(err, &limitErr) {
+					return nil, err
+				}
+				decline(limitErr.Message)
+			}
+			score.Score, score.Scored = s.creditService.LatestScore(customer.ID)
+		}
+	}
+	switch {
+	case !score.Scored:
+		refer("no credit score on file; final terms depend on a credit check")
+	case score.Score < constants.MinCreditScore:
+		decline(fmt.Sprintf("credit score is below the minimum of %d", constants.MinCreditScore))
+	}
+
+	quote, err := s.pricingEngine.Price(req.LoanType, req.LoanAmount, tenure, score)
+	if err != nil {
+		return nil, err
+	}
+	policy.AnnualRate = quote.AnnualRate
+	assessment := underwriting.Assess(policy, underwriting.Input{
+		LoanAmount:          req.LoanAmount,
+		TenureMonths:        tenure,
+		MonthlyIncome:       req.MonthlyIncome,
+		ExistingObligations: req.ExistingObligations,
+	})
+	switch assessment.Outcome {
+	case loanModels.AffordabilityReject:
+		decline(assessment.Reason)
+	case loanModels.AffordabilityRefer:
+		refer(assessment.Reason)
+	}
+
+	// Products not offered in the currency were declined above
+	minAmount, maxAmount, offered := product.AmountLimits(req.Currency)
+	if offered {
+		maxAmount = money.Min(assessment.MaxAffordableAmount, maxAmount)
+	}
+	if !offered {
+		minAmount = money.New(0, req.Currency)
+	}
+	if !offered || maxAmount.IsNegative() {
+		maxAmount = money.New(0, req.Currency)
+	}
+	response := &models.QuoteResponse{
+		Decision:        decision,
+		Reasons:         reasons,
+		LoanType:        req.LoanType,
+		RequestedAmount: req.LoanAmount,
+		Currency:        req.Currency,
+		MinAmount:       minAmount,
+		MaxAmount:       maxAmount,
+		TenureMonths:    tenure,
+		AnnualRate:      quote.AnnualRate,
+		APR:             quote.APR,
+		EMI:             quote.EMI,
+		ProcessingFee:   quote.ProcessingFee,
+		RiskGrade:       quote.RiskGrade,
+		RateCardVersion: quote.RateCardVersion,
+		Affordability:   assessment,
+	}
+	if response.Reasons == nil {
+		response.Reasons = []string{}
+	}
+
+	if req.Save && decision != models.Decline {
+		if req.CustomerPhone == "" {
+			return nil, fmt.Errorf("%w: customer_phone is required to save a quote", ErrInvalidQuoteRequest)
+		}
+		record := &models.LoanQuote{
+			CustomerPhone:   req.CustomerPhone,
+			LoanType:        req.LoanType,
+			LoanAmount:      req.LoanAmount,
+			Currency:        req.Currency,
+			MaxAmount:       maxAmount,
+			TenureMonths:    tenure,
+			AnnualRate:      quote.AnnualRate,
+			APR:             quote.APR,
+			ProcessingFee:   quote.ProcessingFee,
+			RiskGrade:       quote.RiskGrade,
+			RateCardVersion: quote.RateCardVersion,
+			Decision:        decision,
+			ExpiresAt:       time.Now().Add(constants.QuoteValidity),
+		}
+		if err := s.repo.AddQuote(record); err != nil {
+			return nil, err
+		}
+		response.QuoteID = &record.ID
+		response.ExpiresAt = &record.ExpiresAt
+	}
+	return response, nil
+}
+
+// ValidateForApplication checks that a saved quote can back a new loan for
+// the given phone, type, amount and tenure. The quote's price only holds for
+// the terms it was worked out for, so the amount must be the quoted amount
+// and the tenure, unless left at 0 to take the quote's, the quoted tenure.
+func (s *QuoteService) ValidateForApplication(quoteID int, phone string, loanType loanModels.LoanType, amount money.Money, tenure int) (*models.LoanQuote, error) {
+	quote, exists := s.repo.GetQuoteByID(quoteID)
+	if !exists {
+		return nil, ErrQuoteNotFound
+	}
+	if quote.ConvertedLoanID != nil {
+		return nil, ErrQuoteUsed
+	}
+	if time.Now().After(quote.ExpiresAt) {
+		return nil, ErrQuoteExpired
+	}
+	switch {
+	case quote.CustomerPhone != phone:
+		return nil, fmt.Errorf("%w: the quote is for another customer", ErrQuoteMismatch)
+	case quote.LoanType != loanType:
+		return nil, fmt.Errorf("%w: the quote is for a %s loan", ErrQuoteMismatch, quote.LoanType)
+	case amount.Currency() != quote.Currency || !amount.Equal(quote.LoanAmount):
+		return nil, fmt.Errorf("%w: the quote is for %s %s", ErrQuoteMismatch, quote.LoanAmount, quote.Currency)
+	case tenure != 0 && tenure != quote.TenureMonths:
+		return nil, fmt.Errorf("%w: the quote is for %d months", ErrQuoteMismatch, quote.TenureMonths)
+	}
+	return quote, nil
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"loan-module/apperror"
+	"loan-module/repayment/models"
+	"loan-module/repayment/service"
+)
+
+type RepaymentHandler struct {
+	repaymentService *service.RepaymentService
+}
+
+func NewRepaymentHandler(repaymentService *service.RepaymentService) *RepaymentHandler {
+	return &RepaymentHandler{repaymentService: repaymentService}
+}
+
+func (h *RepaymentHandler) GetSchedule(c *gin.Context) {
+	loanID, ok := loanIDParam(c)
+	if !ok {
+		return
+	}
+	version := 0
+	if v := c.Query("version"); v != "" {
+		var err error
+		if version, err = strconv.Atoi(v); err != nil || version < 1 {
+			c.Error(apperror.InvalidParameter("schedule version"))
+			return
+		}
+	}
+	schedule, err := h.repaymentService.GetSchedule(loanID, version)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+func (h *RepaymentHandler) GetSchedules(c *gin.Context) {
+	loanID, ok := loanIDParam(c)
+	if !ok {
+		return
+	}
+	schedules, err := h.repaymentService.GetSchedules(loanID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"schedules": schedules})
+}
+
+func (h *RepaymentHandler) GetLedger(c *gin.Context) {
+	loanID, ok := loanIDParam(c)
+	if !ok {
+		return
+	}
+	entries, err := h.repaymentService.GetLedger(loanID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+func (h *RepaymentHandler) PayInstalment(c *gin.Context) {
+	loanID, ok := loanIDParam(c)
+	if !ok {
+		return
+	}
+	var req models.InstalmentPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	response, err := h.repaymentService.PayInstalment(loanID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *RepaymentHandler) PartPay(c *gin.Context) {
+	loanID, ok := loanIDParam(c)
+	if !ok {
+		return
+	}
+	var req models.PartPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	response, err := h.repaymentService.PartPay(loanID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *RepaymentHandler) GetForeclosureQuote(c *gin.Context) {
+	loanID, ok := loanIDParam(c)
+	if !ok {
+		return
+	}
+	quote, err := h.repaymentService.ForeclosureQuote(loanID, c.Query("date"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, quote)
+}
+
+func (h *RepaymentHandler) Foreclose(c *gin.Context) {
+	loanID, ok := loanIDParam(c)
+	if !ok {
+		return
+	}
+	var req models.ForeclosureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	response, err := h.repaymentService.Foreclose(loanID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *RepaymentHandler) Restructure(c *gin.Context) {
+	loanID, ok := loanIDParam(c)
+	if !ok {
+		return
+	}
+	var req models.RestructureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.InvalidBody(err))
+		return
+	}
+	schedule, err := h.repaymentService.Restructure(loanID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, schedule)
+}
+
+func loanIDParam(c *gin.Context) (int, bool) {
+	loanID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("loan ID"))
+		return 0, false
+	}
+	return loanID, true
+}
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"time"
+
+	"loan-module/money"
+)
+
+type ScheduleStatus string
+
+const (
+	ScheduleActive     ScheduleStatus = "ACTIVE"
+	ScheduleSuperseded ScheduleStatus = "SUPERSEDED"
+	ScheduleClosed     ScheduleStatus = "CLOSED"
+)
+
+// ScheduleReason records why a schedule version was generated.
+type ScheduleReason string
+
+const (
+	ReasonOriginal        ScheduleReason = "ORIGINAL"
+	ReasonPartPaymentEMI  ScheduleReason = "PART_PAYMENT_REDUCE_EMI"
+	ReasonPartPaymentTerm ScheduleReason = "PART_PAYMENT_REDUCE_TENURE"
+	ReasonRestructure     ScheduleReason = "RESTRUCTURE"
+)
+
+// RepaymentSchedule is one version of a loan's instalment plan. A new version
+// is generated whenever the terms change; earlier versions are kept as
+// SUPERSEDED so the history stays auditable. StartDate is when interest on
+// the version's principal starts to run.
+type RepaymentSchedule struct {
+	ID           int            `gorm:"primaryKey" json:"id"`
+	LoanID       int            `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"loan_id"`
+	Version      int            `gorm:"not null" json:"version"`
+	Status       ScheduleStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Reason       ScheduleReason `gorm:"type:varchar(40);not null" json:"reason"`
+	Principal    money.Money    `gorm:"not null" json:"principal"`
+	AnnualRate   float64        `gorm:"not null" json:"annual_rate"`
+	TenureMonths int            `gorm:"not null" json:"tenure_months"`
+	EMI          money.Money    `gorm:"column:emi;not null" json:"emi"`
+	StartDate    time.Time      `gorm:"type:date;not null" json:"start_date"`
+	DisbursedOn  time.Time      `gorm:"type:date;not null" json:"disbursed_on"`
+	Note         string         `json:"note,omitempty"`
+	Instalments  []*Instalment  `gorm:"foreignKey:ScheduleID" json:"instalments,omitempty"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type InstalmentStatus string
+
+const (
+	InstalmentDue       InstalmentStatus = "DUE"
+	InstalmentPaid      InstalmentStatus = "PAID"
+	InstalmentCancelled InstalmentStatus = "CANCELLED"
+)
+
+type Instalment struct {
+	ID               int              `gorm:"primaryKey" json:"id"`
+	ScheduleID       int              `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"-"`
+	Number           int              `gorm:"not null" json:"number"`
+	DueDate          time.Time        `gorm:"type:date;not null" json:"due_date"`
+	OpeningPrincipal money.Money      `gorm:"not null" json:"opening_principal"`
+	EMI              money.Money      `gorm:"column:emi;not null" json:"emi"`
+	Principal        money.Money      `gorm:"not null" json:"principal"`
+	Interest         money.Money      `gorm:"not null" json:"interest"`
+	ClosingPrincipal money.Money      `gorm:"not null" json:"closing_principal"`
+	Status           InstalmentStatus `gorm:"type:varchar(20);not null" json:"status"`
+	PaidAt           *time.Time       `json:"paid_at,omitempty"`
+}
+
+type EntryType string
+
+const (
+	EntryDisbursement EntryType = "DISBURSEMENT"
+	EntryInstalment   EntryType = "INSTALMENT"
+	EntryPartPayment  EntryType = "PART_PAYMENT"
+	EntryForeclosure  EntryType = "FORECLOSURE"
+	EntryRestructure  EntryType = "RESTRUCTURE"
+)
+
+// LedgerEntry is a money movement on a loan. Amount is the total moved and is
+// split into principal, interest and charges; Balance is the outstanding
+// principal afterwards. A restructure entry records arrears capitalised into
+// the principal.
+type LedgerEntry struct {
+	ID              int         `gorm:"primaryKey" json:"id"`
+	LoanID          int         `gorm:"not null;index;constraint:OnDelete:CASCADE" json:"loan_id"`
+	EntryType       EntryType   `gorm:"type:varchar(20);not null" json:"entry_type"`
+	ValueDate       time.Time   `gorm:"type:date;not null" json:"value_date"`
+	Amount          money.Money `gorm:"not null" json:"amount"`
+	Principal       money.Money `gorm:"not null" json:"principal"`
+	Interest        money.Money `gorm:"not null" json:"interest"`
+	Charges         money.Money `gorm:"not null" json:"charges"`
+	Balance         money.Money `gorm:"not null" json:"balance"`
+	ScheduleVersion int         `gorm:"not null" json:"schedule_version"`
+	CreatedAt       time.Time   `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// PrepaymentOption chooses what a part-payment reduces.
+type PrepaymentOption string
+
+const (
+	ReduceEMI    PrepaymentOption = "REDUCE_EMI"
+	ReduceTenure PrepaymentOption = "REDUCE_TENURE"
+)
+
+type InstalmentPaymentRequest struct {
+	Amount    money.Money `json:"amount"`
+	ValueDate string      `json:"value_date"`
+}
+
+type PartPaymentRequest struct {
+	Amount    money.Money      `json:"amount"`
+	Option    PrepaymentOption `json:"option" binding:"required"`
+	ValueDate string           `json:"value_date"`
+}
+
+type ForeclosureRequest struct {
+	Amount    money.Money `json:"amount"`
+	ValueDate string      `json:"value_date"`
+}
+
+// RestructureRequest changes the terms of an active loan. At least one of
+// the tenure extension, new rate or capitalisation of arrears is required.
+type RestructureRequest struct {
+	ExtendMonths      int      `json:"extend_months" binding:"gte=0,lte=120"`
+	AnnualRate        *float64 `json:"annual_rate" binding:"omitempty,gt=0,lt=1"`
+	CapitaliseArrears bool     `json:"capitalise_arrears"`
+	Reason            string   `json:"reason" binding:"required"`
+}
+
+// Standing summarises how a loan is being repaid.
+type Standing struct {
+	LoanID               int         `json:"loan_id"`
+	ScheduleVersion      int         `json:"schedule_version"`
+	EMI                  money.Money `json:"emi"`
+	OutstandingPrincipal money.Money `json:"outstanding_principal"`
+	PaidInstalments      int         `json:"paid_instalments"`
+	RemainingInstalments int         `json:"remaining_instalments"`
+	OverdueInstalments   int         `json:"overdue_instalments"`
+	OverdueAmount        money.Money `json:"overdue_amount"`
+}
+
+// RepaymentSummary is how a loan has been repaid: instalments paid across
+// every schedule version, and what is outstanding or overdue on the current
+// one. Amounts are scanned without a currency.
+type RepaymentSummary struct {
+	LoanID               int            `json:"loan_id"`
+	ScheduleStatus       ScheduleStatus `json:"schedule_status"`
+	PaidInstalments      int            `json:"paid_instalments"`
+	LatePayments         int            `json:"late_payments"`
+	RemainingInstalments int            `json:"remaining_instalments"`
+	OverdueInstalments   int            `json:"overdue_instalments"`
+	OverdueAmount        money.Money    `json:"overdue_amount"`
+	DaysPastDue          int            `json:"days_past_due"`
+	OutstandingPrincipal money.Money    `json:"outstanding_principal"`
+}
+
+// ForeclosureQuote is what it costs to close a loan on a given date.
+type ForeclosureQuote struct {
+	LoanID               int         `json:"loan_id"`
+	ValueDate            time.Time   `json:"value_date"`
+	OutstandingPrincipal money.Money `json:"outstanding_principal"`
+	AccruedInterest      money.Money `json:"accrued_interest"`
+	InterestFrom         time.Time   `json:"interest_from"`
+	PrepaymentCharge     money.Money `json:"prepayment_charge"`
+	ChargeRate           float64     `json:"charge_rate"`
+	Total                money.Money `json:"total"`
+}
+
+// PaymentResponse is the result of a payment: the ledger entry and, when the
+// payment changed the plan, the new schedule.
+type PaymentResponse struct {
+	Entry    *LedgerEntry       `json:"entry"`
+	Schedule *RepaymentSchedule `json:"schedule,omitempty"`
+	Closed   bool               `json:"closed"`
+}
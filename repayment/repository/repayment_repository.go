@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	loanModels "loan-module/loan/models"
+	"loan-module/repayment/models"
+	"loan-module/repository"
+)
+
+type RepaymentRepository struct {
+	db *database.Database
+}
+
+func NewRepaymentRepository(db *database.Database) *RepaymentRepository {
+	return &RepaymentRepository{db: db}
+}
+
+// CreateSchedule stores a loan's first schedule together with its
+// disbursement entry.
+func (r *RepaymentRepository) CreateSchedule(schedule *models.RepaymentSchedule, disbursement *models.LedgerEntry) error {
+	return r.db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(schedule).Error; err != nil {
+			return err
+		}
+		return tx.Create(disbursement).Error
+	})
+}
+
+// GetActiveSchedule returns the loan's current schedule with its instalments.
+func (r *RepaymentRepository) GetActiveSchedule(loanID int) (*models.RepaymentSchedule, bool) {
+	var schedule models.RepaymentSchedule
+	result := r.db.DB.Preload("Instalments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("number ASC")
+	}).Where("loan_id = ? AND status <> ?", loanID, models.ScheduleSuperseded).
+		Order("version DESC").
+		First(&schedule)
+	return &schedule, result.Error == nil
+}
+
+func (r *RepaymentRepository) GetScheduleByVersion(loanID, version int) (*models.RepaymentSchedule, bool) {
+	var schedule models.RepaymentSchedule
+	result := r.db.DB.Preload("Instalments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("number ASC")
+	}).Where("loan_id = ? AND version = ?", loanID, version).First(&schedule)
+	return &schedule, result.Error == nil
+}
+
+// GetSchedules lists every schedule version of a loan without instalments.
+func (r *RepaymentRepository) GetSchedules(loanID int) []*models.RepaymentSchedule {
+	var schedules []*models.RepaymentSchedule
+	r.db.DB.Where("loan_id = ?", loanID).Order("version ASC").Find(&schedules)
+	return schedules
+}
+
+// GetRepaymentSummaries summarises the repayment of the given loans as of
+// today. Loans that were never disbursed have no summary.
+func (r *RepaymentRepository) GetRepaymentSummaries(loanIDs []int, today time.Time) []*models.RepaymentSummary {
+	summaries := []*models.RepaymentSummary{}
+	if len(loanIDs) == 0 {
+		return summaries
+	}
+	r.db.DB.Raw(`
+		WITH current AS (
+			SELECT DISTINCT ON (loan_id) id, loan_id, status
+			FROM repayment_schedules
+			WHERE loan_id IN @loans AND status <> @superseded
+			ORDER BY loan_id, version DESC
+		)
+		SELECT c.loan_id, c.status AS schedule_status,
+			COUNT(*) FILTER (WHERE i.status = @paid) AS paid_instalments,
+			COUNT(*) FILTER (WHERE i.status = @paid AND i.paid_at::date > i.due_date) AS late_payments,
+			COUNT(*) FILTER (WHERE i.schedule_id = c.id AND i.status = @due) AS remaining_instalments,
+			COUNT(*) FILTER (WHERE i.schedule_id = c.id AND i.status = @due AND i.due_date < @today) AS overdue_instalments,
+			COALESCE(SUM(i.emi) FILTER (WHERE i.schedule_id = c.id AND i.status = @due AND i.due_date < @today), 0) AS overdue_amount,
+			COALESCE(MAX(@today::date - i.due_date) FILTER (WHERE i.schedule_id = c.id AND i.status = @due AND i.due_date < @today), 0) AS days_past_due,
+			COALESCE(MAX(i.opening_principal) FILTER (WHERE i.schedule_id = c.id AND i.status = @due), 0) AS outstanding_principal
+		FROM current c
+		JOIN repayment_schedules s ON s.loan_id = c.loan_id
+		JOIN instalments i ON i.schedule_id = s.id
+		GROUP BY c.loan_id, c.status
+		ORDER BY c.loan_id
+	`, map[string]interface{}{
+		"loans":      loanIDs,
+		"superseded": models.ScheduleSuperseded,
+		"paid":       models.InstalmentPaid,
+		"due":        models.InstalmentDue,
+		"today":      today.Format("2006-01-02"),
+	}).Scan(&summaries)
+	return summaries
+}
+
+func (r *RepaymentRepository) GetLedger(loanID int) []*models.LedgerEntry {
+	var entries []*models.LedgerEntry
+	r.db.DB.Where("loan_id = ?", loanID).Order("value_date ASC, id ASC").Find(&entries)
+	return entries
+}
+
+func (r *RepaymentRepository) CountLedgerEntries(loanID int, entryType models.EntryType) int {
+	var count int64
+	r.db.DB.Model(&models.LedgerEntry{}).Where("loan_id = ? AND entry_type = ?", loanID, entryType).Count(&count)
+	return int(count)
+}
+
+// PayInstalment marks an instalment paid and records the payment. When it is
+// the last instalment, the schedule and loan are closed too. It reports false,
+// recording nothing, when the instalment is no longer due or its schedule no
+// longer active, so a retried or concurrent payment is not taken twice.
+func (r *RepaymentRepository) PayInstalment(instalment *models.Instalment, entry *models.LedgerEntry, closeLoan bool) (bool, error) {
+	paid := false
+	err := r.db.DB.Transaction(func(tx *gorm.DB) error {
+		if locked, err := lockActiveSchedule(tx, instalment.ScheduleID); err != nil || !locked {
+			return err
+		}
+		result := tx.Model(&models.Instalment{}).
+			Where("id = ? AND status = ?", instalment.ID, models.InstalmentDue).
+			Updates(map[string]interface{}{
+				"status":  models.InstalmentPaid,
+				"paid_at": instalment.PaidAt,
+			})
+		if result.Error != nil || result.RowsAffected != 1 {
+			return result.Error
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		if closeLoan {
+			if err := closeSchedule(tx, entry.LoanID, instalment.ScheduleID); err != nil {
+				return err
+			}
+		}
+		paid = true
+		return nil
+	})
+	return paid, err
+}
+
+// Reschedule supersedes the current schedule with a new version and records
+// the payment that caused it. It reports false, recording nothing, when the
+// current schedule is no longer active.
+func (r *RepaymentRepository) Reschedule(current, next *models.RepaymentSchedule, entry *models.LedgerEntry) (bool, error) {
+	rescheduled := false
+	err := r.db.DB.Transaction(func(tx *gorm.DB) error {
+		if locked, err := lockActiveSchedule(tx, current.ID); err != nil || !locked {
+			return err
+		}
+		if err := tx.Model(&models.RepaymentSchedule{}).Where("id = ?", current.ID).
+			Update("status", models.ScheduleSuperseded).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		rescheduled = true
+		return nil
+	})
+	return rescheduled, err
+}
+
+// Foreclose records the closing payment, cancels the remaining instalments
+// and closes the schedule and loan. It reports false, recording nothing, when
+// the schedule is no longer active.
+func (r *RepaymentRepository) Foreclose(schedule *models.RepaymentSchedule, entry *models.LedgerEntry) (bool, error) {
+	foreclosed := false
+	err := r.db.DB.Transaction(func(tx *gorm.DB) error {
+		if locked, err := lockActiveSchedule(tx, schedule.ID); err != nil || !locked {
+			return err
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Instalment{}).
+			Where("schedule_id = ? AND status = ?", schedule.ID, models.InstalmentDue).
+			Update("status", models.InstalmentCancelled).Error; err != nil {
+			return err
+		}
+		if err := closeSchedule(tx, entry.LoanID, schedule.ID); err != nil {
+			return err
+		}
+		foreclosed = true
+		return nil
+	})
+	return foreclosed, err
+}
+
+// lockActiveSchedule locks a schedule until the transaction ends, so payments
+// against it are recorded one at a time. It reports false when the schedule
+// is no longer active.
+func lockActiveSchedule(tx *gorm.DB, scheduleID int) (bool, error) {
+	var schedule models.RepaymentSchedule
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		Where("id = ? AND status = ?", scheduleID, models.ScheduleActive).
+		Limit(1).Find(&schedule)
+	return result.RowsAffected == 1, result.Error
+}
+
+func closeSchedule(tx *gorm.DB, loanID, scheduleID int) error {
+	if err := tx.Model(&models.RepaymentSchedule{}).Where("id = ?", scheduleID).
+		Update("status", models.ScheduleClosed).Error; err != nil {
+		return err
+	}
+	var loan loanModels.Loan
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "application_status", "assigned_agent_id").
+		First(&loan, loanID).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&loanModels.Loan{}).Where("id = ?", loanID).Updates(map[string]interface{}{
+		"application_status": loanModels.Closed,
+		"updated_at":         time.Now(),
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Create(&loanModels.LoanStatusChange{
+		LoanID:     loanID,
+		FromStatus: &loan.ApplicationStatus,
+		ToStatus:   loanModels.Closed,
+		AgentID:    loan.AssignedAgentID,
+	}).Error
+}
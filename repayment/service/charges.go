@@ -0,0 +1,48 @@
+package service
+
+import (
+	"fmt"
+
+	loanModels "loan-module/loan/models"
+	"loan-module/providers"
+)
+
+// Charges are the prepayment charges for a loan type, as fractions of the
+// principal prepaid.
+type Charges struct {
+	PartPayment float64
+	Foreclosure float64
+}
+
+type ChargeTable map[loanModels.LoanType]Charges
+
+// DefaultCharges are used when no prepayment charges are configured. Loan
+// types without an entry prepay free of charge.
+var DefaultCharges = ChargeTable{
+	loanModels.Personal: {PartPayment: 0.02, Foreclosure: 0.04},
+	loanModels.Home:     {PartPayment: 0, Foreclosure: 0},
+	loanModels.Auto:     {PartPayment: 0.03, Foreclosure: 0.05},
+	loanModels.Business: {PartPayment: 0.02, Foreclosure: 0.04},
+}
+
+func ChargesFromConfig(cfg providers.PrepaymentConfig) (ChargeTable, error) {
+	if len(cfg.Charges) == 0 {
+		return DefaultCharges, nil
+	}
+	table := make(ChargeTable, len(cfg.Charges))
+	for _, charge := range cfg.Charges {
+		if charge.PartPaymentCharge < 0 || charge.PartPaymentCharge >= 1 ||
+			charge.ForeclosureCharge < 0 || charge.ForeclosureCharge >= 1 {
+			return nil, fmt.Errorf("prepayment charges %s: charges must be in [0, 1)", charge.LoanType)
+		}
+		table[loanModels.LoanType(charge.LoanType)] = Charges{
+			PartPayment: charge.PartPaymentCharge,
+			Foreclosure: charge.ForeclosureCharge,
+		}
+	}
+	return table, nil
+}
+
+func (t ChargeTable) For(loanType loanModels.LoanType) Charges {
+	return t[loanType]
+}
@@ -0,0 +1,486 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"loan-module/apperror"
+	customerRepo "loan-module/customer/repository"
+	loanModels "loan-module/loan/models"
+	loanRepo "loan-module/loan/repository"
+	"loan-module/money"
+	"loan-module/notification"
+	"loan-module/repayment/models"
+	"loan-module/repayment/repository"
+	"loan-module/underwriting"
+)
+
+var (
+	ErrLoanNotFound       = apperror.NotFound("LOAN_NOT_FOUND", "loan not found")
+	ErrScheduleNotFound   = apperror.NotFound("SCHEDULE_NOT_FOUND", "repayment schedule not found")
+	ErrScheduleExists     = apperror.Conflict("SCHEDULE_EXISTS", "loan already has a repayment schedule")
+	ErrLoanNotActive      = apperror.InvalidTransition("LOAN_NOT_ACTIVE", "loan has no active repayment schedule")
+	ErrInvalidPayment     = apperror.Unprocessable("INVALID_PAYMENT", "invalid payment")
+	ErrOverdueInstalments = apperror.Conflict("OVERDUE_INSTALMENTS", "overdue instalments must be paid first")
+	ErrInvalidRestructure = apperror.Unprocessable("INVALID_RESTRUCTURE", "invalid restructure")
+	ErrScheduleChanged    = apperror.Conflict("SCHEDULE_CHANGED", "the repayment schedule changed while the request was processed; check the ledger before retrying")
+)
+
+type RepaymentService struct {
+	repo                *repository.RepaymentRepository
+	loanRepo            *loanRepo.LoanRepository
+	customerRepo        *customerRepo.CustomerRepository
+	notificationService *notification.NotificationService
+	charges             ChargeTable
+}
+
+func NewRepaymentService(
+	repo *repository.RepaymentRepository,
+	loanRepo *loanRepo.LoanRepository,
+	customerRepo *customerRepo.CustomerRepository,
+	notificationService *notification.NotificationService,
+	charges ChargeTable,
+) *RepaymentService {
+	return &RepaymentService{
+		repo:                repo,
+		loanRepo:            loanRepo,
+		customerRepo:        customerRepo,
+		notificationService: notificationService,
+		charges:             charges,
+	}
+}
+
+// CreateSchedule disburses an approved loan on the given date and generates
+// its first repayment schedule at the final rate.
+func (s *RepaymentService) CreateSchedule(loan *loanModels.Loan, disbursedOn time.Time) (*models.RepaymentSchedule, error) {
+	if len(s.repo.GetSchedules(loan.ID)) > 0 {
+		return nil, ErrScheduleExists
+	}
+	rate := loan.FinalRate
+	if rate == nil {
+		rate = loan.QuotedRate
+	}
+	if rate == nil || loan.TenureMonths <= 0 {
+		return nil, fmt.Errorf("loan %d has no rate or tenure to schedule", loan.ID)
+	}
+
+	start := dateOf(disbursedOn)
+	emi := underwriting.EMI(loan.LoanAmount, *rate, loan.TenureMonths)
+	schedule := &models.RepaymentSchedule{
+		LoanID:       loan.ID,
+		Version:      1,
+		Status:       models.ScheduleActive,
+		Reason:       models.ReasonOriginal,
+		Principal:    loan.LoanAmount,
+		AnnualRate:   *rate,
+		TenureMonths: loan.TenureMonths,
+		EMI:          emi,
+		StartDate:    start,
+		DisbursedOn:  start,
+		Instalments:  amortise(loan.LoanAmount, *rate, emi, loan.TenureMonths, start, 1),
+	}
+	disbursement := &models.LedgerEntry{
+		LoanID:          loan.ID,
+		EntryType:       models.EntryDisbursement,
+		ValueDate:       start,
+		Amount:          loan.LoanAmount,
+		Principal:       loan.LoanAmount,
+		Balance:         loan.LoanAmount,
+		ScheduleVersion: schedule.Version,
+	}
+	if err := s.repo.CreateSchedule(schedule, disbursement); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// GetSchedule returns a schedule version of a loan, or the current one when
+// version is 0.
+func (s *RepaymentService) GetSchedule(loanID, version int) (*models.RepaymentSchedule, error) {
+	if _, exists := s.loanRepo.GetLoanByID(loanID); !exists {
+		return nil, ErrLoanNotFound
+	}
+	var schedule *models.RepaymentSchedule
+	var exists bool
+	if version == 0 {
+		schedule, exists = s.repo.GetActiveSchedule(loanID)
+	} else {
+		schedule, exists = s.repo.GetScheduleByVersion(loanID, version)
+	}
+	if !exists {
+		return nil, ErrScheduleNotFound
+	}
+	return schedule, nil
+}
+
+func (s *RepaymentService) GetSchedules(loanID int) ([]*models.RepaymentSchedule, error) {
+	if _, exists := s.loanRepo.GetLoanByID(loanID); !exists {
+		return nil, ErrLoanNotFound
+	}
+	return s.repo.GetSchedules(loanID), nil
+}
+
+func (s *RepaymentService) GetLedger(loanID int) ([]*models.LedgerEntry, error) {
+	if _, exists := s.loanRepo.GetLoanByID(loanID); !exists {
+		return nil, ErrLoanNotFound
+	}
+	return s.repo.GetLedger(loanID), nil
+}
+
+// PayInstalment pays the next instalment due. The amount must match it.
+func (s *RepaymentService) PayInstalment(loanID int, req *models.InstalmentPaymentRequest) (*models.PaymentResponse, error) {
+	position, err := s.position(loanID)
+	if err != nil {
+		return nil, err
+	}
+	valueDate, err := paymentDate(req.ValueDate, position.interestFrom)
+	if err != nil {
+		return nil, err
+	}
+	next := position.next
+	if !req.Amount.Equal(next.EMI) {
+		return nil, fmt.Errorf("%w: amount must equal the instalment due of %s", ErrInvalidPayment, next.EMI)
+	}
+
+	paidAt := time.Now()
+	next.Status = models.InstalmentPaid
+	next.PaidAt = &paidAt
+	entry := &models.LedgerEntry{
+		LoanID:          loanID,
+		EntryType:       models.EntryInstalment,
+		ValueDate:       valueDate,
+		Amount:          next.EMI,
+		Principal:       next.Principal,
+		Interest:        next.Interest,
+		Balance:         next.ClosingPrincipal,
+		ScheduleVersion: position.schedule.Version,
+	}
+	closing := position.remaining == 1
+	paid, err := s.repo.PayInstalment(next, entry, closing)
+	if err != nil {
+		return nil, err
+	}
+	if !paid {
+		return nil, ErrScheduleChanged
+	}
+	if closing {
+		s.notifyClosed(loanID)
+	}
+	return &models.PaymentResponse{Entry: entry, Closed: closing}, nil
+}
+
+// PartPay prepays part of the principal and regenerates the schedule, either
+// keeping the tenure and lowering the EMI or keeping the EMI and shortening
+// the tenure. Interest for the current period is charged on the reduced
+// principal by the next instalment.
+func (s *RepaymentService) PartPay(loanID int, req *models.PartPaymentRequest) (*models.PaymentResponse, error) {
+	if req.Option != models.ReduceEMI && req.Option != models.ReduceTenure {
+		return nil, fmt.Errorf("%w: option must be %s or %s", ErrInvalidPayment, models.ReduceEMI, models.ReduceTenure)
+	}
+	position, err := s.position(loanID)
+	if err != nil {
+		return nil, err
+	}
+	valueDate, err := paymentDate(req.ValueDate, position.interestFrom)
+	if err != nil {
+		return nil, err
+	}
+	if position.next.DueDate.Before(valueDate) {
+		return nil, ErrOverdueInstalments
+	}
+	if !req.Amount.IsPositive() {
+		return nil, fmt.Errorf("%w: amount must be greater than 0", ErrInvalidPayment)
+	}
+	if !req.Amount.LessThan(position.outstanding) {
+		return nil, fmt.Errorf("%w: amount covers the outstanding principal of %s; foreclose the loan instead",
+			ErrInvalidPayment, position.outstanding)
+	}
+
+	current := position.schedule
+	principal := position.outstanding.Sub(req.Amount)
+	months := position.remaining
+	emi := current.EMI
+	reason := models.ReasonPartPaymentTerm
+	if req.Option == models.ReduceEMI {
+		emi = underwriting.EMI(principal, current.AnnualRate, months)
+		reason = models.ReasonPartPaymentEMI
+	} else {
+		months = tenureForEMI(principal, current.AnnualRate, emi)
+	}
+
+	next := &models.RepaymentSchedule{
+		LoanID:       loanID,
+		Version:      current.Version + 1,
+		Status:       models.ScheduleActive,
+		Reason:       reason,
+		Principal:    principal,
+		AnnualRate:   current.AnnualRate,
+		TenureMonths: months,
+		EMI:          emi,
+		StartDate:    position.interestFrom,
+		DisbursedOn:  current.DisbursedOn,
+		Instalments:  amortise(principal, current.AnnualRate, emi, months, current.DisbursedOn, position.next.Number),
+	}
+	charge := req.Amount.Mul(s.charges.For(position.loan.LoanType).PartPayment, money.HalfUp)
+	entry := &models.LedgerEntry{
+		LoanID:          loanID,
+		EntryType:       models.EntryPartPayment,
+		ValueDate:       valueDate,
+		Amount:          req.Amount.Add(charge),
+		Principal:       req.Amount,
+		Charges:         charge,
+		Balance:         principal,
+		ScheduleVersion: next.Version,
+	}
+	rescheduled, err := s.repo.Reschedule(current, next, entry)
+	if err != nil {
+		return nil, err
+	}
+	if !rescheduled {
+		return nil, ErrScheduleChanged
+	}
+	return &models.PaymentResponse{Entry: entry, Schedule: next}, nil
+}
+
+// ForeclosureQuote works out what it costs to close the loan on a date:
+// the outstanding principal, interest accrued since the last instalment and
+// the foreclosure charge.
+func (s *RepaymentService) ForeclosureQuote(loanID int, date string) (*models.ForeclosureQuote, error) {
+	position, err := s.position(loanID)
+	if err != nil {
+		return nil, err
+	}
+	valueDate := dateOf(time.Now())
+	if date != "" {
+		valueDate, err = time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, fmt.Errorf("%w: date must be YYYY-MM-DD", ErrInvalidPayment)
+		}
+	}
+	return s.foreclosureQuote(position, valueDate)
+}
+
+// Foreclose closes the loan. The amount must match the foreclosure quote for
+// the value date.
+func (s *RepaymentService) Foreclose(loanID int, req *models.ForeclosureRequest) (*models.PaymentResponse, error) {
+	position, err := s.position(loanID)
+	if err != nil {
+		return nil, err
+	}
+	valueDate, err := paymentDate(req.ValueDate, position.interestFrom)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := s.foreclosureQuote(position, valueDate)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Amount.Equal(quote.Total) {
+		return nil, fmt.Errorf("%w: amount must equal the foreclosure amount of %s", ErrInvalidPayment, quote.Total)
+	}
+
+	entry := &models.LedgerEntry{
+		LoanID:          loanID,
+		EntryType:       models.EntryForeclosure,
+		ValueDate:       valueDate,
+		Amount:          quote.Total,
+		Principal:       quote.OutstandingPrincipal,
+		Interest:        quote.AccruedInterest,
+		Charges:         quote.PrepaymentCharge,
+		ScheduleVersion: position.schedule.Version,
+	}
+	foreclosed, err := s.repo.Foreclose(position.schedule, entry)
+	if err != nil {
+		return nil, err
+	}
+	if !foreclosed {
+		return nil, ErrScheduleChanged
+	}
+	s.notifyClosed(loanID)
+	return &models.PaymentResponse{Entry: entry, Closed: true}, nil
+}
+
+// Restructure changes the terms of an active loan for a customer in
+// difficulty and generates a new schedule version. Capitalised arrears add
+// the interest of overdue instalments to the principal, and the overdue
+// instalments are rolled forward into the new schedule rather than dropped.
+func (s *RepaymentService) Restructure(loanID int, req *models.RestructureRequest) (*models.RepaymentSchedule, error) {
+	if req.ExtendMonths == 0 && req.AnnualRate == nil && !req.CapitaliseArrears {
+		return nil, fmt.Errorf("%w: nothing to change", ErrInvalidRestructure)
+	}
+	position, err := s.position(loanID)
+	if err != nil {
+		return nil, err
+	}
+	if len(position.overdue) > 0 && !req.CapitaliseArrears {
+		return nil, ErrOverdueInstalments
+	}
+	if req.CapitaliseArrears && len(position.overdue) == 0 {
+		return nil, fmt.Errorf("%w: no arrears to capitalise", ErrInvalidRestructure)
+	}
+
+	current := position.schedule
+	rate := current.AnnualRate
+	if req.AnnualRate != nil {
+		rate = *req.AnnualRate
+	}
+	arrears := money.New(0, position.outstanding.Currency())
+	start := position.interestFrom
+	for _, instalment := range position.overdue {
+		arrears = arrears.Add(instalment.Interest)
+		start = instalment.DueDate
+	}
+	principal := position.outstanding.Add(arrears)
+	months := position.remaining + req.ExtendMonths
+	emi := underwriting.EMI(principal, rate, months)
+	firstNumber := position.next.Number + len(position.overdue)
+
+	next := &models.RepaymentSchedule{
+		LoanID:       loanID,
+		Version:      current.Version + 1,
+		Status:       models.ScheduleActive,
+		Reason:       models.ReasonRestructure,
+		Principal:    principal,
+		AnnualRate:   rate,
+		TenureMonths: months,
+		EMI:          emi,
+		StartDate:    start,
+		DisbursedOn:  current.DisbursedOn,
+		Note:         req.Reason,
+		Instalments:  amortise(principal, rate, emi, months, current.DisbursedOn, firstNumber),
+	}
+	entry := &models.LedgerEntry{
+		LoanID:          loanID,
+		EntryType:       models.EntryRestructure,
+		ValueDate:       dateOf(time.Now()),
+		Amount:          arrears,
+		Interest:        arrears,
+		Balance:         principal,
+		ScheduleVersion: next.Version,
+	}
+	rescheduled, err := s.repo.Reschedule(current, next, entry)
+	if err != nil {
+		return nil, err
+	}
+	if !rescheduled {
+		return nil, ErrScheduleChanged
+	}
+	log.Printf("Loan %d restructured to schedule version %d: %s", loanID, next.Version, req.Reason)
+	s.notificationService.SendSMSToAll(s.customerRepo.GetLoanPartyPhones(loanID),
+		fmt.Sprintf("Your loan #%d has been restructured: %d instalments of %s from now on.", loanID, len(next.Instalments), emi))
+	return next, nil
+}
+
+// GetStanding reports how an active loan is being repaid. Paid instalments
+// are counted across every schedule version.
+func (s *RepaymentService) GetStanding(loanID int) (*models.Standing, error) {
+	position, err := s.position(loanID)
+	if err != nil {
+		return nil, err
+	}
+	standing := &models.Standing{
+		LoanID:               loanID,
+		ScheduleVersion:      position.schedule.Version,
+		EMI:                  position.schedule.EMI,
+		OutstandingPrincipal: position.outstanding,
+		PaidInstalments:      s.repo.CountLedgerEntries(loanID, models.EntryInstalment),
+		RemainingInstalments: position.remaining,
+		OverdueInstalments:   len(position.overdue),
+	}
+	for _, instalment := range position.overdue {
+		standing.OverdueAmount = standing.OverdueAmount.Add(instalment.EMI)
+	}
+	return standing, nil
+}
+
+func (s *RepaymentService) foreclosureQuote(position *loanPosition, valueDate time.Time) (*models.ForeclosureQuote, error) {
+	if valueDate.Before(position.interestFrom) {
+		return nil, fmt.Errorf("%w: date cannot be before %s", ErrInvalidPayment, position.interestFrom.Format("2006-01-02"))
+	}
+	days := valueDate.Sub(position.interestFrom).Hours() / 24
+	chargeRate := s.charges.For(position.loan.LoanType).Foreclosure
+	quote := &models.ForeclosureQuote{
+		LoanID:               position.loan.ID,
+		ValueDate:            valueDate,
+		OutstandingPrincipal: position.outstanding,
+		AccruedInterest:      position.outstanding.Mul(position.schedule.AnnualRate*days/365, money.HalfUp),
+		InterestFrom:         position.interestFrom,
+		PrepaymentCharge:     position.outstanding.Mul(chargeRate, money.HalfUp),
+		ChargeRate:           chargeRate,
+	}
+	quote.Total = quote.OutstandingPrincipal.Add(quote.AccruedInterest).Add(quote.PrepaymentCharge)
+	return quote, nil
+}
+
+// loanPosition is where a loan stands on its current schedule. Overdue
+// instalments are the unpaid ones that fell due before today.
+type loanPosition struct {
+	loan         *loanModels.Loan
+	schedule     *models.RepaymentSchedule
+	next         *models.Instalment
+	remaining    int
+	overdue      []*models.Instalment
+	outstanding  money.Money
+	interestFrom time.Time
+}
+
+func (s *RepaymentService) position(loanID int) (*loanPosition, error) {
+	loan, exists := s.loanRepo.GetLoanByID(loanID)
+	if !exists {
+		return nil, ErrLoanNotFound
+	}
+	schedule, exists := s.repo.GetActiveSchedule(loanID)
+	if !exists || schedule.Status != models.ScheduleActive {
+		return nil, ErrLoanNotActive
+	}
+
+	today := dateOf(time.Now())
+	p := &loanPosition{loan: loan, schedule: schedule, interestFrom: schedule.StartDate}
+	for _, instalment := range schedule.Instalments {
+		switch instalment.Status {
+		case models.InstalmentPaid:
+			p.interestFrom = instalment.DueDate
+		case models.InstalmentDue:
+			if p.next == nil {
+				p.next = instalment
+			}
+			if instalment.DueDate.Before(today) {
+				p.overdue = append(p.overdue, instalment)
+			}
+			p.remaining++
+		}
+	}
+	if p.next == nil {
+		return nil, ErrLoanNotActive
+	}
+	p.outstanding = p.next.OpeningPrincipal
+	return p, nil
+}
+
+// paymentDate parses a payment's value date, defaulting to today. Payments
+// cannot be backdated before the current interest period or made in the
+// future.
+func paymentDate(value string, earliest time.Time) (time.Time, error) {
+	today := dateOf(time.Now())
+	if value == "" {
+		return today, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: value_date must be YYYY-MM-DD", ErrInvalidPayment)
+	}
+	if date.After(today) {
+		return time.Time{}, fmt.Errorf("%w: value_date cannot be in the future", ErrInvalidPayment)
+	}
+	if date.Before(earliest) {
+		return time.Time{}, fmt.Errorf("%w: value_date cannot be before %s", ErrInvalidPayment, earliest.Format("2006-01-02"))
+	}
+	return date, nil
+}
+
+func (s *RepaymentService) notifyClosed(loanID int) {
+	log.Printf("Loan %d closed", loanID)
+	s.notificationService.SendSMSToAll(s.customerRepo.GetLoanPartyPhones(loanID),
+		fmt.Sprintf("Your loan #%d has been repaid in full and is now closed.", loanID))
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"loan-module/money"
+	"loan-module/repayment/models"
+)
+
+// amortise splits principal into monthly instalments of emi at the annual
+// rate. Instalments are numbered from firstNumber and instalment n falls due
+// n months after disbursement. Interest is rounded half up each month and
+// the last instalment absorbs any rounding difference.
+func amortise(principal money.Money, annualRate float64, emi money.Money, months int, disbursedOn time.Time, firstNumber int) []*models.Instalment {
+	instalments := make([]*models.Instalment, 0, months)
+	opening := principal
+	for i := 0; i < months && opening.IsPositive(); i++ {
+		interest := opening.Mul(annualRate/12, money.HalfUp)
+		amount := emi
+		principalPart := emi.Sub(interest)
+		if i == months-1 || !principalPart.LessThan(opening) {
+			principalPart = opening
+			amount = opening.Add(interest)
+		}
+		instalments = append(instalments, &models.Instalment{
+			Number:           firstNumber + i,
+			DueDate:          addMonths(disbursedOn, firstNumber+i),
+			OpeningPrincipal: opening,
+			EMI:              amount,
+			Principal:        principalPart,
+			Interest:         interest,
+			ClosingPrincipal: opening.Sub(principalPart),
+			Status:           models.InstalmentDue,
+		})
+		opening = opening.Sub(principalPart)
+	}
+	return instalments
+}
+
+// tenureForEMI is the number of months an instalment of emi takes to repay
+// principal at the annual rate.
+func tenureForEMI(principal money.Money, annualRate float64, emi money.Money) int {
+	r := annualRate / 12
+	if r == 0 {
+		return int(math.Ceil(principal.Ratio(emi)))
+	}
+	return int(math.Ceil(-math.Log(1-principal.Ratio(emi)*r) / math.Log(1+r)))
+}
+
+// addMonths adds months to a date, clamping to the end of shorter months so
+// that instalments due on the 31st fall on the 30th or 28th rather than
+// spilling into the next month.
+func addMonths(date time.Time, months int) time.Time {
+	year, month, day := date.Date()
+	first := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+// dateOf truncates a time to its calendar date in UTC.
+func dateOf(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
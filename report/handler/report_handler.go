@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"loan-module/apperror"
+	"loan-module/report/models"
+	"loan-module/report/service"
+)
+
+type ReportHandler struct {
+	reportService *service.ReportService
+}
+
+func NewReportHandler(reportService *service.ReportService) *ReportHandler {
+	return &ReportHandler{reportService: reportService}
+}
+
+func (h *ReportHandler) GetApprovalRates(c *gin.Context) {
+	filter, ok := h.filter(c, models.GroupLoanType, models.GroupChannel)
+	if !ok {
+		return
+	}
+	report, err := h.reportService.ApprovalRates(filter)
+	respond(c, report, err)
+}
+
+func (h *ReportHandler) GetDecisionSources(c *gin.Context) {
+	filter, ok := h.filter(c, models.GroupLoanType)
+	if !ok {
+		return
+	}
+	report, err := h.reportService.DecisionSources(filter)
+	respond(c, report, err)
+}
+
+func (h *ReportHandler) GetDecisionTimes(c *gin.Context) {
+	filter, ok := h.filter(c, models.GroupDecidedBy)
+	if !ok {
+		return
+	}
+	report, err := h.reportService.DecisionTimes(filter)
+	respond(c, report, err)
+}
+
+func (h *ReportHandler) GetAmountDistributions(c *gin.Context) {
+	filter, ok := h.filter(c, models.GroupLoanType, models.GroupCurrency)
+	if !ok {
+		return
+	}
+	report, err := h.reportService.AmountDistributions(filter)
+	respond(c, report, err)
+}
+
+func (h *ReportHandler) GetDailyVolumes(c *gin.Context) {
+	filter, ok := h.filter(c)
+	if !ok {
+		return
+	}
+	report, err := h.reportService.DailyVolumes(filter)
+	respond(c, report, err)
+}
+
+func (h *ReportHandler) GetAgentStats(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("agent_id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("agent ID"))
+		return
+	}
+	stats, err := h.reportService.AgentStats(id, c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *ReportHandler) GetTeamStats(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.Error(apperror.InvalidParameter("manager ID"))
+		return
+	}
+	stats, err := h.reportService.TeamStats(id, c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// filter reads the report filter from the from, to and group_by query
+// parameters, recording the error when they are invalid.
+func (h *ReportHandler) filter(c *gin.Context, defaults ...models.GroupBy) (models.Filter, bool) {
+	filter, err := h.reportService.Filter(c.Query("from"), c.Query("to"), c.Query("group_by"), defaults...)
+	if err != nil {
+		c.Error(err)
+		return filter, false
+	}
+	return filter, true
+}
+
+func respond(c *gin.Context, report *models.Report, err error) {
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"time"
+
+	agentModels "loan-module/agent/models"
+	"loan-module/money"
+)
+
+// GroupBy is a dimension a report can be grouped by.
+type GroupBy string
+
+const (
+	GroupLoanType  GroupBy = "loan_type"
+	GroupChannel   GroupBy = "channel"
+	GroupCurrency  GroupBy = "currency"
+	GroupDecidedBy GroupBy = "decided_by"
+)
+
+func (g GroupBy) IsValid() bool {
+	switch g {
+	case GroupLoanType, GroupChannel, GroupCurrency, GroupDecidedBy:
+		return true
+	}
+	return false
+}
+
+// Decision makers reported under decided_by.
+const (
+	DecidedBySystem = "SYSTEM"
+	DecidedByAgent  = "AGENT"
+)
+
+// Filter selects the applications a report covers: those created in
+// [From, To) grouped by GroupBy.
+type Filter struct {
+	From    time.Time
+	To      time.Time
+	GroupBy []GroupBy
+}
+
+// Report is the response of every report: the dates it covers, both
+// inclusive, what it was grouped by and its rows.
+type Report struct {
+	From    string      `json:"from"`
+	To      string      `json:"to"`
+	GroupBy []GroupBy   `json:"group_by"`
+	Rows    interface{} `json:"rows"`
+}
+
+// Group identifies a row of a grouped report. Only the dimensions the
+// report was grouped by are set.
+type Group struct {
+	LoanType  string `json:"loan_type,omitempty"`
+	Channel   string `json:"channel,omitempty"`
+	Currency  string `json:"currency,omitempty"`
+	DecidedBy string `json:"decided_by,omitempty"`
+}
+
+// ApprovalRate counts applications and their decisions. ApprovalRate is
+// approved over decided applications.
+type ApprovalRate struct {
+	Group
+	Applications int     `json:"applications"`
+	Decided      int     `json:"decided"`
+	Approved     int     `json:"approved"`
+	Rejected     int     `json:"rejected"`
+	ApprovalRate float64 `json:"approval_rate"`
+}
+
+// DecisionSource splits decisions between the system and agents.
+type DecisionSource struct {
+	Group
+	SystemApproved int     `json:"system_approved"`
+	SystemRejected int     `json:"system_rejected"`
+	AgentApproved  int     `json:"agent_approved"`
+	AgentRejected  int     `json:"agent_rejected"`
+	SystemShare    float64 `json:"system_share"`
+}
+
+// DecisionTime measures the time from application to decision, in seconds.
+type DecisionTime struct {
+	Group
+	Decisions     int     `json:"decisions"`
+	AvgSeconds    float64 `json:"avg_seconds"`
+	MedianSeconds float64 `json:"median_seconds"`
+	P90Seconds    float64 `json:"p90_seconds"`
+}
+
+// AmountDistribution describes the requested amounts of applications in one
+// currency.
+type AmountDistribution struct {
+	Group
+	Applications int         `json:"applications"`
+	Total        money.Money `json:"total"`
+	Min          money.Money `json:"min"`
+	Max          money.Money `json:"max"`
+	Avg          money.Money `json:"avg"`
+	Median       money.Money `json:"median"`
+	P90          money.Money `json:"p90"`
+}
+
+// DailyVolume counts the applications created on one day.
+type DailyVolume struct {
+	Day string `json:"day"`
+	Group
+	Applications int `json:"applications"`
+}
+
+// AgentStats measures the loans an agent, or a team, reviewed. Assigned and
+// decided count assignments and decisions in the report's date range;
+// review time runs from the loan's assignment to its decision, and a
+// decision taking longer than the SLA is a breach. Queue and Overdue are the
+// loans under review now, and those of them already past the SLA.
+type AgentStats struct {
+	AgentID             int     `json:"-"`
+	Assigned            int     `json:"assigned"`
+	Decided             int     `json:"decided"`
+	Approved            int     `json:"approved"`
+	ApprovalRatio       float64 `json:"approval_ratio"`
+	MedianReviewSeconds float64 `json:"median_review_seconds"`
+	SLABreaches         int     `gorm:"column:sla_breaches" json:"sla_breaches"`
+	Queue               int     `json:"queue"`
+	Overdue             int     `json:"overdue"`
+}
+
+// AgentStatsResponse is an agent's own stats and, for managers, the stats of
+// their whole team including themselves.
+type AgentStatsResponse struct {
+	Agent    *agentModels.Agent `json:"agent"`
+	From     string             `json:"from"`
+	To       string             `json:"to"`
+	SLAHours float64            `json:"sla_hours"`
+	Own      *AgentStats        `json:"own"`
+	Team     *AgentStats        `json:"team,omitempty"`
+}
+
+// TeamMemberStats is one member of a manager's team. Team rolls up the
+// member's own reports and is only set for members who manage others.
+type TeamMemberStats struct {
+	Agent *agentModels.Agent `json:"agent"`
+	Depth int                `json:"depth"`
+	Own   *AgentStats        `json:"own"`
+	Team  *AgentStats        `json:"team,omitempty"`
+}
+
+// TeamStatsResponse rolls up a manager's whole team, including the manager,
+// with a breakdown per member.
+type TeamStatsResponse struct {
+	Manager  *agentModels.Agent `json:"manager"`
+	From     string             `json:"from"`
+	To       string             `json:"to"`
+	SLAHours float64            `json:"sla_hours"`
+	Team     *AgentStats        `json:"team"`
+	Members  []*TeamMemberStats `json:"members"`
+}
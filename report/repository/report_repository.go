@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	loanModels "loan-module/loan/models"
+	"loan-module/report/models"
+	"loan-module/repository"
+)
+
+// decisionsCTE picks each loan's decision from its status history. Every
+// report joins it so that any report can be grouped by decided_by. Queries
+// take their parameters as named arguments, see query.
+const decisionsCTE = `
+	WITH decisions AS (
+		SELECT DISTINCT ON (loan_id) loan_id, to_status, changed_at
+		FROM loan_status_changes
+		WHERE to_status IN @decided
+		ORDER BY loan_id, changed_at
+	)`
+
+const reportFrom = `
+	FROM loans l
+	LEFT JOIN decisions d ON d.loan_id = l.id
+	WHERE l.created_at >= @from AND l.created_at < @to`
+
+// groupColumns maps each grouping to the SQL expression it groups by.
+var groupColumns = map[models.GroupBy]string{
+	models.GroupLoanType: "l.loan_type",
+	models.GroupChannel:  "l.channel",
+	models.GroupCurrency: "l.currency",
+	models.GroupDecidedBy: `CASE
+		WHEN d.to_status IN (@approved_by_system, @rejected_by_system) THEN @system
+		WHEN d.to_status IS NOT NULL THEN @agent
+		ELSE '' END`,
+}
+
+type ReportRepository struct {
+	db *database.Database
+}
+
+func NewReportRepository(db *database.Database) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// GetApprovalRates counts applications and their decisions.
+func (r *ReportRepository) GetApprovalRates(filter models.Filter) ([]*models.ApprovalRate, error) {
+	rows := []*models.ApprovalRate{}
+	err := r.query(filter, `
+		COUNT(*) AS applications,
+		COUNT(d.loan_id) AS decided,
+		COUNT(*) FILTER (WHERE d.to_status IN @approved) AS approved,
+		COUNT(*) FILTER (WHERE d.to_status IN @rejected) AS rejected`, "", &rows)
+	return rows, err
+}
+
+// GetDecisionSources counts decided applications by who decided them.
+func (r *ReportRepository) GetDecisionSources(filter models.Filter) ([]*models.DecisionSource, error) {
+	rows := []*models.DecisionSource{}
+	err := r.query(filter, `
+		COUNT(*) FILTER (WHERE d.to_status = @approved_by_system) AS system_approved,
+		COUNT(*) FILTER (WHERE d.to_status = @rejected_by_system) AS system_rejected,
+		COUNT(*) FILTER (WHERE d.to_status = @approved_by_agent) AS agent_approved,
+		COUNT(*) FILTER (WHERE d.to_status = @rejected_by_agent) AS agent_rejected`,
+		"d.loan_id IS NOT NULL", &rows)
+	return rows, err
+}
+
+// GetDecisionTimes measures the time from application to decision.
+func (r *ReportRepository) GetDecisionTimes(filter models.Filter) ([]*models.DecisionTime, error) {
+	const elapsed = "EXTRACT(EPOCH FROM d.changed_at - l.created_at)::float8"
+	rows := []*models.DecisionTime{}
+	err := r.query(filter, fmt.Sprintf(`
+		COUNT(*) AS decisions,
+		COALESCE(AVG(%[1]s), 0) AS avg_seconds,
+		COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY %[1]s), 0) AS median_seconds,
+		COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY %[1]s), 0) AS p90_seconds`, elapsed),
+		"d.loan_id IS NOT NULL", &rows)
+	return rows, err
+}
+
+// GetAmountDistributions describes requested amounts. The filter must group
+// by currency.
+func (r *ReportRepository) GetAmountDistributions(filter models.Filter) ([]*models.AmountDistribution, error) {
+	rows := []*models.AmountDistribution{}
+	err := r.query(filter, `
+		COUNT(*) AS applications,
+		SUM(l.loan_amount) AS total,
+		MIN(l.loan_amount) AS min,
+		MAX(l.loan_amount) AS max,
+		ROUND(AVG(l.loan_amount), 2) AS avg,
+		percentile_cont(0.5) WITHIN GROUP (ORDER BY l.loan_amount)::numeric(15,2) AS median,
+		percentile_cont(0.9) WITHIN GROUP (ORDER BY l.loan_amount)::numeric(15,2) AS p90`, "", &rows)
+	return rows, err
+}
+
+// GetDailyVolumes counts applications per day.
+func (r *ReportRepository) GetDailyVolumes(filter models.Filter) ([]*models.DailyVolume, error) {
+	rows := []*models.DailyVolume{}
+	err := r.query(filter, `
+		to_char(date_trunc('day', l.created_at), 'YYYY-MM-DD') AS day,
+		COUNT(*) AS applications`, "", &rows, "day")
+	return rows, err
+}
+
+// GetAgentStats measures the agent and every member of their team. With
+// rollup each row covers the member and everyone below them; without it,
+// only the member's own loans.
+func (r *ReportRepository) GetAgentStats(agentID int, from, to time.Time, sla time.Duration, rollup bool) ([]*models.AgentStats, error) {
+	members := "SELECT id AS root_id, id AS agent_id FROM team"
+	if rollup {
+		members = `SELECT id AS root_id, id AS agent_id FROM team
+			UNION
+			SELECT m.root_id, a.id FROM members m JOIN agents a ON a.manager_id = m.agent_id`
+	}
+	rows := []*models.AgentStats{}
+	err := r.db.DB.Raw(`
+		WITH RECURSIVE team AS (
+			SELECT id FROM agents WHERE id = @agent
+			UNION
+			SELECT a.id FROM agents a JOIN team t ON a.manager_id = t.id
+		),
+		members AS (`+members+`),
+		reviews AS (
+			SELECT la.agent_id, 'ASSIGNED' AS kind, NULL::float8 AS seconds
+			FROM loan_assignments la
+			WHERE la.assigned_at >= @from AND la.assigned_at < @to
+			UNION ALL
+			SELECT h.agent_id, h.to_status, EXTRACT(EPOCH FROM h.changed_at - a.assigned_at)::float8
+			FROM loan_status_changes h
+			JOIN LATERAL (
+				SELECT MAX(assigned_at) AS assigned_at FROM loan_assignments
+				WHERE loan_id = h.loan_id AND agent_id = h.agent_id AND assigned_at <= h.changed_at
+			) a ON true
+			WHERE h.to_status IN @decided AND h.changed_at >= @from AND h.changed_at < @to
+			UNION ALL
+			SELECT l.assigned_agent_id, 'QUEUED', EXTRACT(EPOCH FROM NOW() - a.assigned_at)::float8
+			FROM loans l
+			JOIN LATERAL (
+				SELECT MAX(assigned_at) AS assigned_at FROM loan_assignments
+				WHERE loan_id = l.id AND agent_id = l.assigned_agent_id
+			) a ON true
+			WHERE l.application_status = @under_review
+		)
+		SELECT m.root_id AS agent_id,
+			COUNT(*) FILTER (WHERE r.kind = 'ASSIGNED') AS assigned,
+			COUNT(*) FILTER (WHERE r.kind IN @decided) AS decided,
+			COUNT(*) FILTER (WHERE r.kind = @approved) AS approved,
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY r.seconds) FILTER (WHERE r.kind IN @decided), 0) AS median_review_seconds,
+			COUNT(*) FILTER (WHERE r.kind IN @decided AND r.seconds > @sla) AS sla_breaches,
+			COUNT(*) FILTER (WHERE r.kind = 'QUEUED') AS queue,
+			COUNT(*) FILTER (WHERE r.kind = 'QUEUED' AND r.seconds > @sla) AS overdue
+		FROM members m
+		LEFT JOIN reviews r ON r.agent_id = m.agent_id
+		GROUP BY m.root_id
+		ORDER BY m.root_id
+	`, map[string]interface{}{
+		"agent":        agentID,
+		"decided":      []loanModels.LoanStatus{loanModels.ApprovedByAgent, loanModels.RejectedByAgent},
+		"approved":     loanModels.ApprovedByAgent,
+		"under_review": loanModels.UnderReview,
+		"sla":          sla.Seconds(),
+		"from":         from,
+		"to":           to,
+	}).Scan(&rows).Error
+	return rows, err
+}
+
+// query runs an aggregate over the filter's applications, selecting and
+// grouping by the filter's groupings after any leading expressions.
+func (r *ReportRepository) query(filter models.Filter, aggregates, where string, dest interface{}, leading ...string) error {
+	keys := append([]string{}, leading...)
+	columns := make([]string, 0, len(filter.GroupBy)+1)
+	for _, group := range filter.GroupBy {
+		column, ok := groupColumns[group]
+		if !ok {
+			return fmt.Errorf("unsupported grouping %q", group)
+		}
+		columns = append(columns, fmt.Sprintf("%s AS %s", column, group))
+		keys = append(keys, string(group))
+	}
+	columns = append(columns, aggregates)
+
+	sql := decisionsCTE + "\n\tSELECT " + strings.Join(columns, ",") + reportFrom
+	if where != "" {
+		sql += "\n\tAND " + where
+	}
+	if len(keys) > 0 {
+		sql += "\n\tGROUP BY " + strings.Join(keys, ", ") + "\n\tORDER BY " + strings.Join(keys, ", ")
+	}
+	return r.db.DB.Raw(sql, map[string]interface{}{
+		"decided":  loanModels.DecisionStatuses,
+		"approved": loanModels.ApprovedStatuses,
+		"rejected": loanModels.RejectedStatuses,
+
+		"approved_by_system": loanModels.ApprovedBySystem,
+		"rejected_by_system": loanModels.RejectedBySystem,
+		"approved_by_agent":  loanModels.ApprovedByAgent,
+		"rejected_by_agent":  loanModels.RejectedByAgent,
+		"system":             models.DecidedBySystem,
+		"agent":              models.DecidedByAgent,
+
+		"from": filter.From,
+		"to":   filter.To,
+	}).Scan(dest).Error
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	agentRepo "loan-module/agent/repository"
+	"loan-module/apperror"
+	"loan-module/constants"
+	"loan-module/money"
+	"loan-module/report/models"
+	"loan-module/report/repository"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	ErrInvalidDateRange = apperror.Validation("INVALID_DATE_RANGE", "invalid date range")
+	ErrInvalidGrouping  = apperror.Validation("INVALID_GROUPING", "invalid grouping")
+	ErrAgentNotFound    = apperror.NotFound("AGENT_NOT_FOUND", "agent not found")
+	ErrNotManager       = apperror.NotFound("NOT_A_MANAGER", "agent does not manage anyone")
+)
+
+type ReportService struct {
+	repo      *repository.ReportRepository
+	agentRepo *agentRepo.AgentRepository
+}
+
+func NewReportService(repo *repository.ReportRepository, agentRepo *agentRepo.AgentRepository) *ReportService {
+	return &ReportService{repo: repo, agentRepo: agentRepo}
+}
+
+// Filter builds a report filter from the request's date range, see
+// dateRange, and comma-separated groupings. Without groupings it uses the
+// defaults.
+func (s *ReportService) Filter(from, to, groupBy string, defaults ...models.GroupBy) (models.Filter, error) {
+	filter := models.Filter{}
+	var err error
+	filter.From, filter.To, err = dateRange(from, to)
+	if err != nil {
+		return filter, err
+	}
+
+	if groupBy == "" {
+		filter.GroupBy = defaults
+		return filter, nil
+	}
+	seen := make(map[models.GroupBy]bool)
+	for _, name := range strings.Split(groupBy, ",") {
+		group := models.GroupBy(strings.TrimSpace(name))
+		if !group.IsValid() {
+			return filter, fmt.Errorf("%w %q: must be loan_type, channel, currency or decided_by", ErrInvalidGrouping, name)
+		}
+		if !seen[group] {
+			seen[group] = true
+			filter.GroupBy = append(filter.GroupBy, group)
+		}
+	}
+	return filter, nil
+}
+
+// ApprovalRates reports how many applications were approved out of those
+// decided.
+func (s *ReportService) ApprovalRates(filter models.Filter) (*models.Report, error) {
+	rows, err := s.repo.GetApprovalRates(filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		row.ApprovalRate = ratio(row.Approved, row.Decided)
+	}
+	return newReport(filter, rows), nil
+}
+
+// DecisionSources reports how many decisions the system made compared to
+// agents.
+func (s *ReportService) DecisionSources(filter models.Filter) (*models.Report, error) {
+	rows, err := s.repo.GetDecisionSources(filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		system := row.SystemApproved + row.SystemRejected
+		row.SystemShare = ratio(system, system+row.AgentApproved+row.AgentRejected)
+	}
+	return newReport(filter, rows), nil
+}
+
+// DecisionTimes reports the average, median and P90 time to decision.
+func (s *ReportService) DecisionTimes(filter models.Filter) (*models.Report, error) {
+	rows, err := s.repo.GetDecisionTimes(filter)
+	if err != nil {
+		return nil, err
+	}
+	return newReport(filter, rows), nil
+}
+
+// AmountDistributions reports the spread of requested amounts. Amounts are
+// never added across currencies, so the report is always grouped by
+// currency.
+func (s *ReportService) AmountDistributions(filter models.Filter) (*models.Report, error) {
+	grouped := false
+	for _, group := range filter.GroupBy {
+		grouped = grouped || group == models.GroupCurrency
+	}
+	if !grouped {
+		filter.GroupBy = append(filter.GroupBy, models.GroupCurrency)
+	}
+	rows, err := s.repo.GetAmountDistributions(filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		currency := money.Currency(row.Currency)
+		row.Total, row.Min, row.Max = row.Total.In(currency), row.Min.In(currency), row.Max.In(currency)
+		row.Avg, row.Median, row.P90 = row.Avg.In(currency), row.Median.In(currency), row.P90.In(currency)
+	}
+	return newReport(filter, rows), nil
+}
+
+// DailyVolumes reports the number of applications per day.
+func (s *ReportService) DailyVolumes(filter models.Filter) (*models.Report, error) {
+	rows, err := s.repo.GetDailyVolumes(filter)
+	if err != nil {
+		return nil, err
+	}
+	return newReport(filter, rows), nil
+}
+
+// AgentStats measures an agent's reviews in the date range and, when the
+// agent manages others, their whole team's.
+func (s *ReportService) AgentStats(agentID int, from, to string) (*models.AgentStatsResponse, error) {
+	start, end, err := dateRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	agent, exists := s.agentRepo.GetAgentByID(agentID)
+	if !exists {
+		return nil, ErrAgentNotFound
+	}
+	own, team, err := s.stats(agentID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	response := &models.AgentStatsResponse{
+		Agent:    agent,
+		From:     start.Format(dateLayout),
+		To:       end.AddDate(0, 0, -1).Format(dateLayout),
+		SLAHours: constants.ReviewSLA.Hours(),
+		Own:      own[agentID],
+	}
+	if len(s.agentRepo.GetTeam(agentID)) > 0 {
+		response.Team = team[agentID]
+	}
+	return response, nil
+}
+
+// TeamStats measures a manager's whole team in the date range, rolling each
+// member's reports up into the member's team stats.
+func (s *ReportService) TeamStats(managerID int, from, to string) (*models.TeamStatsResponse, error) {
+	start, end, err := dateRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	manager, exists := s.agentRepo.GetAgentByID(managerID)
+	if !exists {
+		return nil, ErrAgentNotFound
+	}
+	agents := s.agentRepo.GetTeam(managerID)
+	if len(agents) == 0 {
+		return nil, ErrNotManager
+	}
+	own, team, err := s.stats(managerID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	depths := map[int]int{managerID: 0}
+	managers := make(map[int]bool)
+	for _, agent := range agents {
+		depths[agent.ID] = depths[*agent.ManagerID] + 1
+		managers[*agent.ManagerID] = true
+	}
+	members := make([]*models.TeamMemberStats, 0, len(agents))
+	for _, agent := range agents {
+		member := &models.TeamMemberStats{Agent: agent, Depth: depths[agent.ID], Own: own[agent.ID]}
+		if managers[agent.ID] {
+			member.Team = team[agent.ID]
+		}
+		members = append(members, member)
+	}
+	return &models.TeamStatsResponse{
+		Manager:  manager,
+		From:     start.Format(dateLayout),
+		To:       end.AddDate(0, 0, -1).Format(dateLayout),
+		SLAHours: constants.ReviewSLA.Hours(),
+		Team:     team[managerID],
+		Members:  members,
+	}, nil
+}
+
+// stats returns the own and rolled-up stats of the agent and every member of
+// their team, by agent ID.
+func (s *ReportService) stats(agentID int, from, to time.Time) (map[int]*models.AgentStats, map[int]*models.AgentStats, error) {
+	own, err := s.repo.GetAgentStats(agentID, from, to, constants.ReviewSLA, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	team, err := s.repo.GetAgentStats(agentID, from, to, constants.ReviewSLA, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return byAgent(own), byAgent(team), nil
+}
+
+func byAgent(rows []*models.AgentStats) map[int]*models.AgentStats {
+	stats := make(map[int]*models.AgentStats, len(rows))
+	for _, row := range rows {
+		row.ApprovalRatio = ratio(row.Approved, row.Decided)
+		stats[row.AgentID] = row
+	}
+	return stats
+}
+
+// dateRange parses a report's from and to dates (YYYY-MM-DD, both inclusive)
+// into the half-open range [from, to + 1 day). Without dates it covers the
+// DefaultReportPeriod up to today.
+func dateRange(from, to string) (time.Time, time.Time, error) {
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	if to != "" {
+		parsed, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: to must be in YYYY-MM-DD format", ErrInvalidDateRange)
+		}
+		end = parsed
+	}
+	start := end.Add(-constants.DefaultReportPeriod).AddDate(0, 0, 1)
+	if from != "" {
+		parsed, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be in YYYY-MM-DD format", ErrInvalidDateRange)
+		}
+		start = parsed
+	}
+	end = end.AddDate(0, 0, 1)
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must not be after to", ErrInvalidDateRange)
+	}
+	if end.Sub(start) > constants.MaxReportPeriod {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: a report can cover at most %d days", ErrInvalidDateRange, constants.MaxReportPeriod/(24*time.Hour))
+	}
+	return start, end, nil
+}
+
+func newReport(filter models.Filter, rows interface{}) *models.Report {
+	groupBy := filter.GroupBy
+	if groupBy == nil {
+		groupBy = []models.GroupBy{}
+	}
+	return &models.Report{
+		From:    filter.From.Format(dateLayout),
+		To:      filter.To.AddDate(0, 0, -1).Format(dateLayout),
+		GroupBy: groupBy,
+		Rows:    rows,
+	}
+}
+
+// ratio divides part by whole to four decimal places, or returns 0 when
+// there is nothing to divide.
+func ratio(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(whole)*10000) / 10000
+}
@@ -14,7 +14,7 @@ type Database struct {
 }
 
 func NewDatabase(dsn string) *Database {
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatal("failed to connect to database: ", err)
 	}
@@ -24,8 +24,9 @@ func NewDatabase(dsn string) *Database {
 
 // NewDatabaseWithConfig creates a new database connection with configuration
 func NewDatabaseWithConfig(config *providers.Config) *Database {
-	// Create the database connection
-	db, err := gorm.Open(postgres.Open(config.GetDSN()), &gorm.Config{})
+	// Create the database connection. Errors are translated so that callers
+	// can check for gorm.ErrDuplicatedKey instead of driver error codes.
+	db, err := gorm.Open(postgres.Open(config.GetDSN()), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatal("failed to connect to database: ", err)
 	}
@@ -0,0 +1,11 @@
+package database
+
+import "strings"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// PrefixPattern returns a LIKE pattern matching values that start with
+// prefix, with any wildcards in prefix matched literally.
+func PrefixPattern(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
@@ -2,18 +2,47 @@
 CREATE TABLE customers (
     id SERIAL PRIMARY KEY,
     name VARCHAR(255) NOT NULL,
+    -- E.164, e.g. +919876543210
     phone VARCHAR(20) NOT NULL,
+    phone_verified_at TIMESTAMP WITH TIME ZONE,
     email VARCHAR(255),
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+    date_of_birth DATE,
+    national_id VARCHAR(50),
+    address_line1 VARCHAR(255),
+    address_line2 VARCHAR(255),
+    city VARCHAR(100),
+    state VARCHAR(100),
+    postal_code VARCHAR(20),
+    country VARCHAR(2),
+    employment_type VARCHAR(20) CHECK (employment_type IN (
+        'SALARIED', 'SELF_EMPLOYED', 'BUSINESS_OWNER', 'UNEMPLOYED', 'RETIRED', 'STUDENT'
+    )),
+    employer_name VARCHAR(255),
+    monthly_income DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (monthly_income >= 0),
+    -- NULL for incomes recorded without a currency
+    income_currency VARCHAR(3),
+    kyc_status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (kyc_status IN ('PENDING', 'VERIFIED', 'FAILED', 'EXPIRED')),
+    kyc_expires_at TIMESTAMP WITH TIME ZONE,
+    merged_into_id INTEGER REFERENCES customers(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
 );
 
-CREATE UNIQUE INDEX idx_customers_phone ON customers(phone);
+-- Deleted customers free their phone for reuse
+CREATE UNIQUE INDEX idx_customers_phone ON customers(phone) WHERE deleted_at IS NULL;
+CREATE INDEX idx_customers_email ON customers(LOWER(email));
+CREATE INDEX idx_customers_name ON customers(LOWER(name) text_pattern_ops);
+CREATE INDEX idx_customers_deleted_at ON customers(deleted_at);
 
 CREATE TABLE agents (
     id SERIAL PRIMARY KEY,
     name VARCHAR(255) NOT NULL,
     manager_id INTEGER,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    deactivated_at TIMESTAMP WITH TIME ZONE,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     
     
     CONSTRAINT fk_agents_manager 
@@ -24,18 +53,41 @@ CREATE TABLE agents (
 
 CREATE INDEX idx_agents_manager_id ON agents(manager_id);
 
+-- Seed a manager and two agents reporting to them
+INSERT INTO agents (id, name, manager_id) VALUES
+    (1, 'John Manager', NULL),
+    (2, 'Alice Agent', 1),
+    (3, 'Bob Agent', 1);
+
+SELECT setval('agents_id_seq', (SELECT MAX(id) FROM agents));
+
 
 CREATE TABLE loans (
     id SERIAL PRIMARY KEY,
     customer_id INTEGER NOT NULL,
+    applicant_name VARCHAR(255),
     loan_amount DECIMAL(15,2) NOT NULL,
-    loan_type VARCHAR(20) NOT NULL CHECK (loan_type IN ('PERSONAL', 'HOME', 'AUTO', 'BUSINESS')),
+    currency VARCHAR(3) NOT NULL DEFAULT 'INR',
+    loan_type VARCHAR(30) NOT NULL,
+    channel VARCHAR(20) NOT NULL DEFAULT 'DIRECT' CHECK (channel IN ('DIRECT', 'BRANCH', 'MOBILE', 'PARTNER')),
     application_status VARCHAR(30) NOT NULL CHECK (application_status IN (
-        'APPLIED', 'PROCESSING', 'APPROVED_BY_SYSTEM', 'REJECTED_BY_SYSTEM', 
-        'UNDER_REVIEW', 'APPROVED_BY_AGENT', 'REJECTED_BY_AGENT'
+        'APPLIED', 'OTP_PENDING', 'KYC_PENDING', 'PROCESSING', 'APPROVED_BY_SYSTEM', 'REJECTED_BY_SYSTEM', 
+        'FRAUD_REVIEW', 'UNDER_REVIEW', 'APPROVED_BY_AGENT', 'REJECTED_BY_AGENT', 'CLOSED'
     )),
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     assigned_agent_id INTEGER,
+    review_team_id INTEGER,
+    tenure_months INTEGER NOT NULL DEFAULT 0 CHECK (tenure_months >= 0),
+    risk_grade VARCHAR(5),
+    quoted_rate DECIMAL(9,6),
+    quoted_apr DECIMAL(9,6),
+    final_rate DECIMAL(9,6),
+    final_apr DECIMAL(9,6),
+    processing_fee DECIMAL(15,2),
+    rate_card_version VARCHAR(50),
+    quote_id INTEGER,
+    parent_loan_id INTEGER,
     
     -- Foreign key constraints
     CONSTRAINT fk_loans_customer 
@@ -46,12 +98,70 @@ CREATE TABLE loans (
     CONSTRAINT fk_loans_assigned_agent 
         FOREIGN KEY (assigned_agent_id) 
         REFERENCES agents(id) 
+        ON DELETE SET NULL,
+
+    CONSTRAINT fk_loans_review_team
+        FOREIGN KEY (review_team_id)
+        REFERENCES agents(id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT fk_loans_parent
+        FOREIGN KEY (parent_loan_id)
+        REFERENCES loans(id)
         ON DELETE SET NULL
 );
 
 
 CREATE INDEX idx_loans_customer_id ON loans(customer_id);
+CREATE INDEX idx_loans_customer_status ON loans(customer_id, application_status);
+CREATE INDEX idx_loans_customer_created_at ON loans(customer_id, created_at);
 CREATE INDEX idx_loans_assigned_agent_id ON loans(assigned_agent_id);
+CREATE INDEX idx_loans_review_team_id ON loans(review_team_id, application_status);
+CREATE INDEX idx_loans_parent_loan_id ON loans(parent_loan_id);
+CREATE INDEX idx_loans_created_at ON loans(created_at);
+
+CREATE TABLE kyc_verifications (
+    id SERIAL PRIMARY KEY,
+    customer_id INTEGER NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    document_type VARCHAR(30) NOT NULL CHECK (document_type IN (
+        'PASSPORT', 'NATIONAL_ID_CARD', 'DRIVING_LICENSE', 'VOTER_ID_CARD'
+    )),
+    document_number VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PENDING', 'VERIFIED', 'FAILED', 'EXPIRED')),
+    reason TEXT,
+    reference_id VARCHAR(100),
+    verified_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_kyc_verifications_customer
+        FOREIGN KEY (customer_id)
+        REFERENCES customers(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_kyc_verifications_customer_id ON kyc_verifications(customer_id);
+
+-- One-time codes sent to verify a customer's phone. Only a SHA-256 hash of
+-- the code is kept.
+CREATE TABLE otps (
+    id SERIAL PRIMARY KEY,
+    customer_id INTEGER NOT NULL,
+    phone VARCHAR(20) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    verified_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_otps_customer
+        FOREIGN KEY (customer_id)
+        REFERENCES customers(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_otps_customer_id ON otps(customer_id, created_at);
 
 CREATE TABLE loan_assignments (
     id SERIAL PRIMARY KEY,
@@ -74,3 +184,376 @@ CREATE TABLE loan_assignments (
 -- Create indexes for foreign key relationships
 CREATE INDEX idx_loan_assignments_loan_id ON loan_assignments(loan_id);
 CREATE INDEX idx_loan_assignments_agent_id ON loan_assignments(agent_id);
+
+-- Every status a loan has moved through, written alongside each status change
+CREATE TABLE loan_status_changes (
+    id SERIAL PRIMARY KEY,
+    loan_id INTEGER NOT NULL,
+    from_status VARCHAR(30),
+    to_status VARCHAR(30) NOT NULL,
+    agent_id INTEGER,
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_loan_status_changes_loan
+        FOREIGN KEY (loan_id)
+        REFERENCES loans(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_loan_status_changes_agent
+        FOREIGN KEY (agent_id)
+        REFERENCES agents(id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX idx_loan_status_changes_loan_id ON loan_status_changes(loan_id, changed_at);
+CREATE INDEX idx_loan_status_changes_to_status ON loan_status_changes(to_status, changed_at);
+
+CREATE TABLE loan_documents (
+    id SERIAL PRIMARY KEY,
+    loan_id INTEGER NOT NULL,
+    document_type VARCHAR(30) NOT NULL CHECK (document_type IN (
+        'ID_PROOF', 'INCOME_PROOF', 'PROPERTY_PAPERS', 'FINANCIAL_STATEMENTS'
+    )),
+    file_name VARCHAR(255) NOT NULL,
+    mime_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+    checksum VARCHAR(64) NOT NULL,
+    storage_key VARCHAR(512) NOT NULL,
+    uploaded_by_type VARCHAR(20) NOT NULL CHECK (uploaded_by_type IN ('AGENT', 'CUSTOMER')),
+    uploaded_by_id INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_loan_documents_loan
+        FOREIGN KEY (loan_id)
+        REFERENCES loans(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_loan_documents_loan_id ON loan_documents(loan_id);
+
+CREATE TABLE credit_reports (
+    id SERIAL PRIMARY KEY,
+    loan_id INTEGER NOT NULL,
+    customer_id INTEGER NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    bureau_reference VARCHAR(100),
+    has_history BOOLEAN NOT NULL,
+    score INTEGER NOT NULL,
+    -- Currency of the bureau's amounts, NULL when it did not report one
+    currency VARCHAR(3),
+    monthly_obligations DECIMAL(15,2) NOT NULL DEFAULT 0,
+    outstanding_balance DECIMAL(15,2) NOT NULL DEFAULT 0,
+    active_accounts INTEGER NOT NULL DEFAULT 0,
+    defaults INTEGER NOT NULL DEFAULT 0,
+    from_cache BOOLEAN NOT NULL DEFAULT FALSE,
+    pulled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_credit_reports_loan
+        FOREIGN KEY (loan_id)
+        REFERENCES loans(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_credit_reports_customer
+        FOREIGN KEY (customer_id)
+        REFERENCES customers(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_credit_reports_loan_id ON credit_reports(loan_id);
+CREATE INDEX idx_credit_reports_customer_pulled_at ON credit_reports(customer_id, pulled_at);
+
+CREATE TABLE affordability_assessments (
+    id SERIAL PRIMARY KEY,
+    loan_id INTEGER NOT NULL,
+    monthly_income DECIMAL(15,2) NOT NULL,
+    existing_obligations DECIMAL(15,2) NOT NULL,
+    co_applicant_income DECIMAL(15,2) NOT NULL DEFAULT 0,
+    co_applicant_obligations DECIMAL(15,2) NOT NULL DEFAULT 0,
+    tenure_months INTEGER NOT NULL,
+    annual_rate DECIMAL(7,4) NOT NULL,
+    proposed_emi DECIMAL(15,2) NOT NULL,
+    dti DECIMAL(9,4) NOT NULL,
+    foir DECIMAL(9,4) NOT NULL,
+    refer_dti DECIMAL(5,4) NOT NULL,
+    max_dti DECIMAL(5,4) NOT NULL,
+    refer_foir DECIMAL(5,4) NOT NULL,
+    max_foir DECIMAL(5,4) NOT NULL,
+    max_affordable_amount DECIMAL(15,2) NOT NULL,
+    outcome VARCHAR(10) NOT NULL CHECK (outcome IN ('PASS', 'REFER', 'REJECT')),
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_affordability_assessments_loan
+        FOREIGN KEY (loan_id)
+        REFERENCES loans(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_affordability_assessments_loan_id ON affordability_assessments(loan_id);
+
+CREATE TABLE fraud_checks (
+    id SERIAL PRIMARY KEY,
+    loan_id INTEGER NOT NULL,
+    score INTEGER NOT NULL,
+    signals JSONB NOT NULL DEFAULT '[]',
+    status VARCHAR(20) NOT NULL CHECK (status IN ('PASSED', 'PENDING_REVIEW', 'CLEARED', 'CONFIRMED')),
+    reviewed_by INTEGER,
+    review_note TEXT,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_fraud_checks_loan
+        FOREIGN KEY (loan_id)
+        REFERENCES loans(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_fraud_checks_reviewer
+        FOREIGN KEY (reviewed_by)
+        REFERENCES agents(id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX idx_fraud_checks_loan_id ON fraud_checks(loan_id);
+CREATE INDEX idx_fraud_checks_status ON fraud_checks(status);
+
+CREATE TABLE loan_quotes (
+    id SERIAL PRIMARY KEY,
+    customer_phone VARCHAR(20) NOT NULL,
+    loan_type VARCHAR(30) NOT NULL,
+    loan_amount DECIMAL(15,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'INR',
+    max_amount DECIMAL(15,2) NOT NULL,
+    tenure_months INTEGER NOT NULL,
+    annual_rate DECIMAL(9,6) NOT NULL,
+    apr DECIMAL(9,6) NOT NULL,
+    processing_fee DECIMAL(15,2) NOT NULL,
+    risk_grade VARCHAR(5) NOT NULL,
+    rate_card_version VARCHAR(50) NOT NULL,
+    decision VARCHAR(10) NOT NULL CHECK (decision IN ('ELIGIBLE', 'REFER', 'DECLINE')),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    converted_loan_id INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_loan_quotes_converted_loan
+        FOREIGN KEY (converted_loan_id)
+        REFERENCES loans(id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX idx_loan_quotes_customer_phone ON loan_quotes(customer_phone);
+
+ALTER TABLE loans
+    ADD CONSTRAINT fk_loans_quote
+        FOREIGN KEY (quote_id)
+        REFERENCES loan_quotes(id)
+        ON DELETE SET NULL;
+
+CREATE TABLE loan_products (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(30) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    currency VARCHAR(3) NOT NULL DEFAULT 'INR',
+    min_amount DECIMAL(15,2) NOT NULL CHECK (min_amount > 0),
+    max_amount DECIMAL(15,2) NOT NULL,
+    min_tenure_months INTEGER NOT NULL CHECK (min_tenure_months > 0),
+    max_tenure_months INTEGER NOT NULL,
+    eligible_segments JSONB NOT NULL DEFAULT '[]',
+    required_documents JSONB NOT NULL DEFAULT '[]',
+    rate_card_version VARCHAR(50),
+    active_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    active_to TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_loan_products_amounts CHECK (max_amount >= min_amount),
+    CONSTRAINT chk_loan_products_tenure CHECK (max_tenure_months >= min_tenure_months),
+    CONSTRAINT chk_loan_products_active CHECK (active_to IS NULL OR active_to > active_from)
+);
+
+CREATE INDEX idx_loan_products_code ON loan_products(code, active_from);
+
+-- Seed the original loan types as open-ended products
+INSERT INTO loan_products (code, name, min_amount, max_amount, min_tenure_months, max_tenure_months, required_documents, active_from) VALUES
+    ('PERSONAL', 'Personal Loan', 1000, 2000000, 6, 84, '["ID_PROOF", "INCOME_PROOF"]', '2020-01-01T00:00:00Z'),
+    ('HOME', 'Home Loan', 100000, 50000000, 60, 360, '["ID_PROOF", "INCOME_PROOF", "PROPERTY_PAPERS"]', '2020-01-01T00:00:00Z'),
+    ('AUTO', 'Auto Loan', 10000, 5000000, 12, 96, '["ID_PROOF", "INCOME_PROOF"]', '2020-01-01T00:00:00Z'),
+    ('BUSINESS', 'Business Loan', 50000, 20000000, 12, 120, '["ID_PROOF", "FINANCIAL_STATEMENTS"]', '2020-01-01T00:00:00Z');
+
+-- Amount ranges of a product in currencies other than its own
+CREATE TABLE product_limits (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    min_amount DECIMAL(15,2) NOT NULL CHECK (min_amount > 0),
+    max_amount DECIMAL(15,2) NOT NULL,
+
+    CONSTRAINT fk_product_limits_product
+        FOREIGN KEY (product_id)
+        REFERENCES loan_products(id)
+        ON DELETE CASCADE,
+    CONSTRAINT chk_product_limits_amounts CHECK (max_amount >= min_amount),
+    CONSTRAINT uq_product_limits_currency UNIQUE (product_id, currency)
+);
+
+CREATE TABLE collaterals (
+    id SERIAL PRIMARY KEY,
+    loan_id INTEGER NOT NULL,
+    asset_type VARCHAR(20) NOT NULL CHECK (asset_type IN ('PROPERTY', 'VEHICLE', 'DEPOSIT')),
+    description TEXT,
+    identifier VARCHAR(100) NOT NULL,
+    owner_name VARCHAR(255) NOT NULL,
+    lien_status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (lien_status IN ('NONE', 'PENDING', 'MARKED', 'RELEASED')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_collaterals_loan
+        FOREIGN KEY (loan_id)
+        REFERENCES loans(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_collaterals_loan_id ON collaterals(loan_id);
+
+CREATE TABLE valuations (
+    id SERIAL PRIMARY KEY,
+    collateral_id INTEGER NOT NULL,
+    value DECIMAL(15,2) NOT NULL CHECK (value > 0),
+    valued_on DATE NOT NULL,
+    valuer VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_valuations_collateral
+        FOREIGN KEY (collateral_id)
+        REFERENCES collaterals(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_valuations_collateral_id ON valuations(collateral_id);
+
+CREATE TABLE loan_parties (
+    id SERIAL PRIMARY KEY,
+    loan_id INTEGER NOT NULL,
+    customer_id INTEGER NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('CO_APPLICANT', 'GUARANTOR')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_loan_parties_loan
+        FOREIGN KEY (loan_id)
+        REFERENCES loans(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_loan_parties_customer
+        FOREIGN KEY (customer_id)
+        REFERENCES customers(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT uq_loan_parties_loan_customer UNIQUE (loan_id, customer_id)
+);
+
+CREATE INDEX idx_loan_parties_customer_id ON loan_parties(customer_id);
+
+CREATE TABLE repayment_schedules (
+    id SERIAL PRIMARY KEY,
+    loan_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('ACTIVE', 'SUPERSEDED', 'CLOSED')),
+    reason VARCHAR(40) NOT NULL,
+    principal DECIMAL(15,2) NOT NULL,
+    annual_rate DECIMAL(9,6) NOT NULL,
+    tenure_months INTEGER NOT NULL,
+    emi DECIMAL(15,2) NOT NULL,
+    start_date DATE NOT NULL,
+    disbursed_on DATE NOT NULL,
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_repayment_schedules_loan
+        FOREIGN KEY (loan_id)
+        REFERENCES loans(id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT uq_repayment_schedules_loan_version UNIQUE (loan_id, version)
+);
+
+CREATE TABLE instalments (
+    id SERIAL PRIMARY KEY,
+    schedule_id INTEGER NOT NULL,
+    number INTEGER NOT NULL,
+    due_date DATE NOT NULL,
+    opening_principal DECIMAL(15,2) NOT NULL,
+    emi DECIMAL(15,2) NOT NULL,
+    principal DECIMAL(15,2) NOT NULL,
+    interest DECIMAL(15,2) NOT NULL,
+    closing_principal DECIMAL(15,2) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('DUE', 'PAID', 'CANCELLED')),
+    paid_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT fk_instalments_schedule
+        FOREIGN KEY (schedule_id)
+        REFERENCES repayment_schedules(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_instalments_schedule_id ON instalments(schedule_id, number);
+
+CREATE TABLE ledger_entries (
+    id SERIAL PRIMARY KEY,
+    loan_id INTEGER NOT NULL,
+    entry_type VARCHAR(20) NOT NULL CHECK (entry_type IN ('DISBURSEMENT', 'INSTALMENT', 'PART_PAYMENT', 'FORECLOSURE', 'RESTRUCTURE')),
+    value_date DATE NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    principal DECIMAL(15,2) NOT NULL DEFAULT 0,
+    interest DECIMAL(15,2) NOT NULL DEFAULT 0,
+    charges DECIMAL(15,2) NOT NULL DEFAULT 0,
+    balance DECIMAL(15,2) NOT NULL,
+    schedule_version INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_ledger_entries_loan
+        FOREIGN KEY (loan_id)
+        REFERENCES loans(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_ledger_entries_loan_id ON ledger_entries(loan_id, value_date);
+
+CREATE TABLE exchange_rates (
+    id SERIAL PRIMARY KEY,
+    base_currency VARCHAR(3) NOT NULL,
+    quote_currency VARCHAR(3) NOT NULL,
+    rate DECIMAL(18,8) NOT NULL CHECK (rate > 0),
+    source VARCHAR(10) NOT NULL CHECK (source IN ('FILE', 'ADMIN')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_exchange_rates_pair ON exchange_rates(base_currency, quote_currency, created_at);
+
+CREATE TABLE currency_conversions (
+    id SERIAL PRIMARY KEY,
+    purpose VARCHAR(50) NOT NULL,
+    rate_id INTEGER NOT NULL,
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    rate DECIMAL(18,8) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    converted_amount DECIMAL(15,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_currency_conversions_rate
+        FOREIGN KEY (rate_id)
+        REFERENCES exchange_rates(id)
+);
+
+-- Every SMS and push notification sent. The recipient is a phone number for
+-- SMS and an agent ID for push notifications.
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    channel VARCHAR(10) NOT NULL CHECK (channel IN ('SMS', 'PUSH')),
+    recipient VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_recipient ON notifications(channel, recipient, sent_at);
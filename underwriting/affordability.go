@@ -0,0 +1,119 @@
+package underwriting
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	loanModels "loan-module/loan/models"
+	"loan-module/money"
+)
+
+// Input is the customer and application data an affordability check needs.
+// Co-applicants' income and obligations are pooled with the applicant's.
+type Input struct {
+	LoanAmount             money.Money
+	TenureMonths           int
+	MonthlyIncome          money.Money
+	ExistingObligations    money.Money
+	CoApplicantIncome      money.Money
+	CoApplicantObligations money.Money
+}
+
+// EMI returns the equated monthly instalment for a principal repaid over the
+// given number of months at an annual rate expressed as a fraction. The
+// instalment is rounded half up so that it never falls short of the schedule.
+func EMI(principal money.Money, annualRate float64, months int) money.Money {
+	if months <= 0 {
+		return money.New(0, principal.Currency())
+	}
+	r := annualRate / 12
+	if r == 0 {
+		return principal.Div(int64(months), money.Up)
+	}
+	factor := math.Pow(1+r, float64(months))
+	return principal.Mul(r*factor/(factor-1), money.HalfUp)
+}
+
+// PrincipalForEMI is the inverse of EMI: the largest principal an instalment
+// can service over the given term, rounded down so that it stays affordable.
+func PrincipalForEMI(emi money.Money, annualRate float64, months int) money.Money {
+	if !emi.IsPositive() || months <= 0 {
+		return money.New(0, emi.Currency())
+	}
+	r := annualRate / 12
+	if r == 0 {
+		return emi.Mul(float64(months), money.Down)
+	}
+	factor := math.Pow(1+r, float64(months))
+	return emi.Mul((factor-1)/(r*factor), money.Down)
+}
+
+// Assess computes the proposed EMI, DTI, FOIR and maximum affordable amount
+// for an application and grades it against the policy's ratio limits.
+func Assess(policy Policy, in Input) *loanModels.AffordabilityAssessment {
+	tenure := in.TenureMonths
+	if tenure <= 0 {
+		tenure = policy.DefaultTenureMonths
+	}
+
+	emi := EMI(in.LoanAmount, policy.AnnualRate, tenure)
+	assessment := &loanModels.AffordabilityAssessment{
+		MonthlyIncome:          in.MonthlyIncome,
+		ExistingObligations:    in.ExistingObligations,
+		CoApplicantIncome:      in.CoApplicantIncome,
+		CoApplicantObligations: in.CoApplicantObligations,
+		TenureMonths:           tenure,
+		AnnualRate:             policy.AnnualRate,
+		ProposedEMI:            emi,
+		ReferDTI:               policy.ReferDTI,
+		MaxDTI:                 policy.MaxDTI,
+		ReferFOIR:              policy.ReferFOIR,
+		MaxFOIR:                policy.MaxFOIR,
+		Outcome:                loanModels.AffordabilityPass,
+	}
+
+	income := in.MonthlyIncome.Add(in.CoApplicantIncome)
+	obligations := in.ExistingObligations.Add(in.CoApplicantObligations)
+	if !income.IsPositive() {
+		assessment.Outcome = loanModels.AffordabilityRefer
+		assessment.Reason = "monthly income not declared"
+		return assessment
+	}
+
+	dti := obligations.Ratio(income)
+	foir := obligations.Add(emi).Ratio(income)
+	headroom := income.Mul(policy.MaxFOIR, money.Down).Sub(obligations)
+
+	assessment.DTI = round4(dti)
+	assessment.FOIR = round4(foir)
+	assessment.MaxAffordableAmount = PrincipalForEMI(headroom, policy.AnnualRate, tenure)
+
+	var rejects, refers []string
+	switch {
+	case dti > policy.MaxDTI:
+		rejects = append(rejects, fmt.Sprintf("DTI %.2f exceeds maximum %.2f", dti, policy.MaxDTI))
+	case dti > policy.ReferDTI:
+		refers = append(refers, fmt.Sprintf("DTI %.2f exceeds referral limit %.2f", dti, policy.ReferDTI))
+	}
+	switch {
+	case foir > policy.MaxFOIR:
+		rejects = append(rejects, fmt.Sprintf("FOIR %.2f exceeds maximum %.2f", foir, policy.MaxFOIR))
+	case foir > policy.ReferFOIR:
+		refers = append(refers, fmt.Sprintf("FOIR %.2f exceeds referral limit %.2f", foir, policy.ReferFOIR))
+	}
+
+	switch {
+	case len(rejects) > 0:
+		assessment.Outcome = loanModels.AffordabilityReject
+		assessment.Reason = strings.Join(append(rejects, refers...), "; ")
+	case len(refers) > 0:
+		assessment.Outcome = loanModels.AffordabilityRefer
+		assessment.Reason = strings.Join(refers, "; ")
+	}
+	return assessment
+}
+
+func round4(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
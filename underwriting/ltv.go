@@ -0,0 +1,38 @@
+package underwriting
+
+import (
+	"fmt"
+
+	loanModels "loan-module/loan/models"
+	"loan-module/providers"
+)
+
+// LTVLimits holds the maximum loan-to-value ratio for each secured loan type.
+// Loan types without a limit are unsecured.
+type LTVLimits map[loanModels.LoanType]float64
+
+// DefaultLTVLimits are used when no LTV limits are configured.
+var DefaultLTVLimits = LTVLimits{
+	loanModels.Home: 0.80,
+	loanModels.Auto: 0.85,
+}
+
+func LTVLimitsFromConfig(configs []providers.LTVLimitConfig) (LTVLimits, error) {
+	if len(configs) == 0 {
+		return DefaultLTVLimits, nil
+	}
+	limits := make(LTVLimits, len(configs))
+	for _, cfg := range configs {
+		if cfg.MaxLTV <= 0 || cfg.MaxLTV > 1 {
+			return nil, fmt.Errorf("ltv limit %s: maxLTV must be in (0, 1]", cfg.LoanType)
+		}
+		limits[loanModels.LoanType(cfg.LoanType)] = cfg.MaxLTV
+	}
+	return limits, nil
+}
+
+// For returns the LTV limit for a loan type and whether the type is secured.
+func (l LTVLimits) For(loanType loanModels.LoanType) (float64, bool) {
+	limit, ok := l[loanType]
+	return limit, ok
+}
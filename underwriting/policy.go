@@ -0,0 +1,72 @@
+package underwriting
+
+import (
+	"fmt"
+
+	loanModels "loan-module/loan/models"
+	"loan-module/providers"
+)
+
+// Policy holds the affordability limits for one loan type. Ratios are
+// fractions of monthly income; an application above a Refer limit goes to an
+// agent, above a Max limit it is rejected.
+type Policy struct {
+	LoanType            loanModels.LoanType
+	AnnualRate          float64
+	DefaultTenureMonths int
+	ReferDTI            float64
+	MaxDTI              float64
+	ReferFOIR           float64
+	MaxFOIR             float64
+}
+
+// DefaultPolicies are used for loan types with no configured policy.
+var DefaultPolicies = map[loanModels.LoanType]Policy{
+	loanModels.Personal: {LoanType: loanModels.Personal, AnnualRate: 0.14, DefaultTenureMonths: 36, ReferDTI: 0.35, MaxDTI: 0.50, ReferFOIR: 0.50, MaxFOIR: 0.60},
+	loanModels.Home:     {LoanType: loanModels.Home, AnnualRate: 0.09, DefaultTenureMonths: 240, ReferDTI: 0.35, MaxDTI: 0.50, ReferFOIR: 0.55, MaxFOIR: 0.65},
+	loanModels.Auto:     {LoanType: loanModels.Auto, AnnualRate: 0.10, DefaultTenureMonths: 60, ReferDTI: 0.35, MaxDTI: 0.50, ReferFOIR: 0.50, MaxFOIR: 0.60},
+	loanModels.Business: {LoanType: loanModels.Business, AnnualRate: 0.15, DefaultTenureMonths: 48, ReferDTI: 0.40, MaxDTI: 0.55, ReferFOIR: 0.55, MaxFOIR: 0.65},
+}
+
+// Policies looks up the affordability policy for a loan type.
+type Policies map[loanModels.LoanType]Policy
+
+// PoliciesFromConfig overlays the configured policies on DefaultPolicies.
+func PoliciesFromConfig(configs []providers.AffordabilityPolicyConfig) (Policies, error) {
+	policies := make(Policies, len(DefaultPolicies))
+	for loanType, policy := range DefaultPolicies {
+		policies[loanType] = policy
+	}
+
+	for _, cfg := range configs {
+		policy := Policy{
+			LoanType:            loanModels.LoanType(cfg.LoanType),
+			AnnualRate:          cfg.AnnualRate,
+			DefaultTenureMonths: cfg.DefaultTenureMonths,
+			ReferDTI:            cfg.ReferDTI,
+			MaxDTI:              cfg.MaxDTI,
+			ReferFOIR:           cfg.ReferFOIR,
+			MaxFOIR:             cfg.MaxFOIR,
+		}
+		if policy.DefaultTenureMonths <= 0 {
+			return nil, fmt.Errorf("affordability policy %s: defaultTenureMonths must be positive", cfg.LoanType)
+		}
+		if policy.ReferDTI > policy.MaxDTI || policy.ReferFOIR > policy.MaxFOIR {
+			return nil, fmt.Errorf("affordability policy %s: refer limits must not exceed max limits", cfg.LoanType)
+		}
+		policies[policy.LoanType] = policy
+	}
+	return policies, nil
+}
+
+// Has reports whether a policy is configured for the loan type.
+func (p Policies) Has(loanType loanModels.LoanType) bool {
+	_, ok := p[loanType]
+	return ok
+}
+
+// For returns the policy for a loan type, and false when none is configured.
+func (p Policies) For(loanType loanModels.LoanType) (Policy, bool) {
+	policy, ok := p[loanType]
+	return policy, ok
+}
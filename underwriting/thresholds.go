@@ -0,0 +1,54 @@
+package underwriting
+
+import (
+	"fmt"
+
+	"loan-module/constants"
+	"loan-module/money"
+	"loan-module/providers"
+)
+
+// ApprovalThreshold bounds the amounts the system decides on its own in one
+// currency: loans under Min can be approved automatically and loans over Max
+// are rejected.
+type ApprovalThreshold struct {
+	Min money.Money
+	Max money.Money
+}
+
+// ApprovalThresholds holds the automatic approval thresholds per currency.
+// Loans in a currency without thresholds always go to an agent.
+type ApprovalThresholds map[money.Currency]ApprovalThreshold
+
+// DefaultApprovalThresholds are used when no thresholds are configured.
+var DefaultApprovalThresholds = ApprovalThresholds{
+	money.DefaultCurrency: {Min: constants.MinAmountApproveBySystem, Max: constants.MaxAmountApproveBySystem},
+}
+
+func ApprovalThresholdsFromConfig(configs []providers.ApprovalThresholdConfig) (ApprovalThresholds, error) {
+	if len(configs) == 0 {
+		return DefaultApprovalThresholds, nil
+	}
+	thresholds := make(ApprovalThresholds, len(configs))
+	for _, cfg := range configs {
+		currency := money.Currency(cfg.Currency)
+		if !currency.IsValid() {
+			return nil, fmt.Errorf("approval threshold: unsupported currency %q", cfg.Currency)
+		}
+		threshold := ApprovalThreshold{
+			Min: money.FromFloat(cfg.MinAmount, currency, money.HalfUp),
+			Max: money.FromFloat(cfg.MaxAmount, currency, money.HalfUp),
+		}
+		if !threshold.Min.IsPositive() || !threshold.Max.GreaterThan(threshold.Min) {
+			return nil, fmt.Errorf("approval threshold %s: need 0 < minAmount < maxAmount", currency)
+		}
+		thresholds[currency] = threshold
+	}
+	return thresholds, nil
+}
+
+// For returns the thresholds for a currency and whether it has any.
+func (t ApprovalThresholds) For(currency money.Currency) (ApprovalThreshold, bool) {
+	threshold, ok := t[currency]
+	return threshold, ok
+}